@@ -15,26 +15,28 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/imitator/mode": {
+        "/imitator/chaos": {
             "get": {
-                "description": "true - auto, false - manual",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "Imitator"
                 ],
-                "summary": "method returns imitator mode",
+                "summary": "method returns the state of the chaos mode",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/apiserver.mode"
+                            "$ref": "#/definitions/model.ChaosProgress"
                         }
                     }
                 }
-            },
-            "put": {
+            }
+        },
+        "/imitator/chaos/start": {
+            "post": {
+                "description": "auto mode only, the auto mode cycle is suspended until stopped. The same seed reproduces the run",
                 "consumes": [
                     "application/json"
                 ],
@@ -44,15 +46,14 @@ const docTemplate = `{
                 "tags": [
                     "Imitator"
                 ],
-                "summary": "method updates imitator mode",
+                "summary": "method starts the random mains outages and flickers of the [chaos] config",
                 "parameters": [
                     {
-                        "description": "mode",
+                        "description": "options",
                         "name": "input",
                         "in": "body",
-                        "required": true,
                         "schema": {
-                            "$ref": "#/definitions/apiserver.mode"
+                            "$ref": "#/definitions/model.ChaosStartForm"
                         }
                     }
                 ],
@@ -62,11 +63,54 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/apiserver.statusBody"
                         }
+                    },
+                    "400": {
+                        "description": "invalid payload",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.errorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "manual mode",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.errorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "not configured, already running or a scenario is running",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.errorResponse"
+                        }
                     }
                 }
             }
         },
-        "/imitator/ups": {
+        "/imitator/chaos/stop": {
+            "post": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Imitator"
+                ],
+                "summary": "method stops the chaos mode, the mains is restored",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.statusBody"
+                        }
+                    },
+                    "422": {
+                        "description": "not running",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/imitator/clock": {
             "get": {
                 "produces": [
                     "application/json"
@@ -74,22 +118,20 @@ const docTemplate = `{
                 "tags": [
                     "Imitator"
                 ],
-                "summary": "method returns all ups params",
+                "summary": "method returns the simulated time",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/model.UpsParams"
-                            }
+                            "$ref": "#/definitions/model.ClockStatus"
                         }
                     }
                 }
             }
         },
-        "/imitator/ups/alarms": {
-            "patch": {
+        "/imitator/clock/forward": {
+            "post": {
+                "description": "in steps of ups_sync_interval, paused or not, the params are sent at the end",
                 "consumes": [
                     "application/json"
                 ],
@@ -99,15 +141,15 @@ const docTemplate = `{
                 "tags": [
                     "Imitator"
                 ],
-                "summary": "method updates ups alarms",
+                "summary": "method advances the simulation by the duration at once",
                 "parameters": [
                     {
-                        "description": "alarms",
+                        "description": "duration",
                         "name": "input",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/model.AlarmsUpdateForm"
+                            "$ref": "#/definitions/apiserver.fastForward"
                         }
                     }
                 ],
@@ -125,7 +167,13 @@ const docTemplate = `{
                         }
                     },
                     "403": {
-                        "description": "auto mode",
+                        "description": "manual mode",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.errorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "invalid duration",
                         "schema": {
                             "$ref": "#/definitions/apiserver.errorResponse"
                         }
@@ -133,7 +181,119 @@ const docTemplate = `{
                 }
             }
         },
-        "/imitator/ups/params": {
+        "/imitator/clock/pause": {
+            "post": {
+                "description": "the simulated time and the recalculation freeze, the state is kept",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Imitator"
+                ],
+                "summary": "method pauses the simulation",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.statusBody"
+                        }
+                    },
+                    "403": {
+                        "description": "manual mode",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.errorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "already paused",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/imitator/clock/resume": {
+            "post": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Imitator"
+                ],
+                "summary": "method resumes the paused simulation",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.statusBody"
+                        }
+                    },
+                    "403": {
+                        "description": "manual mode",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.errorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "not paused",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/imitator/clock/step": {
+            "post": {
+                "description": "paused only, the simulated time advances by ups_sync_interval and the params are sent",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Imitator"
+                ],
+                "summary": "method performs exactly one recalculation step",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.statusBody"
+                        }
+                    },
+                    "403": {
+                        "description": "manual mode",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.errorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "not paused",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/imitator/generator": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Imitator"
+                ],
+                "summary": "method returns the state of the generator and the ATS",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.GeneratorParams"
+                        }
+                    }
+                }
+            },
             "patch": {
                 "consumes": [
                     "application/json"
@@ -144,7 +304,7 @@ const docTemplate = `{
                 "tags": [
                     "Imitator"
                 ],
-                "summary": "method updates ups params",
+                "summary": "method refuels the generator",
                 "parameters": [
                     {
                         "description": "params",
@@ -152,7 +312,7 @@ const docTemplate = `{
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/model.UpsParamsUpdateForm"
+                            "$ref": "#/definitions/model.GeneratorUpdateForm"
                         }
                     }
                 ],
@@ -169,8 +329,8 @@ const docTemplate = `{
                             "$ref": "#/definitions/apiserver.errorResponse"
                         }
                     },
-                    "403": {
-                        "description": "auto mode",
+                    "422": {
+                        "description": "generator disabled or invalid fuel level",
                         "schema": {
                             "$ref": "#/definitions/apiserver.errorResponse"
                         }
@@ -178,8 +338,26 @@ const docTemplate = `{
                 }
             }
         },
-        "/imitator/ups/{bat_id}": {
-            "patch": {
+        "/imitator/load": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Imitator"
+                ],
+                "summary": "method returns the active load profile",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.loadProfile"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "the profile must be configured, it starts from the beginning",
                 "consumes": [
                     "application/json"
                 ],
@@ -189,23 +367,16 @@ const docTemplate = `{
                 "tags": [
                     "Imitator"
                 ],
-                "summary": "method updates ups battery params",
+                "summary": "method switches the load profile",
                 "parameters": [
                     {
-                        "description": "params",
+                        "description": "profile",
                         "name": "input",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/model.BatteryParamsUpdateForm"
+                            "$ref": "#/definitions/apiserver.loadProfile"
                         }
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Battery id",
-                        "name": "bat_id",
-                        "in": "path",
-                        "required": true
                     }
                 ],
                 "responses": {
@@ -221,113 +392,1938 @@ const docTemplate = `{
                             "$ref": "#/definitions/apiserver.errorResponse"
                         }
                     },
-                    "403": {
-                        "description": "auto mode",
-                        "schema": {
-                            "$ref": "#/definitions/apiserver.errorResponse"
-                        }
-                    },
                     "422": {
-                        "description": "Unprocessable Entity",
+                        "description": "unknown or not configured profile",
                         "schema": {
                             "$ref": "#/definitions/apiserver.errorResponse"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "apiserver.errorResponse": {
-            "type": "object",
-            "properties": {
-                "error": {
-                    "type": "string"
-                }
-            }
-        },
-        "apiserver.mode": {
-            "type": "object",
-            "properties": {
-                "mode": {
-                    "type": "boolean",
-                    "example": false
-                }
-            }
         },
-        "apiserver.statusBody": {
-            "type": "object",
-            "properties": {
-                "status": {
-                    "type": "string"
+        "/imitator/mode": {
+            "get": {
+                "description": "true - auto, false - manual",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Imitator"
+                ],
+                "summary": "method returns imitator mode",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.mode"
+                        }
+                    }
                 }
-            }
-        },
-        "model.Alarms": {
-            "type": "object",
-            "properties": {
-                "low_battery": {
-                    "type": "boolean",
-                    "example": false
-                },
-                "overload": {
-                    "type": "boolean",
-                    "example": false
-                },
-                "upc_in_battery_mode": {
-                    "type": "boolean",
+            },
+            "put": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Imitator"
+                ],
+                "summary": "method updates imitator mode",
+                "parameters": [
+                    {
+                        "description": "mode",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.mode"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.statusBody"
+                        }
+                    }
+                }
+            }
+        },
+        "/imitator/pins/{param}": {
+            "put": {
+                "description": "allowed in both modes, the pins are shown in GET /imitator/ups",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Imitator"
+                ],
+                "summary": "method pins the param at the value, the rest of the model keeps running and reacts to it",
+                "parameters": [
+                    {
+                        "description": "value and optional expiry",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.PinForm"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Param name with the optional index, e.g. battery_temp[2]",
+                        "name": "param",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.statusBody"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid payload",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.errorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "unknown param or invalid params",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.errorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Imitator"
+                ],
+                "summary": "method releases the pinned param, it follows the model again",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Param name",
+                        "name": "param",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.statusBody"
+                        }
+                    },
+                    "422": {
+                        "description": "param not pinned",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/imitator/replay": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Imitator"
+                ],
+                "summary": "method returns the progress of the replay",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.ReplayProgress"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "CSV with a header row, the first column is the time, or an OpenTSDB JSON export.\nThe columns are mapped onto the params by the [replay] config or by their names, a running replay is stopped",
+                "consumes": [
+                    "text/plain"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Imitator"
+                ],
+                "summary": "method uploads recorded telemetry for the replay",
+                "parameters": [
+                    {
+                        "description": "recording",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.statusBody"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid recording or no column mapped",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/imitator/replay/start": {
+            "post": {
+                "description": "auto mode only, the speed and the looping default to the [replay] config",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Imitator"
+                ],
+                "summary": "method starts the replay of the loaded recording from the beginning",
+                "parameters": [
+                    {
+                        "description": "options",
+                        "name": "input",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/model.ReplayStartForm"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.statusBody"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid payload",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.errorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "manual mode",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.errorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "no recording loaded, already running or invalid speed",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/imitator/replay/stop": {
+            "post": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Imitator"
+                ],
+                "summary": "method stops the replay",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.statusBody"
+                        }
+                    },
+                    "422": {
+                        "description": "not running",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/imitator/scenario": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Imitator"
+                ],
+                "summary": "method returns the progress of the uploaded scenario",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.ScenarioProgress"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "a timeline of actions in YAML or JSON, a running scenario is stopped",
+                "consumes": [
+                    "text/plain"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Imitator"
+                ],
+                "summary": "method uploads the scenario",
+                "parameters": [
+                    {
+                        "description": "scenario",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.Scenario"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.statusBody"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid scenario",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/imitator/scenario/start": {
+            "post": {
+                "description": "auto mode only, the auto mode cycle is suspended until the scenario ends",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Imitator"
+                ],
+                "summary": "method starts the uploaded scenario from the beginning",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.statusBody"
+                        }
+                    },
+                    "403": {
+                        "description": "manual mode",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.errorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "no scenario uploaded, already running or the chaos mode is running",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/imitator/scenario/stop": {
+            "post": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Imitator"
+                ],
+                "summary": "method stops the running scenario",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.statusBody"
+                        }
+                    },
+                    "422": {
+                        "description": "not running",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/imitator/schedule": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Imitator"
+                ],
+                "summary": "method returns the scheduled jobs with their next and last runs",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.ScheduledJobStatus"
+                            }
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "the jobs run in both modes, on the simulated time unless wall_clock of the [schedule] config is set",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Imitator"
+                ],
+                "summary": "method replaces the scheduled jobs",
+                "parameters": [
+                    {
+                        "description": "jobs",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.ScheduledJob"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.statusBody"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid payload",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.errorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "invalid jobs",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/imitator/schedule/{name}": {
+            "put": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Imitator"
+                ],
+                "summary": "method adds the scheduled job or replaces the one with the name",
+                "parameters": [
+                    {
+                        "description": "job, the name is taken from the path",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.ScheduledJob"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Job name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.statusBody"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid payload",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.errorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "invalid job",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.errorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Imitator"
+                ],
+                "summary": "method removes the scheduled job",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Job name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.statusBody"
+                        }
+                    },
+                    "422": {
+                        "description": "job not found",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/imitator/sensors": {
+            "get": {
+                "description": "by the param name, the params without a sensor are measured exactly",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Imitator"
+                ],
+                "summary": "method returns the sensors of the measured params",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "$ref": "#/definitions/model.SensorConfig"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/imitator/sensors/{name}": {
+            "put": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Imitator"
+                ],
+                "summary": "method sets the sensor of the param",
+                "parameters": [
+                    {
+                        "description": "sensor",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.SensorConfig"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Param name, e.g. input_ac_voltage, battery_temp",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.statusBody"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid payload",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.errorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "unknown sensor or invalid params",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.errorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Imitator"
+                ],
+                "summary": "method removes the sensor of the param, the param is measured exactly",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Param name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.statusBody"
+                        }
+                    },
+                    "422": {
+                        "description": "sensor not configured",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/imitator/ups": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Imitator"
+                ],
+                "summary": "method returns all ups params",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.UpsParams"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/imitator/ups/alarms": {
+            "patch": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Imitator"
+                ],
+                "summary": "method updates ups alarms",
+                "parameters": [
+                    {
+                        "description": "alarms",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.AlarmsUpdateForm"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.statusBody"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid payload",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.errorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "auto mode",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/imitator/ups/battery_test": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Imitator"
+                ],
+                "summary": "method returns the result of the last battery self-test",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.BatteryTestParams"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "auto mode only, the load is transferred to battery for the duration of the [battery_test] config, the result is stored in the UPS params",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Imitator"
+                ],
+                "summary": "method starts the battery self-test",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.statusBody"
+                        }
+                    },
+                    "403": {
+                        "description": "manual mode",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.errorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "already running or the load is not on the inverter",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/imitator/ups/bypass": {
+            "post": {
+                "description": "the load stays on bypass until the return request, also in auto mode",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Imitator"
+                ],
+                "summary": "method transfers the load to the static bypass",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.statusBody"
+                        }
+                    },
+                    "422": {
+                        "description": "bypass unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.errorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Imitator"
+                ],
+                "summary": "method returns the load from the static bypass to the inverter",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.statusBody"
+                        }
+                    },
+                    "422": {
+                        "description": "bypass not requested",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/imitator/ups/inverter_fault": {
+            "post": {
+                "description": "the load is transferred to the static bypass, the output is shut down if the bypass is unavailable, also in auto mode",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Imitator"
+                ],
+                "summary": "method fails the inverter",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.statusBody"
+                        }
+                    },
+                    "422": {
+                        "description": "inverter already failed",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.errorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Imitator"
+                ],
+                "summary": "method repairs the failed inverter, the load returns to it",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.statusBody"
+                        }
+                    },
+                    "422": {
+                        "description": "inverter not failed",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/imitator/ups/params": {
+            "patch": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Imitator"
+                ],
+                "summary": "method updates ups params",
+                "parameters": [
+                    {
+                        "description": "params",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.UpsParamsUpdateForm"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.statusBody"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid payload",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.errorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "auto mode",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/imitator/ups/phases/{phase_id}": {
+            "patch": {
+                "description": "three-phase mode only",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Imitator"
+                ],
+                "summary": "method updates ups phase params",
+                "parameters": [
+                    {
+                        "description": "params",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.PhaseParamsUpdateForm"
+                        }
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Phase id: 0 - L1, 1 - L2, 2 - L3",
+                        "name": "phase_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.statusBody"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid payload",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.errorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "auto mode",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.errorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/imitator/ups/{bat_id}": {
+            "patch": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Imitator"
+                ],
+                "summary": "method updates ups battery params",
+                "parameters": [
+                    {
+                        "description": "params",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.BatteryParamsUpdateForm"
+                        }
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Battery id",
+                        "name": "bat_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.statusBody"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid payload",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.errorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "auto mode",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.errorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/imitator/ups/{bat_id}/faults": {
+            "post": {
+                "description": "the fault evolves while the UPS is running, also in auto mode",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Imitator"
+                ],
+                "summary": "method injects a fault into the battery",
+                "parameters": [
+                    {
+                        "description": "fault",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.batteryFault"
+                        }
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Battery id",
+                        "name": "bat_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.statusBody"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid payload",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.errorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "unknown or already injected fault",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.errorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/imitator/ups/{bat_id}/faults/{fault}": {
+            "delete": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Imitator"
+                ],
+                "summary": "method clears the fault of the battery",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Battery id",
+                        "name": "bat_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "shorted_cell",
+                            "open_cell",
+                            "high_resistance",
+                            "thermal_runaway"
+                        ],
+                        "type": "string",
+                        "description": "Fault",
+                        "name": "fault",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.statusBody"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid battery id",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.errorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "unknown or not injected fault",
+                        "schema": {
+                            "$ref": "#/definitions/apiserver.errorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "apiserver.batteryFault": {
+            "type": "object",
+            "properties": {
+                "fault": {
+                    "type": "string",
+                    "enum": [
+                        "shorted_cell",
+                        "open_cell",
+                        "high_resistance",
+                        "thermal_runaway"
+                    ],
+                    "example": "shorted_cell"
+                }
+            }
+        },
+        "apiserver.errorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                }
+            }
+        },
+        "apiserver.fastForward": {
+            "type": "object",
+            "properties": {
+                "duration": {
+                    "description": "sec",
+                    "type": "number",
+                    "example": 600
+                }
+            }
+        },
+        "apiserver.loadProfile": {
+            "type": "object",
+            "properties": {
+                "profile": {
+                    "type": "string",
+                    "enum": [
+                        "constant",
+                        "daily",
+                        "steps",
+                        "random_walk",
+                        "csv"
+                    ],
+                    "example": "daily"
+                }
+            }
+        },
+        "apiserver.mode": {
+            "type": "object",
+            "properties": {
+                "mode": {
+                    "type": "boolean",
+                    "example": false
+                }
+            }
+        },
+        "apiserver.statusBody": {
+            "type": "object",
+            "properties": {
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.Alarms": {
+            "type": "object",
+            "properties": {
+                "bypass_unavailable": {
+                    "description": "bypass input out of the window or not synchronized",
+                    "type": "boolean",
+                    "example": false
+                },
+                "input_fault": {
+                    "description": "input voltage or frequency out of the windows",
+                    "type": "boolean",
+                    "example": false
+                },
+                "low_battery": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "on_bypass": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "overload": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "phase_imbalance": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "phase_loss": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "upc_in_battery_mode": {
+                    "type": "boolean",
+                    "example": false
+                }
+            }
+        },
+        "model.AlarmsUpdateForm": {
+            "type": "object",
+            "properties": {
+                "bypass_unavailable": {
+                    "type": "boolean",
                     "example": false
+                },
+                "input_fault": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "low_battery": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "on_bypass": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "overload": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "phase_imbalance": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "phase_loss": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "upc_in_battery_mode": {
+                    "type": "boolean",
+                    "example": false
+                }
+            }
+        },
+        "model.BatteryParams": {
+            "type": "object",
+            "properties": {
+                "faults": {
+                    "type": "array",
+                    "items": {
+                        "type": "string",
+                        "enum": [
+                            "shorted_cell",
+                            "open_cell",
+                            "high_resistance",
+                            "thermal_runaway"
+                        ]
+                    }
+                },
+                "resist": {
+                    "description": "mOhm, internal resistance",
+                    "type": "number",
+                    "example": 5
+                },
+                "temp": {
+                    "description": "°C",
+                    "type": "number",
+                    "example": 24
+                },
+                "voltage": {
+                    "description": "V",
+                    "type": "number",
+                    "example": 12
+                }
+            }
+        },
+        "model.BatteryParamsUpdateForm": {
+            "type": "object",
+            "properties": {
+                "resist": {
+                    "type": "number",
+                    "example": 5
+                },
+                "temp": {
+                    "type": "number",
+                    "example": 24
+                },
+                "voltage": {
+                    "type": "number",
+                    "example": 12
+                }
+            }
+        },
+        "model.BatteryTestParams": {
+            "type": "object",
+            "properties": {
+                "reason": {
+                    "description": "of the failed or aborted test",
+                    "type": "string",
+                    "example": "low soc"
+                },
+                "resist": {
+                    "description": "mOhm, of the battery group, measured at the transfer",
+                    "type": "number",
+                    "example": 20
+                },
+                "result": {
+                    "type": "string",
+                    "enum": [
+                        "none",
+                        "running",
+                        "passed",
+                        "warning",
+                        "failed",
+                        "aborted"
+                    ],
+                    "example": "passed"
+                },
+                "soh": {
+                    "description": "from 0 to 1, state of health",
+                    "type": "number",
+                    "example": 1
+                },
+                "time": {
+                    "description": "simulated, of the start",
+                    "type": "string"
+                },
+                "voltage_drop": {
+                    "description": "V, of the battery group at the end of the test",
+                    "type": "number",
+                    "example": 1.2
+                }
+            }
+        },
+        "model.ChaosProgress": {
+            "type": "object",
+            "properties": {
+                "elapsed": {
+                    "description": "sec since the start",
+                    "type": "number",
+                    "example": 86400
+                },
+                "flickers": {
+                    "description": "started so far",
+                    "type": "integer",
+                    "example": 30
+                },
+                "mains_on": {
+                    "description": "as driven by the chaos mode",
+                    "type": "boolean",
+                    "example": true
+                },
+                "next_event": {
+                    "description": "simulated time of the next change of the mains",
+                    "type": "string",
+                    "example": "2024-07-01T12:00:00Z"
+                },
+                "outages": {
+                    "description": "started so far",
+                    "type": "integer",
+                    "example": 12
+                },
+                "seed": {
+                    "description": "reproduces the run",
+                    "type": "integer",
+                    "example": 42
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "idle",
+                        "running",
+                        "stopped"
+                    ],
+                    "example": "running"
+                }
+            }
+        },
+        "model.ChaosStartForm": {
+            "type": "object",
+            "properties": {
+                "seed": {
+                    "type": "integer",
+                    "example": 42
+                }
+            }
+        },
+        "model.ClockStatus": {
+            "type": "object",
+            "properties": {
+                "paused": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "speed": {
+                    "description": "the simulated time runs faster than the wall clock",
+                    "type": "number",
+                    "example": 60
+                },
+                "time": {
+                    "type": "string",
+                    "example": "2024-07-01T12:00:00Z"
+                }
+            }
+        },
+        "model.Condition": {
+            "type": "object",
+            "properties": {
+                "index": {
+                    "description": "of the battery or the phase",
+                    "type": "integer"
+                },
+                "op": {
+                    "description": "\u003c, \u003c=, \u003e, \u003e=, ==, !=",
+                    "type": "string"
+                },
+                "param": {
+                    "type": "string"
+                },
+                "value": {
+                    "type": "number"
+                }
+            }
+        },
+        "model.GeneratorParams": {
+            "type": "object",
+            "properties": {
+                "frequency": {
+                    "description": "Hz",
+                    "type": "number",
+                    "example": 0
+                },
+                "fuel_level": {
+                    "description": "from 0 to 1",
+                    "type": "number",
+                    "example": 1
+                },
+                "source": {
+                    "type": "string",
+                    "enum": [
+                        "mains",
+                        "generator"
+                    ],
+                    "example": "mains"
+                },
+                "start_attempts": {
+                    "description": "of the current start",
+                    "type": "integer",
+                    "example": 0
+                },
+                "state": {
+                    "type": "string",
+                    "enum": [
+                        "standby",
+                        "starting",
+                        "warm_up",
+                        "running",
+                        "failed"
+                    ],
+                    "example": "standby"
+                },
+                "voltage": {
+                    "description": "V",
+                    "type": "number",
+                    "example": 0
                 }
             }
         },
-        "model.AlarmsUpdateForm": {
+        "model.GeneratorUpdateForm": {
             "type": "object",
             "properties": {
-                "low_battery": {
-                    "type": "boolean",
-                    "example": false
+                "fuel_level": {
+                    "description": "from 0 to 1",
+                    "type": "number",
+                    "example": 1
+                }
+            }
+        },
+        "model.PhaseParams": {
+            "type": "object",
+            "properties": {
+                "input_ac_current": {
+                    "description": "Amp",
+                    "type": "number",
+                    "example": 1.7
                 },
-                "overload": {
+                "input_ac_voltage": {
+                    "description": "V",
+                    "type": "number",
+                    "example": 220
+                },
+                "output_ac_current": {
+                    "description": "Amp",
+                    "type": "number",
+                    "example": 1.7
+                },
+                "output_ac_voltage": {
+                    "description": "V",
+                    "type": "number",
+                    "example": 220
+                }
+            }
+        },
+        "model.PhaseParamsUpdateForm": {
+            "type": "object",
+            "properties": {
+                "input_ac_current": {
+                    "description": "Amp",
+                    "type": "number",
+                    "example": 1.7
+                },
+                "input_ac_voltage": {
+                    "description": "V",
+                    "type": "number",
+                    "example": 220
+                },
+                "output_ac_current": {
+                    "description": "Amp",
+                    "type": "number",
+                    "example": 1.7
+                },
+                "output_ac_voltage": {
+                    "description": "V",
+                    "type": "number",
+                    "example": 220
+                }
+            }
+        },
+        "model.Pin": {
+            "type": "object",
+            "properties": {
+                "expires": {
+                    "description": "simulated time, never if absent",
+                    "type": "string",
+                    "example": "2024-07-01T12:10:00Z"
+                },
+                "param": {
+                    "type": "string",
+                    "example": "battery_temp[2]"
+                },
+                "value": {
+                    "type": "number",
+                    "example": 45
+                }
+            }
+        },
+        "model.PinForm": {
+            "type": "object",
+            "properties": {
+                "duration": {
+                    "description": "sec, until unpinned if 0",
+                    "type": "number",
+                    "example": 600
+                },
+                "value": {
+                    "type": "number",
+                    "example": 45
+                }
+            }
+        },
+        "model.ReplayProgress": {
+            "type": "object",
+            "properties": {
+                "columns": {
+                    "description": "column -\u003e param",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "duration": {
+                    "description": "sec",
+                    "type": "number",
+                    "example": 3600
+                },
+                "iteration": {
+                    "description": "from 1",
+                    "type": "integer",
+                    "example": 1
+                },
+                "loop": {
                     "type": "boolean",
-                    "example": false
+                    "example": true
                 },
-                "upc_in_battery_mode": {
+                "position": {
+                    "description": "sec of the recording",
+                    "type": "number",
+                    "example": 125
+                },
+                "speed": {
+                    "type": "number",
+                    "example": 10
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "idle",
+                        "running",
+                        "finished",
+                        "stopped"
+                    ],
+                    "example": "running"
+                }
+            }
+        },
+        "model.ReplayStartForm": {
+            "type": "object",
+            "properties": {
+                "loop": {
                     "type": "boolean",
-                    "example": false
+                    "example": true
+                },
+                "speed": {
+                    "type": "number",
+                    "example": 10
                 }
             }
         },
-        "model.BatteryParams": {
+        "model.Scenario": {
             "type": "object",
             "properties": {
-                "resist": {
+                "name": {
+                    "type": "string"
+                },
+                "steps": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.ScenarioStep"
+                    }
+                }
+            }
+        },
+        "model.ScenarioProgress": {
+            "type": "object",
+            "properties": {
+                "elapsed": {
+                    "description": "sec since the start",
                     "type": "number",
-                    "example": 5
+                    "example": 125
+                },
+                "error": {
+                    "type": "string"
+                },
+                "iteration": {
+                    "description": "of the innermost loop, from 1, 0 - not in a loop",
+                    "type": "integer",
+                    "example": 2
+                },
+                "name": {
+                    "type": "string",
+                    "example": "night outage"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "idle",
+                        "running",
+                        "finished",
+                        "stopped",
+                        "failed"
+                    ],
+                    "example": "running"
+                },
+                "step": {
+                    "description": "the current step, nested steps are separated by dots",
+                    "type": "string",
+                    "example": "3.1"
+                }
+            }
+        },
+        "model.ScenarioStep": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "at": {
+                    "description": "since the start of the scenario",
+                    "type": "string"
+                },
+                "battery": {
+                    "type": "integer"
+                },
+                "duration": {
+                    "description": "of the load ramp",
+                    "type": "string"
+                },
+                "fault": {
+                    "type": "string"
+                },
+                "frequency": {
+                    "description": "Hz",
+                    "type": "number"
+                },
+                "if": {
+                    "$ref": "#/definitions/model.Condition"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "power": {
+                    "description": "W",
+                    "type": "number"
+                },
+                "profile": {
+                    "type": "string"
+                },
+                "repeat": {
+                    "description": "times, 0 - until the Until condition holds",
+                    "type": "integer"
+                },
+                "resist": {
+                    "description": "mOhm",
+                    "type": "number"
+                },
+                "steps": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.ScenarioStep"
+                    }
                 },
                 "temp": {
-                    "type": "number",
-                    "example": 24
+                    "description": "°C",
+                    "type": "number"
+                },
+                "timeout": {
+                    "description": "of WaitUntil, the scenario fails after, 0 - none",
+                    "type": "string"
+                },
+                "until": {
+                    "description": "checked before each iteration",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.Condition"
+                        }
+                    ]
                 },
                 "voltage": {
+                    "description": "V",
+                    "type": "number"
+                },
+                "wait": {
+                    "type": "string"
+                },
+                "wait_until": {
+                    "$ref": "#/definitions/model.Condition"
+                }
+            }
+        },
+        "model.ScheduledJob": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string",
+                    "enum": [
+                        "mains_off",
+                        "mains_on",
+                        "set_load",
+                        "load_profile",
+                        "set_mode",
+                        "battery_test"
+                    ],
+                    "example": "mains_off"
+                },
+                "cron": {
+                    "description": "minute hour day-of-month month day-of-week",
+                    "type": "string",
+                    "example": "0 2 * * sat"
+                },
+                "mode": {
+                    "description": "of set_mode",
+                    "type": "string",
+                    "enum": [
+                        "auto",
+                        "manual"
+                    ],
+                    "example": "auto"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "maintenance"
+                },
+                "power": {
+                    "description": "W, of set_load",
                     "type": "number",
-                    "example": 12
+                    "example": 1500
+                },
+                "profile": {
+                    "description": "of load_profile",
+                    "type": "string",
+                    "example": "daily"
                 }
             }
         },
-        "model.BatteryParamsUpdateForm": {
+        "model.ScheduledJobStatus": {
             "type": "object",
             "properties": {
-                "resist": {
+                "action": {
+                    "type": "string",
+                    "enum": [
+                        "mains_off",
+                        "mains_on",
+                        "set_load",
+                        "load_profile",
+                        "set_mode",
+                        "battery_test"
+                    ],
+                    "example": "mains_off"
+                },
+                "cron": {
+                    "description": "minute hour day-of-month month day-of-week",
+                    "type": "string",
+                    "example": "0 2 * * sat"
+                },
+                "last_error": {
+                    "type": "string"
+                },
+                "last_run": {
+                    "type": "string",
+                    "example": "2024-06-29T02:00:00Z"
+                },
+                "mode": {
+                    "description": "of set_mode",
+                    "type": "string",
+                    "enum": [
+                        "auto",
+                        "manual"
+                    ],
+                    "example": "auto"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "maintenance"
+                },
+                "next_run": {
+                    "description": "absent if the schedule never fires",
+                    "type": "string",
+                    "example": "2024-07-06T02:00:00Z"
+                },
+                "power": {
+                    "description": "W, of set_load",
                     "type": "number",
-                    "example": 5
+                    "example": 1500
                 },
-                "temp": {
+                "profile": {
+                    "description": "of load_profile",
+                    "type": "string",
+                    "example": "daily"
+                }
+            }
+        },
+        "model.SensorConfig": {
+            "type": "object",
+            "properties": {
+                "drift": {
+                    "description": "relative standard deviation of the random walk drift after an hour",
                     "type": "number",
-                    "example": 24
+                    "example": 0
                 },
-                "voltage": {
+                "dropout_probability": {
+                    "description": "from 0 to 1, of each reading, the reading is 0",
                     "type": "number",
-                    "example": 12
+                    "example": 0
+                },
+                "gain": {
+                    "description": "relative calibration gain error",
+                    "type": "number",
+                    "example": 0
+                },
+                "noise": {
+                    "description": "relative standard deviation of the gaussian noise",
+                    "type": "number",
+                    "example": 0.01
+                },
+                "offset": {
+                    "description": "calibration offset in the param units",
+                    "type": "number",
+                    "example": 0
+                },
+                "resolution": {
+                    "description": "quantization step of the ADC in the param units, 0 - none",
+                    "type": "number",
+                    "example": 0.1
+                },
+                "spike_amplitude": {
+                    "description": "relative",
+                    "type": "number",
+                    "example": 0
+                },
+                "spike_probability": {
+                    "description": "from 0 to 1, of each reading",
+                    "type": "number",
+                    "example": 0
+                },
+                "stuck": {
+                    "description": "the reading stays at the last value",
+                    "type": "boolean",
+                    "example": false
                 }
             }
         },
@@ -358,6 +2354,23 @@ const docTemplate = `{
                     "type": "number",
                     "example": 50
                 },
+                "battery_test": {
+                    "$ref": "#/definitions/model.BatteryTestParams"
+                },
+                "charger_stage": {
+                    "type": "string",
+                    "enum": [
+                        "off",
+                        "bulk",
+                        "absorption",
+                        "float",
+                        "equalize"
+                    ],
+                    "example": "float"
+                },
+                "generator": {
+                    "$ref": "#/definitions/model.GeneratorParams"
+                },
                 "input_ac_current": {
                     "description": "Amp",
                     "type": "number",
@@ -368,16 +2381,110 @@ const docTemplate = `{
                     "type": "number",
                     "example": 220
                 },
+                "input_active_power": {
+                    "description": "W",
+                    "type": "number",
+                    "example": 1100
+                },
+                "input_apparent_power": {
+                    "description": "VA",
+                    "type": "number",
+                    "example": 1120
+                },
+                "input_current_thd": {
+                    "description": "percent",
+                    "type": "number",
+                    "example": 4
+                },
+                "input_frequency": {
+                    "description": "Hz",
+                    "type": "number",
+                    "example": 50
+                },
+                "input_power_factor": {
+                    "description": "from 0 to 1",
+                    "type": "number",
+                    "example": 0.98
+                },
+                "inverter_efficiency": {
+                    "description": "from 0 to 1",
+                    "type": "number",
+                    "example": 0.94
+                },
                 "load_current": {
                     "description": "Amp",
                     "type": "number",
                     "example": 20
                 },
+                "load_percent": {
+                    "description": "percent of the rated power",
+                    "type": "number",
+                    "example": 37
+                },
+                "operating_mode": {
+                    "type": "string",
+                    "enum": [
+                        "online",
+                        "on_battery",
+                        "bypass",
+                        "eco",
+                        "shutdown"
+                    ],
+                    "example": "online"
+                },
+                "output_ac_current": {
+                    "description": "Amp",
+                    "type": "number",
+                    "example": 5
+                },
+                "output_ac_voltage": {
+                    "description": "V",
+                    "type": "number",
+                    "example": 220
+                },
+                "output_active_power": {
+                    "description": "W",
+                    "type": "number",
+                    "example": 1000
+                },
+                "output_apparent_power": {
+                    "description": "VA",
+                    "type": "number",
+                    "example": 1111
+                },
+                "output_frequency": {
+                    "description": "Hz",
+                    "type": "number",
+                    "example": 50
+                },
+                "output_power_factor": {
+                    "description": "from 0 to 1, of the load",
+                    "type": "number",
+                    "example": 0.9
+                },
+                "phases": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.PhaseParams"
+                    }
+                },
+                "pins": {
+                    "description": "held by the user, see Pin",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Pin"
+                    }
+                },
                 "remaining_battery_capacity": {
                     "description": "Ah",
                     "type": "number",
                     "example": 50
                 },
+                "runtime": {
+                    "description": "min, estimated time to empty at the present load",
+                    "type": "number",
+                    "example": 25
+                },
                 "soc": {
                     "description": "state of charge (percent)",
                     "type": "number",
@@ -407,6 +2514,11 @@ const docTemplate = `{
                     "description": "V",
                     "type": "number",
                     "example": 220
+                },
+                "input_frequency": {
+                    "description": "Hz",
+                    "type": "number",
+                    "example": 50
                 }
             }
         }
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/alex11prog/ups-imitator/internal/app/model"
 	"github.com/gin-gonic/gin"
@@ -40,6 +41,507 @@ func (s *server) handlerUpdateMode(c *gin.Context) {
 	c.JSON(http.StatusOK, statusBody{"OK"})
 }
 
+//	@Summary	method returns the state of the generator and the ATS
+//	@Tags		Imitator
+//	@Produce	json
+//	@Success	200	{object}	model.GeneratorParams
+//	@Router		/imitator/generator [get]
+func (s *server) handlerGetGenerator(c *gin.Context) {
+	c.JSON(http.StatusOK, s.imitator.GetGenerator())
+}
+
+//	@Summary	method refuels the generator
+//	@Tags		Imitator
+//	@Accept		json
+//	@Param		input	body	model.GeneratorUpdateForm	true	"params"
+//	@Produce	json
+//	@Success	200	{object}	statusBody
+//	@Failure	400	{object}	errorResponse	"invalid payload"
+//	@Failure	422	{object}	errorResponse	"generator disabled or invalid fuel level"
+//	@Router		/imitator/generator [patch]
+func (s *server) handlerUpdateGenerator(c *gin.Context) {
+	var input model.GeneratorUpdateForm
+	if err := c.BindJSON(&input); err != nil {
+		s.errorResponse(c, http.StatusBadRequest, err)
+		return
+	}
+	if err := s.imitator.UpdateGenerator(input); err != nil {
+		s.errorResponse(c, http.StatusUnprocessableEntity, err)
+		return
+	}
+	c.JSON(http.StatusOK, statusBody{"OK"})
+}
+
+//	@Summary		method returns the sensors of the measured params
+//	@Description	by the param name, the params without a sensor are measured exactly
+//	@Tags			Imitator
+//	@Produce		json
+//	@Success		200	{object}	map[string]model.SensorConfig
+//	@Router			/imitator/sensors [get]
+func (s *server) handlerGetSensors(c *gin.Context) {
+	c.JSON(http.StatusOK, s.imitator.GetSensors())
+}
+
+//	@Summary	method sets the sensor of the param
+//	@Tags		Imitator
+//	@Accept		json
+//	@Param		input	body	model.SensorConfig	true	"sensor"
+//	@Produce	json
+//	@Param		name	path		string	true	"Param name, e.g. input_ac_voltage, battery_temp"
+//	@Success	200		{object}	statusBody
+//	@Failure	400		{object}	errorResponse	"invalid payload"
+//	@Failure	422		{object}	errorResponse	"unknown sensor or invalid params"
+//	@Router		/imitator/sensors/{name} [put]
+func (s *server) handlerUpdateSensor(c *gin.Context) {
+	var input model.SensorConfig
+	if err := c.BindJSON(&input); err != nil {
+		s.errorResponse(c, http.StatusBadRequest, err)
+		return
+	}
+	if err := s.imitator.SetSensor(c.Param("name"), input); err != nil {
+		s.errorResponse(c, http.StatusUnprocessableEntity, err)
+		return
+	}
+	c.JSON(http.StatusOK, statusBody{"OK"})
+}
+
+//	@Summary	method removes the sensor of the param, the param is measured exactly
+//	@Tags		Imitator
+//	@Produce	json
+//	@Param		name	path		string	true	"Param name"
+//	@Success	200		{object}	statusBody
+//	@Failure	422		{object}	errorResponse	"sensor not configured"
+//	@Router		/imitator/sensors/{name} [delete]
+func (s *server) handlerDeleteSensor(c *gin.Context) {
+	if err := s.imitator.DeleteSensor(c.Param("name")); err != nil {
+		s.errorResponse(c, http.StatusUnprocessableEntity, err)
+		return
+	}
+	c.JSON(http.StatusOK, statusBody{"OK"})
+}
+
+//	@Summary		method pins the param at the value, the rest of the model keeps running and reacts to it
+//	@Description	allowed in both modes, the pins are shown in GET /imitator/ups
+//	@Tags			Imitator
+//	@Accept			json
+//	@Param			input	body	model.PinForm	true	"value and optional expiry"
+//	@Produce		json
+//	@Param			param	path		string	true	"Param name with the optional index, e.g. battery_temp[2]"
+//	@Success		200		{object}	statusBody
+//	@Failure		400		{object}	errorResponse	"invalid payload"
+//	@Failure		422		{object}	errorResponse	"unknown param or invalid params"
+//	@Router			/imitator/pins/{param} [put]
+func (s *server) handlerPin(c *gin.Context) {
+	var input model.PinForm
+	if err := c.BindJSON(&input); err != nil {
+		s.errorResponse(c, http.StatusBadRequest, err)
+		return
+	}
+	if err := s.imitator.Pin(c.Param("param"), input); err != nil {
+		s.errorResponse(c, http.StatusUnprocessableEntity, err)
+		return
+	}
+	c.JSON(http.StatusOK, statusBody{"OK"})
+}
+
+//	@Summary	method releases the pinned param, it follows the model again
+//	@Tags		Imitator
+//	@Produce	json
+//	@Param		param	path		string	true	"Param name"
+//	@Success	200		{object}	statusBody
+//	@Failure	422		{object}	errorResponse	"param not pinned"
+//	@Router		/imitator/pins/{param} [delete]
+func (s *server) handlerUnpin(c *gin.Context) {
+	if err := s.imitator.Unpin(c.Param("param")); err != nil {
+		s.errorResponse(c, http.StatusUnprocessableEntity, err)
+		return
+	}
+	c.JSON(http.StatusOK, statusBody{"OK"})
+}
+
+type loadProfile struct {
+	Profile string `json:"profile" enums:"constant,daily,steps,random_walk,csv" example:"daily"`
+}
+
+//	@Summary	method returns the active load profile
+//	@Tags		Imitator
+//	@Produce	json
+//	@Success	200	{object}	loadProfile
+//	@Router		/imitator/load [get]
+func (s *server) handlerGetLoadProfile(c *gin.Context) {
+	c.JSON(http.StatusOK, loadProfile{s.imitator.GetLoadProfile()})
+}
+
+//	@Summary		method switches the load profile
+//	@Description	the profile must be configured, it starts from the beginning
+//	@Tags			Imitator
+//	@Accept			json
+//	@Param			input	body	loadProfile	true	"profile"
+//	@Produce		json
+//	@Success		200	{object}	statusBody
+//	@Failure		400	{object}	errorResponse	"invalid payload"
+//	@Failure		422	{object}	errorResponse	"unknown or not configured profile"
+//	@Router			/imitator/load [put]
+func (s *server) handlerUpdateLoadProfile(c *gin.Context) {
+	var input loadProfile
+	if err := c.BindJSON(&input); err != nil {
+		s.errorResponse(c, http.StatusBadRequest, err)
+		return
+	}
+	if err := s.imitator.SetLoadProfile(input.Profile); err != nil {
+		s.errorResponse(c, http.StatusUnprocessableEntity, err)
+		return
+	}
+	c.JSON(http.StatusOK, statusBody{"OK"})
+}
+
+//	@Summary		method uploads the scenario
+//	@Description	a timeline of actions in YAML or JSON, a running scenario is stopped
+//	@Tags			Imitator
+//	@Accept			plain
+//	@Param			input	body	model.Scenario	true	"scenario"
+//	@Produce		json
+//	@Success		200	{object}	statusBody
+//	@Failure		400	{object}	errorResponse	"invalid scenario"
+//	@Router			/imitator/scenario [put]
+func (s *server) handlerUploadScenario(c *gin.Context) {
+	data, err := c.GetRawData()
+	if err != nil {
+		s.errorResponse(c, http.StatusBadRequest, err)
+		return
+	}
+	if err := s.imitator.UploadScenario(data); err != nil {
+		s.errorResponse(c, http.StatusBadRequest, err)
+		return
+	}
+	c.JSON(http.StatusOK, statusBody{"OK"})
+}
+
+//	@Summary	method returns the progress of the uploaded scenario
+//	@Tags		Imitator
+//	@Produce	json
+//	@Success	200	{object}	model.ScenarioProgress
+//	@Router		/imitator/scenario [get]
+func (s *server) handlerGetScenarioProgress(c *gin.Context) {
+	c.JSON(http.StatusOK, s.imitator.GetScenarioProgress())
+}
+
+//	@Summary		method starts the uploaded scenario from the beginning
+//	@Description	auto mode only, the auto mode cycle is suspended until the scenario ends
+//	@Tags			Imitator
+//	@Produce		json
+//	@Success		200	{object}	statusBody
+//	@Failure		403	{object}	errorResponse	"manual mode"
+//	@Failure		422	{object}	errorResponse	"no scenario uploaded, already running or the chaos mode is running"
+//	@Router			/imitator/scenario/start [post]
+func (s *server) handlerStartScenario(c *gin.Context) {
+	if !s.imitator.GetMode() {
+		s.errorResponse(c, http.StatusForbidden, errors.New("manual mode"))
+		return
+	}
+	if err := s.imitator.StartScenario(); err != nil {
+		s.errorResponse(c, http.StatusUnprocessableEntity, err)
+		return
+	}
+	c.JSON(http.StatusOK, statusBody{"OK"})
+}
+
+//	@Summary	method stops the running scenario
+//	@Tags		Imitator
+//	@Produce	json
+//	@Success	200	{object}	statusBody
+//	@Failure	422	{object}	errorResponse	"not running"
+//	@Router		/imitator/scenario/stop [post]
+func (s *server) handlerStopScenario(c *gin.Context) {
+	if err := s.imitator.StopScenario(); err != nil {
+		s.errorResponse(c, http.StatusUnprocessableEntity, err)
+		return
+	}
+	c.JSON(http.StatusOK, statusBody{"OK"})
+}
+
+//	@Summary		method uploads recorded telemetry for the replay
+//	@Description	CSV with a header row, the first column is the time, or an OpenTSDB JSON export.
+//	@Description	The columns are mapped onto the params by the [replay] config or by their names, a running replay is stopped
+//	@Tags			Imitator
+//	@Accept			plain
+//	@Param			input	body	string	true	"recording"
+//	@Produce		json
+//	@Success		200	{object}	statusBody
+//	@Failure		400	{object}	errorResponse	"invalid recording or no column mapped"
+//	@Router			/imitator/replay [put]
+func (s *server) handlerUploadRecording(c *gin.Context) {
+	data, err := c.GetRawData()
+	if err != nil {
+		s.errorResponse(c, http.StatusBadRequest, err)
+		return
+	}
+	if err := s.imitator.UploadRecording(data); err != nil {
+		s.errorResponse(c, http.StatusBadRequest, err)
+		return
+	}
+	c.JSON(http.StatusOK, statusBody{"OK"})
+}
+
+//	@Summary	method returns the progress of the replay
+//	@Tags		Imitator
+//	@Produce	json
+//	@Success	200	{object}	model.ReplayProgress
+//	@Router		/imitator/replay [get]
+func (s *server) handlerGetReplayProgress(c *gin.Context) {
+	c.JSON(http.StatusOK, s.imitator.GetReplayProgress())
+}
+
+//	@Summary		method starts the replay of the loaded recording from the beginning
+//	@Description	auto mode only, the speed and the looping default to the [replay] config
+//	@Tags			Imitator
+//	@Accept			json
+//	@Param			input	body	model.ReplayStartForm	false	"options"
+//	@Produce		json
+//	@Success		200	{object}	statusBody
+//	@Failure		400	{object}	errorResponse	"invalid payload"
+//	@Failure		403	{object}	errorResponse	"manual mode"
+//	@Failure		422	{object}	errorResponse	"no recording loaded, already running or invalid speed"
+//	@Router			/imitator/replay/start [post]
+func (s *server) handlerStartReplay(c *gin.Context) {
+	var input model.ReplayStartForm
+	if c.Request.ContentLength != 0 {
+		if err := c.BindJSON(&input); err != nil {
+			s.errorResponse(c, http.StatusBadRequest, err)
+			return
+		}
+	}
+	if !s.imitator.GetMode() {
+		s.errorResponse(c, http.StatusForbidden, errors.New("manual mode"))
+		return
+	}
+	if err := s.imitator.StartReplay(input); err != nil {
+		s.errorResponse(c, http.StatusUnprocessableEntity, err)
+		return
+	}
+	c.JSON(http.StatusOK, statusBody{"OK"})
+}
+
+//	@Summary	method stops the replay
+//	@Tags		Imitator
+//	@Produce	json
+//	@Success	200	{object}	statusBody
+//	@Failure	422	{object}	errorResponse	"not running"
+//	@Router		/imitator/replay/stop [post]
+func (s *server) handlerStopReplay(c *gin.Context) {
+	if err := s.imitator.StopReplay(); err != nil {
+		s.errorResponse(c, http.StatusUnprocessableEntity, err)
+		return
+	}
+	c.JSON(http.StatusOK, statusBody{"OK"})
+}
+
+//	@Summary	method returns the state of the chaos mode
+//	@Tags		Imitator
+//	@Produce	json
+//	@Success	200	{object}	model.ChaosProgress
+//	@Router		/imitator/chaos [get]
+func (s *server) handlerGetChaosProgress(c *gin.Context) {
+	c.JSON(http.StatusOK, s.imitator.GetChaosProgress())
+}
+
+//	@Summary		method starts the random mains outages and flickers of the [chaos] config
+//	@Description	auto mode only, the auto mode cycle is suspended until stopped. The same seed reproduces the run
+//	@Tags			Imitator
+//	@Accept			json
+//	@Param			input	body	model.ChaosStartForm	false	"options"
+//	@Produce		json
+//	@Success		200	{object}	statusBody
+//	@Failure		400	{object}	errorResponse	"invalid payload"
+//	@Failure		403	{object}	errorResponse	"manual mode"
+//	@Failure		422	{object}	errorResponse	"not configured, already running or a scenario is running"
+//	@Router			/imitator/chaos/start [post]
+func (s *server) handlerStartChaos(c *gin.Context) {
+	var input model.ChaosStartForm
+	if c.Request.ContentLength != 0 {
+		if err := c.BindJSON(&input); err != nil {
+			s.errorResponse(c, http.StatusBadRequest, err)
+			return
+		}
+	}
+	if !s.imitator.GetMode() {
+		s.errorResponse(c, http.StatusForbidden, errors.New("manual mode"))
+		return
+	}
+	if err := s.imitator.StartChaos(input); err != nil {
+		s.errorResponse(c, http.StatusUnprocessableEntity, err)
+		return
+	}
+	c.JSON(http.StatusOK, statusBody{"OK"})
+}
+
+//	@Summary	method stops the chaos mode, the mains is restored
+//	@Tags		Imitator
+//	@Produce	json
+//	@Success	200	{object}	statusBody
+//	@Failure	422	{object}	errorResponse	"not running"
+//	@Router		/imitator/chaos/stop [post]
+func (s *server) handlerStopChaos(c *gin.Context) {
+	if err := s.imitator.StopChaos(); err != nil {
+		s.errorResponse(c, http.StatusUnprocessableEntity, err)
+		return
+	}
+	c.JSON(http.StatusOK, statusBody{"OK"})
+}
+
+//	@Summary	method returns the scheduled jobs with their next and last runs
+//	@Tags		Imitator
+//	@Produce	json
+//	@Success	200	{array}	model.ScheduledJobStatus
+//	@Router		/imitator/schedule [get]
+func (s *server) handlerGetSchedule(c *gin.Context) {
+	c.JSON(http.StatusOK, s.imitator.GetSchedule())
+}
+
+//	@Summary		method replaces the scheduled jobs
+//	@Description	the jobs run in both modes, on the simulated time unless wall_clock of the [schedule] config is set
+//	@Tags			Imitator
+//	@Accept			json
+//	@Param			input	body	[]model.ScheduledJob	true	"jobs"
+//	@Produce		json
+//	@Success		200	{object}	statusBody
+//	@Failure		400	{object}	errorResponse	"invalid payload"
+//	@Failure		422	{object}	errorResponse	"invalid jobs"
+//	@Router			/imitator/schedule [put]
+func (s *server) handlerUpdateSchedule(c *gin.Context) {
+	var input model.ScheduledJobs
+	if err := c.BindJSON(&input); err != nil {
+		s.errorResponse(c, http.StatusBadRequest, err)
+		return
+	}
+	if err := s.imitator.SetSchedule(input); err != nil {
+		s.errorResponse(c, http.StatusUnprocessableEntity, err)
+		return
+	}
+	c.JSON(http.StatusOK, statusBody{"OK"})
+}
+
+//	@Summary	method adds the scheduled job or replaces the one with the name
+//	@Tags		Imitator
+//	@Accept		json
+//	@Param		input	body	model.ScheduledJob	true	"job, the name is taken from the path"
+//	@Produce	json
+//	@Param		name	path		string	true	"Job name"
+//	@Success	200		{object}	statusBody
+//	@Failure	400		{object}	errorResponse	"invalid payload"
+//	@Failure	422		{object}	errorResponse	"invalid job"
+//	@Router		/imitator/schedule/{name} [put]
+func (s *server) handlerUpdateScheduledJob(c *gin.Context) {
+	var input model.ScheduledJob
+	if err := c.BindJSON(&input); err != nil {
+		s.errorResponse(c, http.StatusBadRequest, err)
+		return
+	}
+	if err := s.imitator.PutScheduledJob(c.Param("name"), input); err != nil {
+		s.errorResponse(c, http.StatusUnprocessableEntity, err)
+		return
+	}
+	c.JSON(http.StatusOK, statusBody{"OK"})
+}
+
+//	@Summary	method removes the scheduled job
+//	@Tags		Imitator
+//	@Produce	json
+//	@Param		name	path		string	true	"Job name"
+//	@Success	200		{object}	statusBody
+//	@Failure	422		{object}	errorResponse	"job not found"
+//	@Router		/imitator/schedule/{name} [delete]
+func (s *server) handlerDeleteScheduledJob(c *gin.Context) {
+	if err := s.imitator.DeleteScheduledJob(c.Param("name")); err != nil {
+		s.errorResponse(c, http.StatusUnprocessableEntity, err)
+		return
+	}
+	c.JSON(http.StatusOK, statusBody{"OK"})
+}
+
+//	@Summary	method returns the simulated time
+//	@Tags		Imitator
+//	@Produce	json
+//	@Success	200	{object}	model.ClockStatus
+//	@Router		/imitator/clock [get]
+func (s *server) handlerGetClock(c *gin.Context) {
+	c.JSON(http.StatusOK, s.imitator.GetClock())
+}
+
+//	@Summary		method pauses the simulation
+//	@Description	the simulated time and the recalculation freeze, the state is kept
+//	@Tags			Imitator
+//	@Produce		json
+//	@Success		200	{object}	statusBody
+//	@Failure		403	{object}	errorResponse	"manual mode"
+//	@Failure		422	{object}	errorResponse	"already paused"
+//	@Router			/imitator/clock/pause [post]
+func (s *server) handlerPause(c *gin.Context) {
+	s.clockControl(c, s.imitator.Pause)
+}
+
+//	@Summary	method resumes the paused simulation
+//	@Tags		Imitator
+//	@Produce	json
+//	@Success	200	{object}	statusBody
+//	@Failure	403	{object}	errorResponse	"manual mode"
+//	@Failure	422	{object}	errorResponse	"not paused"
+//	@Router		/imitator/clock/resume [post]
+func (s *server) handlerResume(c *gin.Context) {
+	s.clockControl(c, s.imitator.Resume)
+}
+
+//	@Summary		method performs exactly one recalculation step
+//	@Description	paused only, the simulated time advances by ups_sync_interval and the params are sent
+//	@Tags			Imitator
+//	@Produce		json
+//	@Success		200	{object}	statusBody
+//	@Failure		403	{object}	errorResponse	"manual mode"
+//	@Failure		422	{object}	errorResponse	"not paused"
+//	@Router			/imitator/clock/step [post]
+func (s *server) handlerStep(c *gin.Context) {
+	s.clockControl(c, s.imitator.Step)
+}
+
+type fastForward struct {
+	Duration float64 `json:"duration" example:"600"` // sec
+}
+
+//	@Summary		method advances the simulation by the duration at once
+//	@Description	in steps of ups_sync_interval, paused or not, the params are sent at the end
+//	@Tags			Imitator
+//	@Accept			json
+//	@Param			input	body	fastForward	true	"duration"
+//	@Produce		json
+//	@Success		200	{object}	statusBody
+//	@Failure		400	{object}	errorResponse	"invalid payload"
+//	@Failure		403	{object}	errorResponse	"manual mode"
+//	@Failure		422	{object}	errorResponse	"invalid duration"
+//	@Router			/imitator/clock/forward [post]
+func (s *server) handlerFastForward(c *gin.Context) {
+	var input fastForward
+	if err := c.BindJSON(&input); err != nil {
+		s.errorResponse(c, http.StatusBadRequest, err)
+		return
+	}
+	s.clockControl(c, func() error {
+		return s.imitator.FastForward(time.Duration(input.Duration * float64(time.Second)))
+	})
+}
+
+// clockControl performs the control of the simulated time, auto mode only
+func (s *server) clockControl(c *gin.Context, control func() error) {
+	if !s.imitator.GetMode() {
+		s.errorResponse(c, http.StatusForbidden, errors.New("manual mode"))
+		return
+	}
+	if err := control(); err != nil {
+		s.errorResponse(c, http.StatusUnprocessableEntity, err)
+		return
+	}
+	c.JSON(http.StatusOK, statusBody{"OK"})
+}
+
 //	@Summary	method returns all ups params
 //	@Tags		Imitator
 //	@Produce	json
@@ -106,6 +608,182 @@ func (s *server) handlerUpdateBattery(c *gin.Context) {
 	c.JSON(http.StatusOK, statusBody{"OK"})
 }
 
+//	@Summary		method updates ups phase params
+//	@Description	three-phase mode only
+//	@Tags			Imitator
+//	@Accept			json
+//	@Param			input	body	model.PhaseParamsUpdateForm	true	"params"
+//	@Produce		json
+//	@Param			phase_id	path		int	true	"Phase id: 0 - L1, 1 - L2, 2 - L3"
+//	@Success		200			{object}	statusBody
+//	@Failure		400			{object}	errorResponse	"invalid payload"
+//	@Failure		403			{object}	errorResponse	"auto mode"
+//	@Failure		422			{object}	errorResponse
+//	@Router			/imitator/ups/phases/{phase_id} [patch]
+func (s *server) handlerUpdatePhase(c *gin.Context) {
+	phase_id, err := strconv.Atoi(c.Param("phase_id"))
+	if err != nil {
+		s.errorResponse(c, http.StatusBadRequest, err)
+		return
+	}
+	var input model.PhaseParamsUpdateForm
+	if err := c.BindJSON(&input); err != nil {
+		s.errorResponse(c, http.StatusBadRequest, err)
+		return
+	}
+	if s.imitator.GetMode() {
+		s.errorResponse(c, http.StatusForbidden, errors.New("auto mode"))
+		return
+	}
+	if err := s.imitator.UpdateUpsPhaseParams(phase_id, input); err != nil {
+		s.errorResponse(c, http.StatusUnprocessableEntity, err)
+		return
+	}
+	c.JSON(http.StatusOK, statusBody{"OK"})
+}
+
+//	@Summary		method transfers the load to the static bypass
+//	@Description	the load stays on bypass until the return request, also in auto mode
+//	@Tags			Imitator
+//	@Produce		json
+//	@Success		200	{object}	statusBody
+//	@Failure		422	{object}	errorResponse	"bypass unavailable"
+//	@Router			/imitator/ups/bypass [post]
+func (s *server) handlerRequestBypass(c *gin.Context) {
+	if err := s.imitator.RequestBypass(); err != nil {
+		s.errorResponse(c, http.StatusUnprocessableEntity, err)
+		return
+	}
+	c.JSON(http.StatusOK, statusBody{"OK"})
+}
+
+//	@Summary	method returns the load from the static bypass to the inverter
+//	@Tags		Imitator
+//	@Produce	json
+//	@Success	200	{object}	statusBody
+//	@Failure	422	{object}	errorResponse	"bypass not requested"
+//	@Router		/imitator/ups/bypass [delete]
+func (s *server) handlerReturnFromBypass(c *gin.Context) {
+	if err := s.imitator.ReturnFromBypass(); err != nil {
+		s.errorResponse(c, http.StatusUnprocessableEntity, err)
+		return
+	}
+	c.JSON(http.StatusOK, statusBody{"OK"})
+}
+
+//	@Summary		method fails the inverter
+//	@Description	the load is transferred to the static bypass, the output is shut down if the bypass is unavailable, also in auto mode
+//	@Tags			Imitator
+//	@Produce		json
+//	@Success		200	{object}	statusBody
+//	@Failure		422	{object}	errorResponse	"inverter already failed"
+//	@Router			/imitator/ups/inverter_fault [post]
+func (s *server) handlerFailInverter(c *gin.Context) {
+	if err := s.imitator.FailInverter(); err != nil {
+		s.errorResponse(c, http.StatusUnprocessableEntity, err)
+		return
+	}
+	c.JSON(http.StatusOK, statusBody{"OK"})
+}
+
+//	@Summary	method repairs the failed inverter, the load returns to it
+//	@Tags		Imitator
+//	@Produce	json
+//	@Success	200	{object}	statusBody
+//	@Failure	422	{object}	errorResponse	"inverter not failed"
+//	@Router		/imitator/ups/inverter_fault [delete]
+func (s *server) handlerRepairInverter(c *gin.Context) {
+	if err := s.imitator.RepairInverter(); err != nil {
+		s.errorResponse(c, http.StatusUnprocessableEntity, err)
+		return
+	}
+	c.JSON(http.StatusOK, statusBody{"OK"})
+}
+
+//	@Summary	method returns the result of the last battery self-test
+//	@Tags		Imitator
+//	@Produce	json
+//	@Success	200	{object}	model.BatteryTestParams
+//	@Router		/imitator/ups/battery_test [get]
+func (s *server) handlerGetBatteryTest(c *gin.Context) {
+	c.JSON(http.StatusOK, s.imitator.GetBatteryTest())
+}
+
+//	@Summary		method starts the battery self-test
+//	@Description	auto mode only, the load is transferred to battery for the duration of the [battery_test] config, the result is stored in the UPS params
+//	@Tags			Imitator
+//	@Produce		json
+//	@Success		200	{object}	statusBody
+//	@Failure		403	{object}	errorResponse	"manual mode"
+//	@Failure		422	{object}	errorResponse	"already running or the load is not on the inverter"
+//	@Router			/imitator/ups/battery_test [post]
+func (s *server) handlerStartBatteryTest(c *gin.Context) {
+	if !s.imitator.GetMode() {
+		s.errorResponse(c, http.StatusForbidden, errors.New("manual mode"))
+		return
+	}
+	if err := s.imitator.StartBatteryTest(); err != nil {
+		s.errorResponse(c, http.StatusUnprocessableEntity, err)
+		return
+	}
+	c.JSON(http.StatusOK, statusBody{"OK"})
+}
+
+type batteryFault struct {
+	Fault string `json:"fault" enums:"shorted_cell,open_cell,high_resistance,thermal_runaway" example:"shorted_cell"`
+}
+
+//	@Summary		method injects a fault into the battery
+//	@Description	the fault evolves while the UPS is running, also in auto mode
+//	@Tags			Imitator
+//	@Accept			json
+//	@Param			input	body	batteryFault	true	"fault"
+//	@Produce		json
+//	@Param			bat_id	path		int	true	"Battery id"
+//	@Success		200		{object}	statusBody
+//	@Failure		400		{object}	errorResponse	"invalid payload"
+//	@Failure		422		{object}	errorResponse	"unknown or already injected fault"
+//	@Router			/imitator/ups/{bat_id}/faults [post]
+func (s *server) handlerAddBatteryFault(c *gin.Context) {
+	bat_id, err := strconv.Atoi(c.Param("bat_id"))
+	if err != nil {
+		s.errorResponse(c, http.StatusBadRequest, err)
+		return
+	}
+	var input batteryFault
+	if err := c.BindJSON(&input); err != nil {
+		s.errorResponse(c, http.StatusBadRequest, err)
+		return
+	}
+	if err := s.imitator.AddBatteryFault(bat_id, input.Fault); err != nil {
+		s.errorResponse(c, http.StatusUnprocessableEntity, err)
+		return
+	}
+	c.JSON(http.StatusOK, statusBody{"OK"})
+}
+
+//	@Summary	method clears the fault of the battery
+//	@Tags		Imitator
+//	@Produce	json
+//	@Param		bat_id	path		int		true	"Battery id"
+//	@Param		fault	path		string	true	"Fault"	Enums(shorted_cell, open_cell, high_resistance, thermal_runaway)
+//	@Success	200		{object}	statusBody
+//	@Failure	400		{object}	errorResponse	"invalid battery id"
+//	@Failure	422		{object}	errorResponse	"unknown or not injected fault"
+//	@Router		/imitator/ups/{bat_id}/faults/{fault} [delete]
+func (s *server) handlerClearBatteryFault(c *gin.Context) {
+	bat_id, err := strconv.Atoi(c.Param("bat_id"))
+	if err != nil {
+		s.errorResponse(c, http.StatusBadRequest, err)
+		return
+	}
+	if err := s.imitator.ClearBatteryFault(bat_id, c.Param("fault")); err != nil {
+		s.errorResponse(c, http.StatusUnprocessableEntity, err)
+		return
+	}
+	c.JSON(http.StatusOK, statusBody{"OK"})
+}
+
 //	@Summary	method updates ups alarms
 //	@Tags		Imitator
 //	@Accept		json
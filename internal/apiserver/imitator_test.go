@@ -6,14 +6,16 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/alex11prog/ups-imitator/internal/app/imitator"
+	"github.com/alex11prog/ups-imitator/internal/app/imitator/mockmodbus"
 	"github.com/alex11prog/ups-imitator/internal/app/model"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestServer_handlerGetMode(t *testing.T) {
-	imitator := imitator.New(nil, model.TestConfig(t))
+	imitator := imitator.New(mockmodbus.New(), model.TestConfig(t))
 	s := newServer(imitator)
 	testCase := struct {
 		name         string
@@ -29,7 +31,7 @@ func TestServer_handlerGetMode(t *testing.T) {
 }
 
 func TestServer_handlerUpdateMode(t *testing.T) {
-	imitator := imitator.New(nil, model.TestConfig(t))
+	imitator := imitator.New(mockmodbus.New(), model.TestConfig(t))
 	s := newServer(imitator)
 	testCases := []struct {
 		name         string
@@ -76,7 +78,7 @@ func TestServer_handlerUpdateMode(t *testing.T) {
 }
 
 func TestServer_handlerGetAllUpsParams(t *testing.T) {
-	imitator := imitator.New(nil, model.TestConfig(t))
+	imitator := imitator.New(mockmodbus.New(), model.TestConfig(t))
 	s := newServer(imitator)
 	testCase := struct {
 		name         string
@@ -92,7 +94,7 @@ func TestServer_handlerGetAllUpsParams(t *testing.T) {
 }
 
 func TestServer_handlerUpdateUpsParams(t *testing.T) {
-	imitator := imitator.New(nil, model.TestConfig(t))
+	imitator := imitator.New(mockmodbus.New(), model.TestConfig(t))
 	s := newServer(imitator)
 	testCases := []struct {
 		name         string
@@ -171,10 +173,28 @@ func TestServer_handlerUpdateUpsParams(t *testing.T) {
 			assert.Equal(t, tc.expectedCode, rec.Code)
 		})
 	}
+
+	// the explicit values are kept when the input change is recalculated
+	patch := func(path string, payload any) {
+		rec := httptest.NewRecorder()
+		b := &bytes.Buffer{}
+		json.NewEncoder(b).Encode(payload)
+		req, _ := http.NewRequest(http.MethodPatch, path, b)
+		s.router.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+	}
+	patch("/imitator/ups/alarms", map[string]any{"low_battery": true, "overload": true})
+	patch("/imitator/ups/params", map[string]any{"input_ac_voltage": 0, "input_ac_current": 0.5, "bat_group_current": -9})
+	params := imitator.GetAllUpsParams()
+	assert.Equal(t, model.ModeOnBattery, params.OperatingMode)
+	assert.True(t, params.Alarms.LowBattery)
+	assert.True(t, params.Alarms.Overload)
+	assert.Equal(t, float32(0.5), params.InputAcCurrent)
+	assert.Equal(t, float32(-9), params.BatGroupCurrent)
 }
 
 func TestServer_handlerUpdateBattery(t *testing.T) {
-	imitator := imitator.New(nil, model.TestConfig(t))
+	imitator := imitator.New(mockmodbus.New(), model.TestConfig(t))
 	s := newServer(imitator)
 	testCases := []struct {
 		name         string
@@ -274,7 +294,7 @@ func TestServer_handlerUpdateBattery(t *testing.T) {
 }
 
 func TestServer_handlerUpdateAlarms(t *testing.T) {
-	imitator := imitator.New(nil, model.TestConfig(t))
+	imitator := imitator.New(mockmodbus.New(), model.TestConfig(t))
 	s := newServer(imitator)
 	testCases := []struct {
 		name         string
@@ -345,3 +365,987 @@ func TestServer_handlerUpdateAlarms(t *testing.T) {
 		})
 	}
 }
+
+func TestServer_handlerUpdatePhase(t *testing.T) {
+	conf := model.TestConfig(t)
+	conf.ThreePhase.Enabled = true
+	imitator := imitator.New(mockmodbus.New(), conf)
+	s := newServer(imitator)
+	testCases := []struct {
+		name         string
+		prapare      func()
+		payload      any
+		phaseId      string
+		expectedCode int
+	}{
+		{
+			"invalid payload",
+			nil,
+			"invalid",
+			"0",
+			http.StatusBadRequest,
+		},
+		{
+			"invalid phase id",
+			nil,
+			map[string]any{
+				"input_ac_voltage": 0,
+			},
+			"invalid",
+			http.StatusBadRequest,
+		},
+		{
+			"invalid, auto mode",
+			nil,
+			map[string]any{
+				"input_ac_voltage": 0,
+			},
+			"0",
+			http.StatusForbidden,
+		},
+		{
+			"valid, input_ac_voltage",
+			func() {
+				imitator.SetMode(false)
+			},
+			map[string]any{
+				"input_ac_voltage": 0,
+			},
+			"2",
+			http.StatusOK,
+		},
+		{
+			"invalid phase id, range over",
+			nil,
+			map[string]any{
+				"input_ac_voltage": 0,
+			},
+			"3",
+			http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.prapare != nil {
+				tc.prapare()
+			}
+			rec := httptest.NewRecorder()
+			b := &bytes.Buffer{}
+			json.NewEncoder(b).Encode(tc.payload)
+			req, _ := http.NewRequest(http.MethodPatch, "/imitator/ups/phases/"+tc.phaseId, b)
+			s.router.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+		})
+	}
+}
+
+func TestServer_handlerBypass(t *testing.T) {
+	imitator := imitator.New(mockmodbus.New(), model.TestConfig(t))
+	s := newServer(imitator)
+	testCases := []struct {
+		name         string
+		prapare      func()
+		method       string
+		expectedCode int
+	}{
+		{
+			"invalid, bypass not requested",
+			nil,
+			http.MethodDelete,
+			http.StatusUnprocessableEntity,
+		},
+		{
+			"valid, request in auto mode",
+			nil,
+			http.MethodPost,
+			http.StatusOK,
+		},
+		{
+			"valid, return",
+			nil,
+			http.MethodDelete,
+			http.StatusOK,
+		},
+		{
+			"valid, request in manual mode",
+			func() {
+				imitator.SetMode(false)
+			},
+			http.MethodPost,
+			http.StatusOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.prapare != nil {
+				tc.prapare()
+			}
+			rec := httptest.NewRecorder()
+			req, _ := http.NewRequest(tc.method, "/imitator/ups/bypass", nil)
+			s.router.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+		})
+	}
+}
+
+func TestServer_handlerInverterFault(t *testing.T) {
+	imitator := imitator.New(mockmodbus.New(), model.TestConfig(t))
+	s := newServer(imitator)
+	testCases := []struct {
+		name         string
+		method       string
+		expectedCode int
+		expectedMode model.OperatingMode
+	}{
+		{"invalid, not failed", http.MethodDelete, http.StatusUnprocessableEntity, model.ModeOnline},
+		{"valid, fail", http.MethodPost, http.StatusOK, model.ModeBypass},
+		{"invalid, already failed", http.MethodPost, http.StatusUnprocessableEntity, model.ModeBypass},
+		{"valid, repair", http.MethodDelete, http.StatusOK, model.ModeOnline},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req, _ := http.NewRequest(tc.method, "/imitator/ups/inverter_fault", nil)
+			s.router.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+			assert.Equal(t, tc.expectedMode, imitator.GetAllUpsParams().OperatingMode)
+		})
+	}
+}
+
+func TestServer_handlerUpdateLoadProfile(t *testing.T) {
+	imitator := imitator.New(mockmodbus.New(), model.TestConfig(t))
+	s := newServer(imitator)
+	testCases := []struct {
+		name         string
+		payload      any
+		expectedCode int
+	}{
+		{
+			"invalid payload",
+			"invalid",
+			http.StatusBadRequest,
+		},
+		{
+			"invalid, unknown profile",
+			map[string]any{
+				"profile": "invalid",
+			},
+			http.StatusUnprocessableEntity,
+		},
+		{
+			"valid, steps",
+			map[string]any{
+				"profile": "steps",
+			},
+			http.StatusOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			b := &bytes.Buffer{}
+			json.NewEncoder(b).Encode(tc.payload)
+			req, _ := http.NewRequest(http.MethodPut, "/imitator/load", b)
+			s.router.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+		})
+	}
+	assert.Equal(t, "steps", imitator.GetLoadProfile())
+}
+
+func TestServer_handlerBatteryFault(t *testing.T) {
+	imitator := imitator.New(mockmodbus.New(), model.TestConfig(t))
+	s := newServer(imitator)
+	testCases := []struct {
+		name         string
+		method       string
+		path         string
+		payload      any
+		expectedCode int
+	}{
+		{
+			"invalid bat_id",
+			http.MethodPost,
+			"/imitator/ups/invalid/faults",
+			map[string]any{"fault": "shorted_cell"},
+			http.StatusBadRequest,
+		},
+		{
+			"invalid payload",
+			http.MethodPost,
+			"/imitator/ups/0/faults",
+			"invalid",
+			http.StatusBadRequest,
+		},
+		{
+			"invalid, unknown fault",
+			http.MethodPost,
+			"/imitator/ups/0/faults",
+			map[string]any{"fault": "invalid"},
+			http.StatusUnprocessableEntity,
+		},
+		{
+			"invalid, bat_id out of range",
+			http.MethodPost,
+			"/imitator/ups/4/faults",
+			map[string]any{"fault": "shorted_cell"},
+			http.StatusUnprocessableEntity,
+		},
+		{
+			"valid, add in auto mode",
+			http.MethodPost,
+			"/imitator/ups/0/faults",
+			map[string]any{"fault": "shorted_cell"},
+			http.StatusOK,
+		},
+		{
+			"invalid, already injected",
+			http.MethodPost,
+			"/imitator/ups/0/faults",
+			map[string]any{"fault": "shorted_cell"},
+			http.StatusUnprocessableEntity,
+		},
+		{
+			"valid, clear",
+			http.MethodDelete,
+			"/imitator/ups/0/faults/shorted_cell",
+			nil,
+			http.StatusOK,
+		},
+		{
+			"invalid, not injected",
+			http.MethodDelete,
+			"/imitator/ups/0/faults/shorted_cell",
+			nil,
+			http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			b := &bytes.Buffer{}
+			json.NewEncoder(b).Encode(tc.payload)
+			req, _ := http.NewRequest(tc.method, tc.path, b)
+			s.router.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+		})
+	}
+}
+
+func TestServer_handlerUpdateGenerator(t *testing.T) {
+	conf := model.TestConfig(t)
+	conf.Generator.Enabled = true
+	imitator := imitator.New(mockmodbus.New(), conf)
+	s := newServer(imitator)
+	testCases := []struct {
+		name         string
+		payload      any
+		expectedCode int
+	}{
+		{
+			"invalid payload",
+			"invalid",
+			http.StatusBadRequest,
+		},
+		{
+			"invalid fuel_level",
+			map[string]any{
+				"fuel_level": 1.5,
+			},
+			http.StatusUnprocessableEntity,
+		},
+		{
+			"valid",
+			map[string]any{
+				"fuel_level": 0.5,
+			},
+			http.StatusOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			b := &bytes.Buffer{}
+			json.NewEncoder(b).Encode(tc.payload)
+			req, _ := http.NewRequest(http.MethodPatch, "/imitator/generator", b)
+			s.router.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/imitator/generator", nil)
+	s.router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var received model.GeneratorParams
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&received))
+	assert.Equal(t, float32(0.5), received.FuelLevel)
+	assert.Equal(t, model.GeneratorStandby, received.State)
+}
+
+func TestServer_handlerUpdateSensor(t *testing.T) {
+	imitator := imitator.New(mockmodbus.New(), model.TestConfig(t))
+	s := newServer(imitator)
+	testCases := []struct {
+		name         string
+		method       string
+		path         string
+		payload      any
+		expectedCode int
+	}{
+		{
+			"invalid payload",
+			http.MethodPut,
+			"/imitator/sensors/soc",
+			"invalid",
+			http.StatusBadRequest,
+		},
+		{
+			"invalid, unknown sensor",
+			http.MethodPut,
+			"/imitator/sensors/invalid",
+			map[string]any{"noise": 0.01},
+			http.StatusUnprocessableEntity,
+		},
+		{
+			"invalid params",
+			http.MethodPut,
+			"/imitator/sensors/soc",
+			map[string]any{"dropout_probability": 2},
+			http.StatusUnprocessableEntity,
+		},
+		{
+			"valid, set",
+			http.MethodPut,
+			"/imitator/sensors/soc",
+			map[string]any{"noise": 0.01, "stuck": true},
+			http.StatusOK,
+		},
+		{
+			"valid, delete",
+			http.MethodDelete,
+			"/imitator/sensors/input_ac_voltage",
+			nil,
+			http.StatusOK,
+		},
+		{
+			"invalid, not configured",
+			http.MethodDelete,
+			"/imitator/sensors/input_ac_voltage",
+			nil,
+			http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			b := &bytes.Buffer{}
+			json.NewEncoder(b).Encode(tc.payload)
+			req, _ := http.NewRequest(tc.method, tc.path, b)
+			s.router.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/imitator/sensors", nil)
+	s.router.ServeHTTP(rec, req)
+	var received model.SensorsConfig
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&received))
+	assert.Equal(t, model.SensorsConfig{
+		model.SensorSOC:         {Noise: 0.01, Stuck: true},
+		model.SensorBatteryTemp: {Noise: 0.02},
+	}, received)
+}
+
+func TestServer_handlerPin(t *testing.T) {
+	imitator := imitator.New(mockmodbus.New(), model.TestConfig(t))
+	s := newServer(imitator)
+	testCases := []struct {
+		name         string
+		method       string
+		path         string
+		payload      any
+		expectedCode int
+	}{
+		{
+			"invalid payload",
+			http.MethodPut,
+			"/imitator/pins/soc",
+			"invalid",
+			http.StatusBadRequest,
+		},
+		{
+			"invalid, unknown param",
+			http.MethodPut,
+			"/imitator/pins/invalid",
+			map[string]any{"value": 1},
+			http.StatusUnprocessableEntity,
+		},
+		{
+			"invalid, no value",
+			http.MethodPut,
+			"/imitator/pins/soc",
+			map[string]any{"duration": 60},
+			http.StatusUnprocessableEntity,
+		},
+		{
+			"valid, pin",
+			http.MethodPut,
+			"/imitator/pins/battery_temp[2]",
+			map[string]any{"value": 45, "duration": 600},
+			http.StatusOK,
+		},
+		{
+			"valid, pin without expiry",
+			http.MethodPut,
+			"/imitator/pins/input_ac_voltage",
+			map[string]any{"value": 170},
+			http.StatusOK,
+		},
+		{
+			"valid, unpin",
+			http.MethodDelete,
+			"/imitator/pins/input_ac_voltage",
+			nil,
+			http.StatusOK,
+		},
+		{
+			"invalid, not pinned",
+			http.MethodDelete,
+			"/imitator/pins/input_ac_voltage",
+			nil,
+			http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			b := &bytes.Buffer{}
+			json.NewEncoder(b).Encode(tc.payload)
+			req, _ := http.NewRequest(tc.method, tc.path, b)
+			s.router.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/imitator/ups", nil)
+	s.router.ServeHTTP(rec, req)
+	var received model.UpsParams
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&received))
+	assert.Len(t, received.Pins, 1)
+	assert.Equal(t, "battery_temp[2]", received.Pins[0].Param)
+	assert.NotNil(t, received.Pins[0].Expires)
+	assert.Equal(t, float32(45), received.Batteries[2].Temp)
+}
+
+func TestServer_handlerScenario(t *testing.T) {
+	imitator := imitator.New(mockmodbus.New(), model.TestConfig(t))
+	s := newServer(imitator)
+	testCases := []struct {
+		name         string
+		method       string
+		path         string
+		payload      string
+		expectedCode int
+	}{
+		{
+			"invalid, start without scenario",
+			http.MethodPost,
+			"/imitator/scenario/start",
+			"",
+			http.StatusUnprocessableEntity,
+		},
+		{
+			"invalid, unknown action",
+			http.MethodPut,
+			"/imitator/scenario",
+			"steps:\n  - action: invalid\n",
+			http.StatusBadRequest,
+		},
+		{
+			"valid, yaml",
+			http.MethodPut,
+			"/imitator/scenario",
+			"name: outage\nsteps:\n  - action: mains_off\n  - wait: 90s\n    action: mains_on\n",
+			http.StatusOK,
+		},
+		{
+			"valid, json",
+			http.MethodPut,
+			"/imitator/scenario",
+			`{"name": "outage", "steps": [{"action": "mains_off"}, {"wait": "90s", "action": "mains_on"}]}`,
+			http.StatusOK,
+		},
+		{
+			"invalid, stop not running",
+			http.MethodPost,
+			"/imitator/scenario/stop",
+			"",
+			http.StatusUnprocessableEntity,
+		},
+		{
+			"valid, start",
+			http.MethodPost,
+			"/imitator/scenario/start",
+			"",
+			http.StatusOK,
+		},
+		{
+			"invalid, already running",
+			http.MethodPost,
+			"/imitator/scenario/start",
+			"",
+			http.StatusUnprocessableEntity,
+		},
+		{
+			"valid, progress",
+			http.MethodGet,
+			"/imitator/scenario",
+			"",
+			http.StatusOK,
+		},
+		{
+			"valid, stop",
+			http.MethodPost,
+			"/imitator/scenario/stop",
+			"",
+			http.StatusOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req, _ := http.NewRequest(tc.method, tc.path, bytes.NewBufferString(tc.payload))
+			s.router.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+		})
+	}
+	progress := imitator.GetScenarioProgress()
+	assert.Equal(t, "outage", progress.Name)
+	assert.Equal(t, model.ScenarioStopped, progress.Status)
+
+	imitator.SetMode(false)
+	rec := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/imitator/scenario/start", nil)
+	s.router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code, "manual mode")
+}
+
+func TestServer_handlerClock(t *testing.T) {
+	imitator := imitator.New(mockmodbus.New(), model.TestConfig(t))
+	s := newServer(imitator)
+	testCases := []struct {
+		name         string
+		path         string
+		payload      any
+		expectedCode int
+	}{
+		{
+			"invalid, resume not paused",
+			"/imitator/clock/resume",
+			nil,
+			http.StatusUnprocessableEntity,
+		},
+		{
+			"invalid, step not paused",
+			"/imitator/clock/step",
+			nil,
+			http.StatusUnprocessableEntity,
+		},
+		{
+			"valid, pause",
+			"/imitator/clock/pause",
+			nil,
+			http.StatusOK,
+		},
+		{
+			"invalid, already paused",
+			"/imitator/clock/pause",
+			nil,
+			http.StatusUnprocessableEntity,
+		},
+		{
+			"valid, step",
+			"/imitator/clock/step",
+			nil,
+			http.StatusOK,
+		},
+		{
+			"invalid payload",
+			"/imitator/clock/forward",
+			"invalid",
+			http.StatusBadRequest,
+		},
+		{
+			"invalid, negative duration",
+			"/imitator/clock/forward",
+			map[string]any{
+				"duration": -1,
+			},
+			http.StatusUnprocessableEntity,
+		},
+		{
+			"valid, forward",
+			"/imitator/clock/forward",
+			map[string]any{
+				"duration": 600,
+			},
+			http.StatusOK,
+		},
+	}
+
+	start := imitator.GetClock().Time
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			b := &bytes.Buffer{}
+			json.NewEncoder(b).Encode(tc.payload)
+			req, _ := http.NewRequest(http.MethodPost, tc.path, b)
+			s.router.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+		})
+	}
+	clock := imitator.GetClock()
+	assert.True(t, clock.Paused)
+	assert.GreaterOrEqual(t, clock.Time.Sub(start), 630*time.Second, "a step and the fast-forward")
+
+	imitator.SetMode(false)
+	rec := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/imitator/clock/resume", nil)
+	s.router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code, "manual mode")
+}
+
+func TestServer_handlerReplay(t *testing.T) {
+	imitator := imitator.New(mockmodbus.New(), model.TestConfig(t))
+	s := newServer(imitator)
+	testCases := []struct {
+		name         string
+		method       string
+		path         string
+		payload      string
+		expectedCode int
+	}{
+		{
+			"invalid, start without recording",
+			http.MethodPost,
+			"/imitator/replay/start",
+			"",
+			http.StatusUnprocessableEntity,
+		},
+		{
+			"invalid, no column mapped",
+			http.MethodPut,
+			"/imitator/replay",
+			"time,invalid\n0,1\n",
+			http.StatusBadRequest,
+		},
+		{
+			"valid, csv",
+			http.MethodPut,
+			"/imitator/replay",
+			"time,input_ac_voltage,ups.temp\n0,220,25\n60,0,30\n",
+			http.StatusOK,
+		},
+		{
+			"invalid payload",
+			http.MethodPost,
+			"/imitator/replay/start",
+			"invalid",
+			http.StatusBadRequest,
+		},
+		{
+			"invalid speed",
+			http.MethodPost,
+			"/imitator/replay/start",
+			`{"speed": 0}`,
+			http.StatusUnprocessableEntity,
+		},
+		{
+			"valid, start",
+			http.MethodPost,
+			"/imitator/replay/start",
+			`{"speed": 10, "loop": true}`,
+			http.StatusOK,
+		},
+		{
+			"valid, progress",
+			http.MethodGet,
+			"/imitator/replay",
+			"",
+			http.StatusOK,
+		},
+		{
+			"valid, stop",
+			http.MethodPost,
+			"/imitator/replay/stop",
+			"",
+			http.StatusOK,
+		},
+		{
+			"valid, start with the config defaults",
+			http.MethodPost,
+			"/imitator/replay/start",
+			"",
+			http.StatusOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req, _ := http.NewRequest(tc.method, tc.path, bytes.NewBufferString(tc.payload))
+			s.router.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+		})
+	}
+	progress := imitator.GetReplayProgress()
+	assert.Equal(t, model.ReplayRunning, progress.Status)
+	assert.Equal(t, float64(1), progress.Speed)
+	assert.InDelta(t, 220, imitator.GetAllUpsParams().InputAcVoltage, 0.1, "replayed")
+
+	imitator.SetMode(false)
+	assert.Equal(t, model.ReplayStopped, imitator.GetReplayProgress().Status)
+}
+
+func TestServer_handlerChaos(t *testing.T) {
+	imitator := imitator.New(mockmodbus.New(), model.TestConfig(t))
+	s := newServer(imitator)
+	testCases := []struct {
+		name         string
+		method       string
+		path         string
+		payload      string
+		expectedCode int
+	}{
+		{
+			"invalid, stop not running",
+			http.MethodPost,
+			"/imitator/chaos/stop",
+			"",
+			http.StatusUnprocessableEntity,
+		},
+		{
+			"invalid payload",
+			http.MethodPost,
+			"/imitator/chaos/start",
+			"invalid",
+			http.StatusBadRequest,
+		},
+		{
+			"valid, start with the seed",
+			http.MethodPost,
+			"/imitator/chaos/start",
+			`{"seed": 7}`,
+			http.StatusOK,
+		},
+		{
+			"invalid, already running",
+			http.MethodPost,
+			"/imitator/chaos/start",
+			"",
+			http.StatusUnprocessableEntity,
+		},
+		{
+			"invalid, scenario while running",
+			http.MethodPost,
+			"/imitator/scenario/start",
+			"",
+			http.StatusUnprocessableEntity,
+		},
+		{
+			"valid, progress",
+			http.MethodGet,
+			"/imitator/chaos",
+			"",
+			http.StatusOK,
+		},
+		{
+			"valid, stop",
+			http.MethodPost,
+			"/imitator/chaos/stop",
+			"",
+			http.StatusOK,
+		},
+		{
+			"valid, start with the config seed",
+			http.MethodPost,
+			"/imitator/chaos/start",
+			"",
+			http.StatusOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req, _ := http.NewRequest(tc.method, tc.path, bytes.NewBufferString(tc.payload))
+			s.router.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+		})
+	}
+	progress := imitator.GetChaosProgress()
+	assert.Equal(t, model.ChaosRunning, progress.Status)
+	assert.Equal(t, int64(42), progress.Seed)
+
+	imitator.SetMode(false)
+	assert.Equal(t, model.ChaosStopped, imitator.GetChaosProgress().Status)
+	rec := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/imitator/chaos/start", nil)
+	s.router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code, "manual mode")
+}
+
+func TestServer_handlerSchedule(t *testing.T) {
+	imitator := imitator.New(mockmodbus.New(), model.TestConfig(t))
+	s := newServer(imitator)
+	testCases := []struct {
+		name         string
+		method       string
+		path         string
+		payload      any
+		expectedCode int
+	}{
+		{
+			"invalid payload",
+			http.MethodPut,
+			"/imitator/schedule",
+			"invalid",
+			http.StatusBadRequest,
+		},
+		{
+			"invalid, duplicate name",
+			http.MethodPut,
+			"/imitator/schedule",
+			[]map[string]any{
+				{"name": "off", "cron": "0 2 * * sat", "action": "mains_off"},
+				{"name": "off", "cron": "30 2 * * sat", "action": "mains_on"},
+			},
+			http.StatusUnprocessableEntity,
+		},
+		{
+			"valid, replace",
+			http.MethodPut,
+			"/imitator/schedule",
+			[]map[string]any{
+				{"name": "off", "cron": "0 2 * * sat", "action": "mains_off"},
+				{"name": "on", "cron": "30 2 * * sat", "action": "mains_on"},
+			},
+			http.StatusOK,
+		},
+		{
+			"invalid cron",
+			http.MethodPut,
+			"/imitator/schedule/peak",
+			map[string]any{"cron": "0 25 * * *", "action": "set_load", "power": 2000},
+			http.StatusUnprocessableEntity,
+		},
+		{
+			"valid, add",
+			http.MethodPut,
+			"/imitator/schedule/peak",
+			map[string]any{"cron": "0 18 * * mon-fri", "action": "set_load", "power": 2000},
+			http.StatusOK,
+		},
+		{
+			"valid, delete",
+			http.MethodDelete,
+			"/imitator/schedule/on",
+			nil,
+			http.StatusOK,
+		},
+		{
+			"invalid, not found",
+			http.MethodDelete,
+			"/imitator/schedule/on",
+			nil,
+			http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			b := &bytes.Buffer{}
+			json.NewEncoder(b).Encode(tc.payload)
+			req, _ := http.NewRequest(tc.method, tc.path, b)
+			s.router.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/imitator/schedule", nil)
+	s.router.ServeHTTP(rec, req)
+	var received []model.ScheduledJobStatus
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&received))
+	assert.Len(t, received, 2)
+	assert.Equal(t, "peak", received[1].Name)
+	assert.Equal(t, float32(2000), received[1].Power)
+	assert.NotNil(t, received[1].NextRun)
+}
+
+func TestServer_handlerBatteryTest(t *testing.T) {
+	imitator := imitator.New(mockmodbus.New(), model.TestConfig(t))
+	s := newServer(imitator)
+	testCases := []struct {
+		name         string
+		method       string
+		expectedCode int
+	}{
+		{
+			"valid, never run",
+			http.MethodGet,
+			http.StatusOK,
+		},
+		{
+			"valid, start",
+			http.MethodPost,
+			http.StatusOK,
+		},
+		{
+			"invalid, already running",
+			http.MethodPost,
+			http.StatusUnprocessableEntity,
+		},
+		{
+			"valid, running",
+			http.MethodGet,
+			http.StatusOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req, _ := http.NewRequest(tc.method, "/imitator/ups/battery_test", nil)
+			s.router.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+		})
+	}
+	rec := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/imitator/ups/battery_test", nil)
+	s.router.ServeHTTP(rec, req)
+	var received model.BatteryTestParams
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&received))
+	assert.Equal(t, model.BatteryTestRunning, received.Result)
+	assert.NotNil(t, received.Time)
+
+	imitator.SetMode(false)
+	rec = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodPost, "/imitator/ups/battery_test", nil)
+	s.router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code, "manual mode")
+}
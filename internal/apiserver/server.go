@@ -45,6 +45,44 @@ func (s *server) configureRouter() {
  	subRouter_imitator.PATCH("/ups/params", s.handlerUpdateUpsParams)
 	subRouter_imitator.PATCH("/ups/:bat_id", s.handlerUpdateBattery) 
 	subRouter_imitator.PATCH("/ups/alarms", s.handlerUpdateAlarms) 
+	subRouter_imitator.PATCH("/ups/phases/:phase_id", s.handlerUpdatePhase)
+	subRouter_imitator.POST("/ups/bypass", s.handlerRequestBypass)
+	subRouter_imitator.DELETE("/ups/bypass", s.handlerReturnFromBypass)
+	subRouter_imitator.POST("/ups/inverter_fault", s.handlerFailInverter)
+	subRouter_imitator.DELETE("/ups/inverter_fault", s.handlerRepairInverter)
+	subRouter_imitator.GET("/ups/battery_test", s.handlerGetBatteryTest)
+	subRouter_imitator.POST("/ups/battery_test", s.handlerStartBatteryTest)
+	subRouter_imitator.POST("/ups/:bat_id/faults", s.handlerAddBatteryFault)
+	subRouter_imitator.DELETE("/ups/:bat_id/faults/:fault", s.handlerClearBatteryFault)
+	subRouter_imitator.GET("/generator", s.handlerGetGenerator)
+	subRouter_imitator.PATCH("/generator", s.handlerUpdateGenerator)
+	subRouter_imitator.GET("/sensors", s.handlerGetSensors)
+	subRouter_imitator.PUT("/sensors/:name", s.handlerUpdateSensor)
+	subRouter_imitator.DELETE("/sensors/:name", s.handlerDeleteSensor)
+	subRouter_imitator.PUT("/pins/:param", s.handlerPin)
+	subRouter_imitator.DELETE("/pins/:param", s.handlerUnpin)
+	subRouter_imitator.GET("/load", s.handlerGetLoadProfile)
+	subRouter_imitator.PUT("/load", s.handlerUpdateLoadProfile)
+	subRouter_imitator.GET("/scenario", s.handlerGetScenarioProgress)
+	subRouter_imitator.PUT("/scenario", s.handlerUploadScenario)
+	subRouter_imitator.POST("/scenario/start", s.handlerStartScenario)
+	subRouter_imitator.POST("/scenario/stop", s.handlerStopScenario)
+	subRouter_imitator.GET("/replay", s.handlerGetReplayProgress)
+	subRouter_imitator.PUT("/replay", s.handlerUploadRecording)
+	subRouter_imitator.POST("/replay/start", s.handlerStartReplay)
+	subRouter_imitator.POST("/replay/stop", s.handlerStopReplay)
+	subRouter_imitator.GET("/chaos", s.handlerGetChaosProgress)
+	subRouter_imitator.POST("/chaos/start", s.handlerStartChaos)
+	subRouter_imitator.POST("/chaos/stop", s.handlerStopChaos)
+	subRouter_imitator.GET("/schedule", s.handlerGetSchedule)
+	subRouter_imitator.PUT("/schedule", s.handlerUpdateSchedule)
+	subRouter_imitator.PUT("/schedule/:name", s.handlerUpdateScheduledJob)
+	subRouter_imitator.DELETE("/schedule/:name", s.handlerDeleteScheduledJob)
+	subRouter_imitator.GET("/clock", s.handlerGetClock)
+	subRouter_imitator.POST("/clock/pause", s.handlerPause)
+	subRouter_imitator.POST("/clock/resume", s.handlerResume)
+	subRouter_imitator.POST("/clock/step", s.handlerStep)
+	subRouter_imitator.POST("/clock/forward", s.handlerFastForward)
 }
 
 func (s *server) errorResponse(c *gin.Context, code int, err error) {
@@ -0,0 +1,145 @@
+// Package clock provides the time of the simulation, it may run faster than the wall clock
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+type Clock interface {
+	Now() time.Time
+	Since(t time.Time) time.Duration
+}
+
+// Controlled is a clock that can be paused and moved forward
+type Controlled interface {
+	Clock
+	Pause()
+	Resume()
+	Paused() bool
+	Advance(d time.Duration)
+}
+
+// Wall is the wall clock
+type Wall struct{}
+
+func (Wall) Now() time.Time {
+	return time.Now()
+}
+
+func (Wall) Since(t time.Time) time.Duration {
+	return time.Since(t)
+}
+
+// Scaled runs speed times faster than the wall clock
+type Scaled struct {
+	mu     sync.Mutex
+	origin time.Time // of the wall clock
+	base   time.Time // the simulated time at origin
+	speed  float64
+	paused bool
+}
+
+func NewScaled(speed float64) *Scaled {
+	now := time.Now()
+	return &Scaled{origin: now, base: now, speed: speed}
+}
+
+func (c *Scaled) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now()
+}
+
+func (c *Scaled) now() time.Time {
+	if c.paused {
+		return c.base
+	}
+	elapsed := time.Since(c.origin)
+	return c.base.Add(time.Duration(float64(elapsed) * c.speed))
+}
+
+func (c *Scaled) Since(t time.Time) time.Duration {
+	return c.Now().Sub(t)
+}
+
+// Speed returns the speed-up factor
+func (c *Scaled) Speed() float64 {
+	return c.speed
+}
+
+// Pause stops the simulated time until Resume
+func (c *Scaled) Pause() {
+	c.mu.Lock()
+	c.base = c.now()
+	c.paused = true
+	c.mu.Unlock()
+}
+
+func (c *Scaled) Resume() {
+	c.mu.Lock()
+	c.origin = time.Now()
+	c.paused = false
+	c.mu.Unlock()
+}
+
+func (c *Scaled) Paused() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.paused
+}
+
+// Advance moves the simulated time forward by d at once
+func (c *Scaled) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.base = c.now().Add(d)
+	c.origin = time.Now()
+	c.mu.Unlock()
+}
+
+// Fake stands still until it is advanced manually
+type Fake struct {
+	mu     sync.Mutex
+	now    time.Time
+	paused bool
+}
+
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now}
+}
+
+func (c *Fake) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *Fake) Since(t time.Time) time.Duration {
+	return c.Now().Sub(t)
+}
+
+// Advance moves the clock forward by d
+func (c *Fake) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	c.mu.Unlock()
+}
+
+// Pause only marks the clock as paused, it never runs by itself
+func (c *Fake) Pause() {
+	c.mu.Lock()
+	c.paused = true
+	c.mu.Unlock()
+}
+
+func (c *Fake) Resume() {
+	c.mu.Lock()
+	c.paused = false
+	c.mu.Unlock()
+}
+
+func (c *Fake) Paused() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.paused
+}
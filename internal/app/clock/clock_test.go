@@ -0,0 +1,41 @@
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Scaled(t *testing.T) {
+	c := NewScaled(1000)
+	start := c.Now()
+	time.Sleep(10 * time.Millisecond)
+	assert.GreaterOrEqual(t, c.Since(start), 10*time.Second)
+	assert.Less(t, c.Since(start), time.Hour)
+}
+
+func Test_Fake(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewFake(now)
+	assert.Equal(t, now, c.Now())
+	c.Advance(time.Hour)
+	assert.Equal(t, time.Hour, c.Since(now))
+}
+
+func Test_Scaled_Pause(t *testing.T) {
+	c := NewScaled(1000)
+	c.Pause()
+	assert.True(t, c.Paused())
+	paused := c.Now()
+	time.Sleep(5 * time.Millisecond)
+	assert.Equal(t, paused, c.Now())
+
+	c.Advance(time.Hour)
+	assert.Equal(t, paused.Add(time.Hour), c.Now(), "advances while paused")
+
+	c.Resume()
+	assert.False(t, c.Paused())
+	time.Sleep(5 * time.Millisecond)
+	assert.Greater(t, c.Since(paused), time.Hour+time.Second)
+}
@@ -0,0 +1,188 @@
+// Package chaos drives the mains with random outages and flickers
+package chaos
+
+import (
+	"errors"
+	"log"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/alex11prog/ups-imitator/internal/app/clock"
+	"github.com/alex11prog/ups-imitator/internal/app/model"
+)
+
+// Target is the UPS driven by the chaos mode
+type Target interface {
+	SetMains(on bool)
+	SuspendCycle(suspend bool)
+}
+
+// change is a planned change of the mains
+type change struct {
+	time    time.Time
+	mainsOn bool
+	flicker bool // a part of a cluster of flickers, otherwise an outage
+}
+
+// Engine plans the events from the seed only, so a run is reproduced by the same seed.
+// All the due changes of the mains are applied at a tick in order, so the events keep up with the clock
+// while fast-forwarding. A flicker shorter than the sync interval is seen by the model of the UPS,
+// but the registers may show the mains restored already
+type Engine struct {
+	target Target
+	clock  clock.Clock
+	conf   model.ChaosConfig
+
+	mu        sync.Mutex
+	rnd       *rand.Rand
+	seed      int64
+	status    string
+	startTime time.Time
+	stopTime  time.Time
+	changes   []change // in time order
+	mainsOn   bool
+	outages   int
+	flickers  int
+}
+
+func New(target Target, clk clock.Clock, conf model.ChaosConfig) *Engine {
+	return &Engine{target: target, clock: clk, conf: conf, status: model.ChaosIdle, mainsOn: true}
+}
+
+// Start drives the mains by random events from now on, the seed of the config is used if nil.
+// The auto mode cycle is suspended until Stop
+func (e *Engine) Start(seed *int64) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if !e.conf.IsConfigured() {
+		return errors.New("chaos mode is not configured")
+	}
+	if e.status == model.ChaosRunning {
+		return errors.New("chaos mode is already running")
+	}
+	e.seed = e.conf.Seed
+	if seed != nil {
+		e.seed = *seed
+	}
+	if e.seed == 0 {
+		e.seed = time.Now().UnixNano()
+	}
+	log.Printf("chaos: started, seed %v\n", e.seed)
+	e.rnd = rand.New(rand.NewSource(e.seed))
+	e.status = model.ChaosRunning
+	e.startTime = e.clock.Now()
+	e.outages, e.flickers = 0, 0
+	e.target.SuspendCycle(true)
+	e.setMains(true)
+	e.changes = e.planEvent(e.startTime)
+	return nil
+}
+
+// Stop stops the events, the mains is restored and the cycle is resumed
+func (e *Engine) Stop() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.status != model.ChaosRunning {
+		return errors.New("chaos mode is not running")
+	}
+	log.Println("chaos: stopped")
+	e.status = model.ChaosStopped
+	e.stopTime = e.clock.Now()
+	e.changes = nil
+	e.setMains(true)
+	e.target.SuspendCycle(false)
+	return nil
+}
+
+// IsRunning reports whether the chaos mode is running
+func (e *Engine) IsRunning() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.status == model.ChaosRunning
+}
+
+// Tick applies the due changes of the mains
+func (e *Engine) Tick() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.status != model.ChaosRunning {
+		return
+	}
+	now := e.clock.Now()
+	for !now.Before(e.changes[0].time) {
+		c := e.changes[0]
+		e.changes = e.changes[1:]
+		switch {
+		case c.mainsOn:
+		case c.flicker:
+			e.flickers++
+		default:
+			e.outages++
+		}
+		e.setMains(c.mainsOn)
+		if len(e.changes) == 0 {
+			e.changes = e.planEvent(c.time) // the planned time, not the tick, keeps the run reproducible
+		}
+	}
+}
+
+func (e *Engine) GetProgress() model.ChaosProgress {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	progress := model.ChaosProgress{
+		Status:   e.status,
+		Seed:     e.seed,
+		Outages:  e.outages,
+		Flickers: e.flickers,
+		MainsOn:  e.mainsOn,
+	}
+	switch e.status {
+	case model.ChaosRunning:
+		progress.Elapsed = float32(e.clock.Since(e.startTime).Seconds())
+		next := e.changes[0].time
+		progress.NextEvent = &next
+	case model.ChaosStopped:
+		progress.Elapsed = float32(e.stopTime.Sub(e.startTime).Seconds())
+	}
+	return progress
+}
+
+func (e *Engine) setMains(on bool) {
+	if e.mainsOn != on {
+		log.Printf("chaos: mains on %v\n", on)
+	}
+	e.mainsOn = on
+	e.target.SetMains(on)
+}
+
+// planEvent plans the changes of the next event arriving after the time:
+// the interval is exponential, the outage duration is lognormal, the flickers are uniform
+func (e *Engine) planEvent(after time.Time) []change {
+	conf := &e.conf
+	t := after.Add(time.Duration(e.rnd.ExpFloat64() / conf.EventRate * float64(time.Hour)))
+	if e.rnd.Float64() < conf.FlickerProbability {
+		var changes []change
+		for i := range 2 + e.rnd.Intn(conf.FlickerCount-1) {
+			if i > 0 {
+				t = t.Add(e.uniform(conf.FlickerGap))
+			}
+			changes = append(changes, change{time: t, flicker: true})
+			t = t.Add(e.uniform(conf.FlickerDuration))
+			changes = append(changes, change{time: t, mainsOn: true, flicker: true})
+		}
+		return changes
+	}
+	duration := time.Duration(float64(conf.DurationMedian) * math.Exp(conf.DurationSigma*e.rnd.NormFloat64()))
+	if conf.MaxDuration > 0 {
+		duration = min(duration, conf.MaxDuration)
+	}
+	duration = max(duration, time.Second)
+	return []change{{time: t}, {time: t.Add(duration), mainsOn: true}}
+}
+
+// uniform returns a random duration from 1 sec to the limit
+func (e *Engine) uniform(limit time.Duration) time.Duration {
+	return time.Second + time.Duration(e.rnd.Int63n(int64(limit-time.Second)+1))
+}
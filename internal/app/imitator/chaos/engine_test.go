@@ -0,0 +1,98 @@
+package chaos
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alex11prog/ups-imitator/internal/app/clock"
+	"github.com/alex11prog/ups-imitator/internal/app/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// target records the changes of the mains
+type target struct {
+	clock     clock.Clock
+	mainsOn   bool
+	changes   []time.Time
+	suspended bool
+}
+
+func (t *target) SetMains(on bool) {
+	if t.mainsOn != on {
+		t.changes = append(t.changes, t.clock.Now())
+	}
+	t.mainsOn = on
+}
+
+func (t *target) SuspendCycle(suspend bool) {
+	t.suspended = suspend
+}
+
+// run ticks the engine every interval for the duration and returns the changes of the mains
+func run(t *testing.T, seed int64, interval, duration time.Duration) (*Engine, *target) {
+	clk := clock.NewFake(time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC))
+	tgt := &target{clock: clk, mainsOn: true}
+	e := New(tgt, clk, model.TestConfig(t).Chaos)
+	require.NoError(t, e.Start(&seed))
+	for elapsed := time.Duration(0); elapsed < duration; elapsed += interval {
+		clk.Advance(interval)
+		e.Tick()
+	}
+	return e, tgt
+}
+
+func Test_Engine_reproducible(t *testing.T) {
+	_, first := run(t, 1, time.Second, 48*time.Hour)
+	_, second := run(t, 1, time.Second, 48*time.Hour)
+	_, other := run(t, 2, time.Second, 48*time.Hour)
+	assert.NotEmpty(t, first.changes)
+	assert.Equal(t, first.changes, second.changes)
+	assert.NotEqual(t, first.changes, other.changes)
+}
+
+func Test_Engine_events(t *testing.T) {
+	e, tgt := run(t, 42, 30*time.Second, 7*24*time.Hour)
+	progress := e.GetProgress()
+	assert.Equal(t, model.ChaosRunning, progress.Status)
+	assert.Equal(t, int64(42), progress.Seed)
+	assert.InDelta(t, 7*24, progress.Outages+progress.Flickers/3, 7*24/2, "about an event per hour")
+	assert.Greater(t, progress.Flickers, progress.Outages, "a cluster has several flickers")
+	assert.NotNil(t, progress.NextEvent)
+	assert.True(t, tgt.suspended)
+
+	// every change reaches the UPS even if the flickers are shorter than the sync interval
+	changes := 2 * (progress.Outages + progress.Flickers)
+	if !progress.MainsOn {
+		changes--
+	}
+	assert.Len(t, tgt.changes, changes)
+
+	require.NoError(t, e.Stop())
+	assert.True(t, tgt.mainsOn, "the mains is restored")
+	assert.False(t, tgt.suspended)
+	assert.Equal(t, model.ChaosStopped, e.GetProgress().Status)
+	assert.Error(t, e.Stop())
+}
+
+func Test_Engine_catchUp(t *testing.T) {
+	e, _ := run(t, 7, time.Second, 24*time.Hour)
+	expected := e.GetProgress()
+
+	// a single tick applies all the changes due since the start
+	caught, tgt := run(t, 7, 24*time.Hour, 24*time.Hour)
+	progress := caught.GetProgress()
+	assert.Greater(t, progress.Outages+progress.Flickers, 1)
+	assert.Equal(t, expected.Outages, progress.Outages)
+	assert.Equal(t, expected.Flickers, progress.Flickers)
+	assert.Equal(t, expected.MainsOn, progress.MainsOn)
+	assert.Equal(t, expected.NextEvent, progress.NextEvent)
+	assert.True(t, progress.NextEvent.After(tgt.clock.Now()), "no due change is left")
+}
+
+func Test_Engine_notConfigured(t *testing.T) {
+	clk := clock.NewFake(time.Now())
+	e := New(&target{clock: clk}, clk, model.ChaosConfig{})
+	assert.Error(t, e.Start(nil))
+	assert.Equal(t, model.ChaosIdle, e.GetProgress().Status)
+}
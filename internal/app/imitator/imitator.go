@@ -1,10 +1,19 @@
 package imitator
 
 import (
+	"encoding/binary"
+	"errors"
+	"fmt"
 	"log"
+	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/alex11prog/ups-imitator/internal/app/clock"
+	"github.com/alex11prog/ups-imitator/internal/app/imitator/chaos"
+	"github.com/alex11prog/ups-imitator/internal/app/imitator/replay"
+	"github.com/alex11prog/ups-imitator/internal/app/imitator/scenario"
+	"github.com/alex11prog/ups-imitator/internal/app/imitator/schedule"
 	"github.com/alex11prog/ups-imitator/internal/app/imitator/ups"
 	"github.com/alex11prog/ups-imitator/internal/app/model"
 	"github.com/goburrow/modbus"
@@ -13,46 +22,164 @@ import (
 type Imitator struct {
 	client        modbus.Client
 	conf          *model.Config
+	clock         clock.Controlled
+	stepMu        sync.Mutex // a recalculation step at a time
+	sendMu        sync.Mutex // a sync with the UPS at a time
 	upsSyncTicker *time.Ticker
 	mode          atomic.Bool // true - auto, false - manual
 	ups           *ups.Ups
+	scenario      *scenario.Runner
+	replay        *replay.Player
+	chaos         *chaos.Engine
+	schedule      *schedule.Scheduler
+	commandErr    bool // the last read of RegCommand failed
+}
+
+// scheduleTarget performs the scheduled actions on the UPS and the imitator
+type scheduleTarget struct {
+	*ups.Ups
+	im *Imitator
+}
+
+func (t scheduleTarget) SetMode(auto bool) {
+	t.im.SetMode(auto)
+}
+
+func (t scheduleTarget) StartBatteryTest() error {
+	return t.im.StartBatteryTest()
 }
 
 func New(client modbus.Client, conf *model.Config) *Imitator {
+	return NewWithClock(client, conf, clock.NewScaled(conf.ClockSpeed))
+}
+
+// NewWithClock creates the imitator living by the clock, e.g. a fake one in tests
+func NewWithClock(client modbus.Client, conf *model.Config, clk clock.Controlled) *Imitator {
 	res := &Imitator{
 		client:        client,
 		conf:          conf,
-		upsSyncTicker: time.NewTicker(conf.UpsSyncInterval),
-		ups:           ups.New(conf),
+		clock:         clk,
+		upsSyncTicker: time.NewTicker(conf.SyncTickInterval()),
+		ups:           ups.NewWithClock(conf, clk),
+	}
+	res.scenario = scenario.New(res.ups, clk)
+	res.replay = replay.New(clk)
+	if conf.Replay.Recording != nil {
+		if err := res.replay.Load(conf.Replay.Recording, conf.Replay.Columns); err != nil {
+			log.Println(err)
+		}
+	}
+	res.chaos = chaos.New(res.ups, clk, conf.Chaos)
+	if conf.Chaos.Enabled {
+		if err := res.chaos.Start(nil); err != nil {
+			log.Println(err)
+		}
+	}
+	var scheduleClock clock.Clock = clk
+	if conf.Schedule.WallClock {
+		scheduleClock = clock.Wall{}
+	}
+	res.schedule = schedule.New(scheduleTarget{res.ups, res}, scheduleClock)
+	if err := res.schedule.Set(conf.Schedule.Jobs); err != nil {
+		log.Println(err)
 	}
 	res.mode.Store(true)
 	return res
 }
 
-// Start starts working in the background, recalculating and sending parameters to the UPS via Modbus
+// Start starts working in the background, recalculating and sending parameters to the UPS via Modbus.
+// In manual mode the params are only re-sent. The scheduled jobs and the commands run in both modes:
+// the jobs are run by the recalculation step in auto mode and on their own in manual mode or while paused
 func (im *Imitator) Start() {
 	go func() {
 		for range im.upsSyncTicker.C {
-			im.recalcAndSendParams()
+			im.sync()
 		}
 	}()
 }
 
+// sync performs the work of a tick of the sync ticker
+func (im *Imitator) sync() {
+	im.pollCommand()
+	switch {
+	case !im.GetMode():
+		im.tickSchedule()
+		im.sendParams()
+	case im.clock.Paused():
+		im.tickSchedule()
+	default:
+		im.recalcAndSendParams()
+	}
+}
+
+// tickSchedule runs the due jobs outside of a recalculation step
+func (im *Imitator) tickSchedule() {
+	im.stepMu.Lock()
+	im.schedule.Tick()
+	im.stepMu.Unlock()
+}
+
 func (im *Imitator) recalcAndSendParams() {
+	im.recalc()
+	im.sendParams()
+}
+
+// recalc performs a recalculation step of the schedule, the scenario, the chaos mode and the UPS
+func (im *Imitator) recalc() {
+	im.stepMu.Lock()
+	im.schedule.Tick() // also while fast-forwarding
+	im.scenario.Tick()
+	im.chaos.Tick()
 	im.ups.RecalculateParams()
-	params := im.ups.GetParamsWithSimulatedMeasErr()
+	im.stepMu.Unlock()
+}
+
+// paramsToSend returns the params as read by the sensors, exact in manual mode if ManualSyncExact
+func (im *Imitator) paramsToSend() model.UpsParams {
+	var params model.UpsParams
+	if !im.GetMode() && im.conf.ManualSyncExact {
+		params = im.ups.GetAllParams()
+	} else {
+		params = im.ups.GetParamsWithSimulatedMeasErr()
+	}
+	im.replay.Apply(&params)
+	return params
+}
+
+// push sends the params at once after a change made via rest api in manual mode,
+// in auto mode they are sent after the next recalculation
+func (im *Imitator) push() {
+	if !im.GetMode() {
+		im.sendParams()
+	}
+}
+
+func (im *Imitator) sendParams() {
+	im.sendMu.Lock()
+	defer im.sendMu.Unlock()
+	params := im.paramsToSend()
 	paramBytes := params.GetParamBytes()
 	if _, err := im.client.WriteMultipleRegisters(model.RegInputAcVoltage, uint16(len(paramBytes)/2), paramBytes); err != nil {
 		log.Println(err)
 		return
 	}
+	extParamBytes := params.GetExtParamBytes()
+	if _, err := im.client.WriteMultipleRegisters(model.RegExtParamsStart, uint16(len(extParamBytes)/2), extParamBytes); err != nil {
+		log.Println(err)
+		return
+	}
+	log.Printf("Time: %v\n", im.clock.Now().Format(time.DateTime))
 	log.Printf("InputAcVoltage: %v\n", params.InputAcVoltage)
 	log.Printf("InputAcCurrent: %v\n", params.InputAcCurrent)
 	log.Printf("BatGroupVoltage: %v\n", params.BatGroupVoltage)
 	log.Printf("BatGroupCurrent: %v\n", params.BatGroupCurrent)
 	log.Printf("LoadCurrent: %v\n", params.LoadCurrent)
 	log.Printf("RemainingBatCapacity: %v\n", params.RemainingBatCapacity)
-	log.Printf("SOC: %v\n\n", params.SOC)
+	log.Printf("SOC: %v\n", params.SOC)
+	log.Printf("ChargerStage: %v\n", params.ChargerStage)
+	log.Printf("OperatingMode: %v\n", params.OperatingMode)
+	log.Printf("OutputAcVoltage: %v\n", params.OutputAcVoltage)
+	log.Printf("LoadPercent: %v\n\n", params.LoadPercent)
 
 	alarmBytes := params.GetAlarmBytes()
 	if _, err := im.client.WriteMultipleCoils(model.RegAlarmUpcInBatteryMode, model.NumOfAlarm, alarmBytes); err != nil {
@@ -60,6 +187,56 @@ func (im *Imitator) recalcAndSendParams() {
 	}
 }
 
+// pollCommand performs the command written to RegCommand by the monitoring, reports it to RegCommandStatus
+// and clears the command unless the monitoring has written a new one meanwhile. The commands wait while replaying,
+// the replayed params would hide their effect
+func (im *Imitator) pollCommand() {
+	if im.replay.IsRunning() {
+		return
+	}
+	command, err := im.readCommand()
+	if err != nil || command == model.CommandNone {
+		return
+	}
+	status := model.CommandStatusDone
+	switch command {
+	case model.CommandBatteryTest:
+		if err := im.StartBatteryTest(); err != nil {
+			log.Printf("battery test: %v\n", err)
+			status = model.CommandStatusRejected
+		}
+	default:
+		log.Printf("unknown command: %d\n", command)
+		status = model.CommandStatusUnknown
+	}
+	if _, err := im.client.WriteSingleRegister(model.RegCommandStatus, status); err != nil {
+		log.Println(err)
+	}
+	if current, err := im.readCommand(); err != nil || current != command {
+		return // the new command is performed at the next poll
+	}
+	if _, err := im.client.WriteSingleRegister(model.RegCommand, model.CommandNone); err != nil {
+		log.Println(err)
+	}
+}
+
+// readCommand reads RegCommand, the error is logged once until the register is read again
+func (im *Imitator) readCommand() (uint16, error) {
+	res, err := im.client.ReadHoldingRegisters(model.RegCommand, 1)
+	if err == nil && len(res) < 2 {
+		err = errors.New("short response")
+	}
+	if err != nil {
+		if !im.commandErr {
+			log.Printf("command register: %v\n", err)
+		}
+		im.commandErr = true
+		return 0, err
+	}
+	im.commandErr = false
+	return binary.BigEndian.Uint16(res), nil
+}
+
 func (im *Imitator) GetMode() bool {
 	return im.mode.Load()
 }
@@ -67,27 +244,314 @@ func (im *Imitator) GetMode() bool {
 func (im *Imitator) SetMode(val bool) {
 	old := im.mode.Swap(val)
 	if old != val {
+		im.ups.SetManual(!val)
 		if val {
 			im.ups.Reset()
-			im.upsSyncTicker.Reset(im.conf.UpsSyncInterval)
 		} else {
-			im.upsSyncTicker.Stop()
+			im.scenario.Stop()
+			im.replay.Stop()
+			im.chaos.Stop()
 		}
+		im.upsSyncTicker.Reset(im.conf.SyncTickInterval())
+	}
+}
+
+// maxFastForward limits the duration of a fast-forward
+const maxFastForward = 30 * 24 * time.Hour
+
+func (im *Imitator) GetClock() model.ClockStatus {
+	return model.ClockStatus{
+		Time:   im.clock.Now(),
+		Speed:  im.conf.ClockSpeed,
+		Paused: im.clock.Paused(),
 	}
 }
 
+// Pause freezes the simulated time and the recalculation, the state is kept
+func (im *Imitator) Pause() error {
+	if im.clock.Paused() {
+		return errors.New("already paused")
+	}
+	im.clock.Pause()
+	return nil
+}
+
+func (im *Imitator) Resume() error {
+	if !im.clock.Paused() {
+		return errors.New("not paused")
+	}
+	im.clock.Resume()
+	return nil
+}
+
+// Step performs exactly one recalculation step of UpsSyncInterval while paused and sends the params
+func (im *Imitator) Step() error {
+	if !im.clock.Paused() {
+		return errors.New("not paused")
+	}
+	im.clock.Advance(im.conf.UpsSyncInterval)
+	im.recalcAndSendParams()
+	return nil
+}
+
+// FastForward advances the simulation by d at once in steps of UpsSyncInterval, the params are sent at the end
+func (im *Imitator) FastForward(d time.Duration) error {
+	if d <= 0 || d > maxFastForward {
+		return fmt.Errorf("duration must be from 0 to %v", maxFastForward)
+	}
+	for d > 0 {
+		step := min(d, im.conf.UpsSyncInterval)
+		im.clock.Advance(step)
+		im.recalc()
+		d -= step
+	}
+	im.sendParams()
+	return nil
+}
+
 func (im *Imitator) GetAllUpsParams() model.UpsParams {
-	return im.ups.GetAllParams()
+	params := im.ups.GetAllParams()
+	im.replay.Apply(&params)
+	return params
 }
 
 func (im *Imitator) UpdateUpsParams(form model.UpsParamsUpdateForm) {
 	im.ups.UpdateParams(form)
+	im.push()
 }
 
 func (im *Imitator) UpdateUpsBatteryParams(bat_id int, batParams model.BatteryParamsUpdateForm) error {
-	return im.ups.UpdateBatteryParams(bat_id, batParams)
+	if err := im.ups.UpdateBatteryParams(bat_id, batParams); err != nil {
+		return err
+	}
+	im.push()
+	return nil
+}
+
+func (im *Imitator) UpdateUpsPhaseParams(phase_id int, phaseParams model.PhaseParamsUpdateForm) error {
+	if err := im.ups.UpdatePhaseParams(phase_id, phaseParams); err != nil {
+		return err
+	}
+	im.push()
+	return nil
 }
 
 func (im *Imitator) UpdateAlarms(alarms model.AlarmsUpdateForm) {
 	im.ups.UpdateAlarms(alarms)
+	im.push()
+}
+
+func (im *Imitator) RequestBypass() error {
+	if err := im.ups.RequestBypass(); err != nil {
+		return err
+	}
+	im.push()
+	return nil
+}
+
+func (im *Imitator) ReturnFromBypass() error {
+	if err := im.ups.ReturnFromBypass(); err != nil {
+		return err
+	}
+	im.push()
+	return nil
+}
+
+func (im *Imitator) FailInverter() error {
+	if err := im.ups.FailInverter(); err != nil {
+		return err
+	}
+	im.push()
+	return nil
+}
+
+func (im *Imitator) RepairInverter() error {
+	if err := im.ups.RepairInverter(); err != nil {
+		return err
+	}
+	im.push()
+	return nil
+}
+
+func (im *Imitator) AddBatteryFault(bat_id int, fault string) error {
+	if err := im.ups.AddBatteryFault(bat_id, fault); err != nil {
+		return err
+	}
+	im.push()
+	return nil
+}
+
+func (im *Imitator) ClearBatteryFault(bat_id int, fault string) error {
+	if err := im.ups.ClearBatteryFault(bat_id, fault); err != nil {
+		return err
+	}
+	im.push()
+	return nil
+}
+
+// StartBatteryTest starts the battery self-test, only in auto mode as the model must run to complete it
+func (im *Imitator) StartBatteryTest() error {
+	if !im.GetMode() {
+		return errors.New("manual mode")
+	}
+	return im.ups.StartBatteryTest()
+}
+
+func (im *Imitator) GetBatteryTest() model.BatteryTestParams {
+	return im.ups.GetAllParams().BatteryTest
+}
+
+func (im *Imitator) GetGenerator() model.GeneratorParams {
+	return im.ups.GetGenerator()
+}
+
+func (im *Imitator) UpdateGenerator(form model.GeneratorUpdateForm) error {
+	if err := im.ups.UpdateGenerator(form); err != nil {
+		return err
+	}
+	im.push()
+	return nil
+}
+
+func (im *Imitator) GetSensors() model.SensorsConfig {
+	return im.ups.GetSensors()
+}
+
+func (im *Imitator) SetSensor(name string, conf model.SensorConfig) error {
+	if err := im.ups.SetSensor(name, conf); err != nil {
+		return err
+	}
+	im.push()
+	return nil
+}
+
+func (im *Imitator) DeleteSensor(name string) error {
+	if err := im.ups.DeleteSensor(name); err != nil {
+		return err
+	}
+	im.push()
+	return nil
+}
+
+// Pin holds the param at the value while the model keeps running, in both modes
+func (im *Imitator) Pin(name string, form model.PinForm) error {
+	if err := form.Validate(); err != nil {
+		return err
+	}
+	if err := im.ups.Pin(name, *form.Value, time.Duration(form.Duration*float64(time.Second))); err != nil {
+		return err
+	}
+	im.push()
+	return nil
+}
+
+func (im *Imitator) Unpin(name string) error {
+	if err := im.ups.Unpin(name); err != nil {
+		return err
+	}
+	im.push()
+	return nil
+}
+
+func (im *Imitator) GetLoadProfile() string {
+	return im.ups.GetLoadProfile()
+}
+
+func (im *Imitator) SetLoadProfile(profile string) error {
+	if err := im.ups.SetLoadProfile(profile); err != nil {
+		return err
+	}
+	im.push()
+	return nil
+}
+
+// UploadScenario parses the scenario from YAML or JSON and replaces the uploaded one
+func (im *Imitator) UploadScenario(data []byte) error {
+	sc, err := model.ParseScenario(data)
+	if err != nil {
+		return err
+	}
+	im.scenario.Upload(sc)
+	return nil
+}
+
+func (im *Imitator) StartScenario() error {
+	if im.chaos.IsRunning() {
+		return errors.New("chaos mode is running")
+	}
+	return im.scenario.Start()
+}
+
+func (im *Imitator) StopScenario() error {
+	return im.scenario.Stop()
+}
+
+func (im *Imitator) GetScenarioProgress() model.ScenarioProgress {
+	return im.scenario.GetProgress()
+}
+
+// UploadRecording parses the recorded telemetry from CSV or OpenTSDB JSON and replaces the loaded one,
+// the columns are mapped by the replay config
+func (im *Imitator) UploadRecording(data []byte) error {
+	rec, err := model.ParseRecording(data)
+	if err != nil {
+		return err
+	}
+	return im.replay.Load(rec, im.conf.Replay.Columns)
+}
+
+// StartReplay replays the loaded recording over the params sent to the UPS, the model keeps running beneath
+func (im *Imitator) StartReplay(form model.ReplayStartForm) error {
+	speed, loop := im.conf.Replay.Speed, im.conf.Replay.Loop
+	if form.Speed != nil {
+		speed = *form.Speed
+	}
+	if form.Loop != nil {
+		loop = *form.Loop
+	}
+	return im.replay.Start(speed, loop)
+}
+
+func (im *Imitator) StopReplay() error {
+	return im.replay.Stop()
+}
+
+func (im *Imitator) GetReplayProgress() model.ReplayProgress {
+	return im.replay.GetProgress()
+}
+
+// StartChaos starts the random mains outages, the seed of the config is used unless overridden
+func (im *Imitator) StartChaos(form model.ChaosStartForm) error {
+	if im.scenario.IsRunning() {
+		return errors.New("scenario is running")
+	}
+	return im.chaos.Start(form.Seed)
+}
+
+func (im *Imitator) StopChaos() error {
+	return im.chaos.Stop()
+}
+
+func (im *Imitator) GetChaosProgress() model.ChaosProgress {
+	return im.chaos.GetProgress()
+}
+
+// GetSchedule returns the scheduled jobs with their runs
+func (im *Imitator) GetSchedule() []model.ScheduledJobStatus {
+	return im.schedule.List()
+}
+
+// SetSchedule replaces the scheduled jobs
+func (im *Imitator) SetSchedule(jobs model.ScheduledJobs) error {
+	return im.schedule.Set(jobs)
+}
+
+// PutScheduledJob adds the job or replaces the one with the same name
+func (im *Imitator) PutScheduledJob(name string, job model.ScheduledJob) error {
+	job.Name = name
+	return im.schedule.Put(job)
+}
+
+func (im *Imitator) DeleteScheduledJob(name string) error {
+	return im.schedule.Delete(name)
 }
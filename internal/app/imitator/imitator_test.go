@@ -1,10 +1,20 @@
 package imitator
 
 import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"log"
+	"math"
+	"os"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/alex11prog/ups-imitator/internal/app/clock"
 	"github.com/alex11prog/ups-imitator/internal/app/imitator/mockmodbus"
 	"github.com/alex11prog/ups-imitator/internal/app/model"
+	"github.com/alex11prog/ups-imitator/internal/app/utils"
 	"github.com/stretchr/testify/require"
 )
 
@@ -17,13 +27,207 @@ func Test_recalcAndSendParams(t *testing.T) {
 	require.Equal(t, 1, len(sentAlarmsData))
 	sentAlarms := sentAlarmsData[0]
 	require.Equal(t, uint16(0), sentAlarms.Address)
-	require.Equal(t, uint16(3), sentAlarms.Quantity)
+	require.Equal(t, uint16(model.NumOfAlarm), sentAlarms.Quantity)
 	require.Equal(t, 1, len(sentAlarms.Value))
 
 	sentParamsData := mockModbus.GetWriteMultipleRegistersQueries()
-	require.Equal(t, 1, len(sentParamsData))
+	require.Equal(t, 2, len(sentParamsData))
 	sentParams := sentParamsData[0]
 	require.Equal(t, uint16(0), sentParams.Address)
 	require.Equal(t, uint16(70), sentParams.Quantity)
 	require.Equal(t, 140, len(sentParams.Value))
+
+	sentExtParams := sentParamsData[1]
+	require.Equal(t, model.RegExtParamsStart, sentExtParams.Address)
+	require.Equal(t, model.RegExtParamsEnd-model.RegExtParamsStart, sentExtParams.Quantity)
+	require.Equal(t, int(sentExtParams.Quantity)*2, len(sentExtParams.Value))
+}
+
+func Test_recalcAndSendParams_fullCycle(t *testing.T) {
+	conf := model.TestConfig(t)
+	clk := clock.NewFake(time.Now())
+	imitator := NewWithClock(mockmodbus.New(), conf, clk)
+
+	var modes []model.OperatingMode
+	for range 24 * time.Hour / conf.UpsSyncInterval {
+		clk.Advance(conf.UpsSyncInterval)
+		imitator.recalcAndSendParams()
+		mode := imitator.GetAllUpsParams().OperatingMode
+		if len(modes) == 0 || modes[len(modes)-1] != mode {
+			modes = append(modes, mode)
+		}
+	}
+	require.GreaterOrEqual(t, len(modes), 4)
+	require.Equal(t, []model.OperatingMode{model.ModeOnline, model.ModeOnBattery, model.ModeShutdown, model.ModeOnline}, modes[:4])
+}
+
+func Test_FastForward(t *testing.T) {
+	conf := model.TestConfig(t)
+	clk := clock.NewFake(time.Now())
+	mockModbus := mockmodbus.New()
+	imitator := NewWithClock(mockModbus, conf, clk)
+	start := clk.Now()
+
+	require.Error(t, imitator.Step(), "not paused")
+	require.NoError(t, imitator.Pause())
+	require.NoError(t, imitator.Step())
+	require.Equal(t, conf.UpsSyncInterval, clk.Since(start))
+
+	imitator.ups.SetMains(false)
+	require.NoError(t, imitator.FastForward(time.Hour+time.Second))
+	require.Equal(t, time.Hour+time.Second+conf.UpsSyncInterval, clk.Since(start))
+	require.Less(t, imitator.GetAllUpsParams().SOC, float32(0.9), "discharged during the hour")
+	require.Len(t, mockModbus.GetWriteMultipleCoilsQueries(), 2, "sent after the step and at the end")
+	require.Error(t, imitator.FastForward(0))
+	require.NoError(t, imitator.Resume())
+}
+
+func Test_push(t *testing.T) {
+	conf := model.TestConfig(t)
+	conf.ManualSyncExact = true
+	mockModbus := mockmodbus.New()
+	imitator := New(mockModbus, conf)
+
+	imitator.UpdateAlarms(model.AlarmsUpdateForm{})
+	require.Empty(t, mockModbus.GetWriteMultipleRegistersQueries(), "auto mode, sent after the recalculation")
+
+	imitator.SetMode(false)
+	imitator.UpdateUpsParams(model.UpsParamsUpdateForm{InputAcVoltage: utils.NewP(float32(205.3))})
+	sent := mockModbus.GetWriteMultipleRegistersQueries()
+	require.Len(t, sent, 2)
+	voltage := math.Float32frombits(binary.BigEndian.Uint32(sent[0].Value[model.RegInputAcVoltage*2:]))
+	require.Equal(t, float32(205.3), voltage, "exact, no sensor noise")
+	require.Len(t, mockModbus.GetWriteMultipleCoilsQueries(), 1)
+
+	require.Error(t, imitator.UpdateUpsBatteryParams(5, model.BatteryParamsUpdateForm{}))
+	require.Len(t, mockModbus.GetWriteMultipleRegistersQueries(), 2, "not sent on error")
+}
+
+func Test_schedule(t *testing.T) {
+	conf := model.TestConfig(t)
+	start := time.Date(2024, 7, 1, 0, 0, 0, 0, time.Local)
+	conf.Schedule.Jobs = model.ScheduledJobs{
+		{Name: "peak", Cron: "0 2 * * *", Action: model.ActionSetLoad, Power: 2000},
+		{Name: "manual", Cron: "0 3 * * *", Action: model.ActionSetMode, Mode: model.ModeManual},
+	}
+	clk := clock.NewFake(start)
+	imitator := NewWithClock(mockmodbus.New(), conf, clk)
+	require.NoError(t, imitator.FastForward(time.Hour))
+	require.Equal(t, conf.LoadPower, imitator.ups.GetLoadPower())
+	require.NoError(t, imitator.FastForward(time.Hour+time.Minute))
+	require.Equal(t, float32(2000), imitator.ups.GetLoadPower(), "fired while fast-forwarding")
+	require.NoError(t, imitator.FastForward(time.Hour))
+	require.False(t, imitator.GetMode())
+	require.Equal(t, start.Add(3*time.Hour), *imitator.GetSchedule()[1].LastRun)
+}
+
+func Test_sync_schedule(t *testing.T) {
+	conf := model.TestConfig(t)
+	start := time.Date(2024, 7, 1, 0, 0, 0, 0, time.Local)
+	conf.Schedule.Jobs = model.ScheduledJobs{
+		{Name: "peak", Cron: "0 * * * *", Action: model.ActionSetLoad, Power: 2000},
+	}
+	clk := clock.NewFake(start)
+	mockModbus := mockmodbus.New()
+	imitator := NewWithClock(mockModbus, conf, clk)
+
+	clk.Advance(time.Hour)
+	imitator.sync()
+	require.Equal(t, float32(2000), imitator.ups.GetLoadPower(), "auto mode")
+	require.Equal(t, start.Add(time.Hour), *imitator.GetSchedule()[0].LastRun)
+
+	imitator.SetMode(false)
+	imitator.ups.SetLoadPower(1000)
+	clk.Advance(time.Hour)
+	imitator.sync()
+	require.Equal(t, float32(2000), imitator.ups.GetLoadPower(), "manual mode")
+	require.Equal(t, start.Add(2*time.Hour), *imitator.GetSchedule()[0].LastRun)
+	require.NotEmpty(t, mockModbus.GetWriteMultipleRegistersQueries(), "sent")
+}
+
+func Test_pollCommand(t *testing.T) {
+	conf := model.TestConfig(t)
+	mockModbus := mockmodbus.New()
+	imitator := NewWithClock(mockModbus, conf, clock.NewFake(time.Now()))
+
+	imitator.pollCommand()
+	require.Equal(t, model.BatteryTestNone, imitator.GetBatteryTest().Result, "no command")
+
+	mockModbus.HoldingRegisters[model.RegCommand] = model.CommandBatteryTest
+	imitator.pollCommand()
+	require.Equal(t, model.BatteryTestRunning, imitator.GetBatteryTest().Result)
+	require.Equal(t, model.CommandNone, mockModbus.HoldingRegisters[model.RegCommand], "cleared")
+
+	require.NoError(t, imitator.FastForward(conf.BatteryTest.Duration))
+	require.Equal(t, model.BatteryTestPassed, imitator.GetBatteryTest().Result)
+	require.Equal(t, model.ModeOnline, imitator.GetAllUpsParams().OperatingMode)
+
+	imitator.SetMode(false)
+	mockModbus.HoldingRegisters[model.RegCommand] = model.CommandBatteryTest
+	imitator.pollCommand()
+	require.Equal(t, model.CommandNone, mockModbus.HoldingRegisters[model.RegCommand], "cleared in manual mode")
+	require.Equal(t, model.CommandStatusRejected, mockModbus.HoldingRegisters[model.RegCommandStatus])
+	require.Equal(t, model.BatteryTestPassed, imitator.GetBatteryTest().Result, "not started in manual mode")
+}
+
+func Test_pollCommand_status(t *testing.T) {
+	conf := model.TestConfig(t)
+	mockModbus := mockmodbus.New()
+	imitator := NewWithClock(mockModbus, conf, clock.NewFake(time.Now()))
+
+	mockModbus.HoldingRegisters[model.RegCommand] = 99
+	imitator.pollCommand()
+	require.Equal(t, model.CommandNone, mockModbus.HoldingRegisters[model.RegCommand])
+	require.Equal(t, model.CommandStatusUnknown, mockModbus.HoldingRegisters[model.RegCommandStatus])
+
+	// the monitoring writes a new command while the previous one is performed
+	mockModbus.HoldingRegisters[model.RegCommand] = 99
+	mockModbus.OnWriteSingleRegister = func(address uint16) {
+		if address == model.RegCommandStatus {
+			mockModbus.HoldingRegisters[model.RegCommand] = model.CommandBatteryTest
+		}
+	}
+	imitator.pollCommand()
+	mockModbus.OnWriteSingleRegister = nil
+	require.Equal(t, model.CommandBatteryTest, mockModbus.HoldingRegisters[model.RegCommand], "not erased")
+	imitator.pollCommand()
+	require.Equal(t, model.CommandNone, mockModbus.HoldingRegisters[model.RegCommand])
+	require.Equal(t, model.CommandStatusDone, mockModbus.HoldingRegisters[model.RegCommandStatus])
+	require.Equal(t, model.BatteryTestRunning, imitator.GetBatteryTest().Result)
+}
+
+func Test_pollCommand_replay(t *testing.T) {
+	conf := model.TestConfig(t)
+	mockModbus := mockmodbus.New()
+	imitator := NewWithClock(mockModbus, conf, clock.NewFake(time.Now()))
+	require.NoError(t, imitator.UploadRecording([]byte("time,ups.temp\n0,25\n60,27\n")))
+	require.NoError(t, imitator.StartReplay(model.ReplayStartForm{}))
+
+	mockModbus.HoldingRegisters[model.RegCommand] = model.CommandBatteryTest
+	imitator.pollCommand()
+	require.Equal(t, model.CommandBatteryTest, mockModbus.HoldingRegisters[model.RegCommand], "waits for the replay")
+	require.Equal(t, model.BatteryTestNone, imitator.GetBatteryTest().Result)
+
+	require.NoError(t, imitator.StopReplay())
+	imitator.pollCommand()
+	require.Equal(t, model.BatteryTestRunning, imitator.GetBatteryTest().Result)
+}
+
+func Test_pollCommand_readError(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+	mockModbus := mockmodbus.New()
+	imitator := NewWithClock(mockModbus, model.TestConfig(t), clock.NewFake(time.Now()))
+
+	mockModbus.ReadHoldingRegistersErr = errors.New("timeout")
+	imitator.pollCommand()
+	imitator.pollCommand()
+	require.Equal(t, 1, strings.Count(buf.String(), "command register: timeout"), "logged once")
+
+	mockModbus.ReadHoldingRegistersErr = nil
+	imitator.pollCommand()
+	mockModbus.ReadHoldingRegistersErr = errors.New("timeout")
+	imitator.pollCommand()
+	require.Equal(t, 2, strings.Count(buf.String(), "command register: timeout"), "logged again after a success")
 }
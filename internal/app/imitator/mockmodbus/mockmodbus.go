@@ -1,5 +1,7 @@
 package mockmodbus
 
+import "encoding/binary"
+
 type QueryParams struct {
 	Address, Quantity uint16
 	Value             []byte
@@ -8,10 +10,13 @@ type QueryParams struct {
 type MockModbus struct {
 	WriteMultipleCoilsQueries     []QueryParams
 	WriteMultipleRegistersQueries []QueryParams
+	HoldingRegisters              map[uint16]uint16    // read by ReadHoldingRegisters, written by WriteSingleRegister
+	ReadHoldingRegistersErr       error                // returned by ReadHoldingRegisters if set
+	OnWriteSingleRegister         func(address uint16) // called after WriteSingleRegister if set
 }
 
 func New() *MockModbus {
-	return &MockModbus{}
+	return &MockModbus{HoldingRegisters: map[uint16]uint16{}}
 }
 
 func (m *MockModbus) ReadCoils(address, quantity uint16) (results []byte, err error) {
@@ -42,10 +47,21 @@ func (m *MockModbus) ReadInputRegisters(address, quantity uint16) (results []byt
 }
 
 func (m *MockModbus) ReadHoldingRegisters(address, quantity uint16) (results []byte, err error) {
-	return nil, nil
+	if m.ReadHoldingRegistersErr != nil {
+		return nil, m.ReadHoldingRegistersErr
+	}
+	results = make([]byte, quantity*2)
+	for i := range quantity {
+		binary.BigEndian.PutUint16(results[i*2:], m.HoldingRegisters[address+i])
+	}
+	return results, nil
 }
 
 func (m *MockModbus) WriteSingleRegister(address, value uint16) (results []byte, err error) {
+	m.HoldingRegisters[address] = value
+	if m.OnWriteSingleRegister != nil {
+		m.OnWriteSingleRegister(address)
+	}
 	return nil, nil
 }
 
@@ -0,0 +1,145 @@
+// Package replay replays recorded UPS telemetry over the simulated params
+package replay
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/alex11prog/ups-imitator/internal/app/clock"
+	"github.com/alex11prog/ups-imitator/internal/app/model"
+)
+
+type Player struct {
+	clock clock.Clock
+
+	mu        sync.Mutex
+	recording *model.Recording
+	mapping   map[string]model.ParamRef // by column
+	columns   map[string]string         // column -> param as configured, for the progress
+	status    string
+	speed     float64
+	loop      bool
+	startTime time.Time
+	position  time.Duration // since the start, including the loops
+}
+
+func New(clk clock.Clock) *Player {
+	return &Player{clock: clk}
+}
+
+// Load replaces the recording with the columns mapped onto the params, a running replay is stopped
+func (p *Player) Load(rec *model.Recording, columns map[string]string) error {
+	mapping, err := rec.Mapping(columns)
+	if err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.recording = rec
+	p.mapping = mapping
+	p.columns = map[string]string{}
+	for column := range mapping {
+		p.columns[column] = columns[column]
+		if p.columns[column] == "" {
+			p.columns[column] = column
+		}
+	}
+	p.status = model.ReplayIdle
+	p.position = 0
+	return nil
+}
+
+// Start replays the loaded recording from the beginning
+func (p *Player) Start(speed float64, loop bool) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.recording == nil {
+		return errors.New("no recording loaded")
+	}
+	if p.status == model.ReplayRunning {
+		return errors.New("replay is already running")
+	}
+	if speed < 0.01 || speed > 1000 {
+		return errors.New("speed must be from 0.01 to 1000")
+	}
+	log.Printf("replay: started, speed %v, loop %v\n", speed, loop)
+	p.status = model.ReplayRunning
+	p.speed = speed
+	p.loop = loop
+	p.startTime = p.clock.Now()
+	p.position = 0
+	return nil
+}
+
+// IsRunning reports whether the replay is running
+func (p *Player) IsRunning() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.status == model.ReplayRunning
+}
+
+func (p *Player) Stop() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.status != model.ReplayRunning {
+		return errors.New("replay is not running")
+	}
+	p.finish(model.ReplayStopped)
+	return nil
+}
+
+// Apply overrides the mapped params with the recorded values at the current position
+func (p *Player) Apply(params *model.UpsParams) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.status != model.ReplayRunning {
+		return
+	}
+	p.position = time.Duration(float64(p.clock.Since(p.startTime)) * p.speed)
+	at := p.position
+	if duration := p.recording.Duration; at > duration {
+		if !p.loop || duration == 0 {
+			p.finish(model.ReplayFinished)
+			return
+		}
+		at %= duration
+	}
+	for column, param := range p.mapping {
+		param.Set(params, p.recording.Columns[column].Value(at, param.IsDiscrete()))
+	}
+}
+
+func (p *Player) GetProgress() model.ReplayProgress {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	progress := model.ReplayProgress{Status: p.status, Speed: p.speed, Loop: p.loop, Columns: p.columns}
+	if p.recording == nil {
+		return progress
+	}
+	duration := p.recording.Duration
+	progress.Duration = float32(duration.Seconds())
+	if p.status == model.ReplayIdle {
+		return progress
+	}
+	position := p.position
+	if p.status == model.ReplayRunning {
+		position = time.Duration(float64(p.clock.Since(p.startTime)) * p.speed)
+	}
+	progress.Position = float32(position.Seconds())
+	if duration > 0 {
+		progress.Iteration = int(position/duration) + 1
+		progress.Position = float32((position % duration).Seconds())
+	}
+	if !p.loop && position > duration {
+		progress.Iteration = 1
+		progress.Position = progress.Duration
+	}
+	return progress
+}
+
+func (p *Player) finish(status string) {
+	log.Printf("replay: %v\n", status)
+	p.status = status
+}
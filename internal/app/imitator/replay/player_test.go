@@ -0,0 +1,81 @@
+package replay
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alex11prog/ups-imitator/internal/app/clock"
+	"github.com/alex11prog/ups-imitator/internal/app/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testRecording = `time,input_ac_voltage,ups.temp,alarms.upc_in_battery_mode
+0,220,25,0
+10,0,26,1
+20,220,30,0
+`
+
+func newTestPlayer(t *testing.T) (*Player, *clock.Fake) {
+	rec, err := model.ParseRecording([]byte(testRecording))
+	require.NoError(t, err)
+	clk := clock.NewFake(time.Now())
+	p := New(clk)
+	require.NoError(t, p.Load(rec, map[string]string{"ups.temp": "battery_temp[1]"}))
+	return p, clk
+}
+
+func Test_Player_Apply(t *testing.T) {
+	p, clk := newTestPlayer(t)
+	params := model.UpsParams{InputAcVoltage: 230}
+	p.Apply(&params)
+	assert.Equal(t, float32(230), params.InputAcVoltage, "not started")
+
+	require.NoError(t, p.Start(2, false))
+	clk.Advance(2500 * time.Millisecond) // 5 sec of the recording
+	p.Apply(&params)
+	assert.Equal(t, float32(110), params.InputAcVoltage, "interpolated")
+	assert.Equal(t, float32(25.5), params.Batteries[1].Temp)
+	assert.False(t, params.Alarms.UpcInBatteryMode, "stepped")
+
+	clk.Advance(2500 * time.Millisecond)
+	p.Apply(&params)
+	assert.Zero(t, params.InputAcVoltage)
+	assert.True(t, params.Alarms.UpcInBatteryMode)
+	progress := p.GetProgress()
+	assert.Equal(t, model.ReplayRunning, progress.Status)
+	assert.Equal(t, float32(10), progress.Position)
+	assert.Equal(t, float32(20), progress.Duration)
+	assert.Equal(t, "battery_temp[1]", progress.Columns["ups.temp"])
+
+	clk.Advance(10 * time.Second)
+	params.InputAcVoltage = 230
+	p.Apply(&params)
+	assert.Equal(t, float32(230), params.InputAcVoltage, "finished")
+	assert.Equal(t, model.ReplayFinished, p.GetProgress().Status)
+}
+
+func Test_Player_loop(t *testing.T) {
+	p, clk := newTestPlayer(t)
+	require.NoError(t, p.Start(1, true))
+	clk.Advance(30 * time.Second)
+	var params model.UpsParams
+	p.Apply(&params)
+	assert.Zero(t, params.InputAcVoltage, "the second iteration")
+	progress := p.GetProgress()
+	assert.Equal(t, 2, progress.Iteration)
+	assert.Equal(t, float32(10), progress.Position)
+}
+
+func Test_Player_Start(t *testing.T) {
+	p := New(clock.NewFake(time.Now()))
+	assert.Error(t, p.Start(1, false), "no recording")
+
+	p, _ = newTestPlayer(t)
+	assert.Error(t, p.Stop(), "not running")
+	assert.Error(t, p.Start(0, false), "invalid speed")
+	require.NoError(t, p.Start(1, false))
+	assert.Error(t, p.Start(1, false), "already running")
+	require.NoError(t, p.Stop())
+	assert.Equal(t, model.ReplayStopped, p.GetProgress().Status)
+}
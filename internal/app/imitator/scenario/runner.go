@@ -0,0 +1,304 @@
+// Package scenario runs scripted timelines of actions against the UPS
+package scenario
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alex11prog/ups-imitator/internal/app/clock"
+	"github.com/alex11prog/ups-imitator/internal/app/model"
+)
+
+// maxStepsPerTick protects from a loop without delays
+const maxStepsPerTick = 1000
+
+// Target is the UPS driven by the scenario
+type Target interface {
+	GetAllParams() model.UpsParams
+	SetMains(on bool)
+	UpdateParams(form model.UpsParamsUpdateForm)
+	GetLoadPower() float32
+	SetLoadPower(power float32)
+	SetLoadProfile(profile string) error
+	UpdateBatteryParams(bat_id int, form model.BatteryParamsUpdateForm) error
+	AddBatteryFault(bat_id int, fault string) error
+	ClearBatteryFault(bat_id int, fault string) error
+	RequestBypass() error
+	ReturnFromBypass() error
+	FailInverter() error
+	RepairInverter() error
+	SuspendCycle(suspend bool)
+}
+
+// step phases, performed in order
+const (
+	phaseIf = iota
+	phaseDelay
+	phaseWaitUntil
+	phaseAction
+	phaseLoop
+)
+
+// frame is a position in a list of steps, the nested steps of a loop are a frame above
+type frame struct {
+	steps     []model.ScenarioStep
+	index     int
+	iteration int // of the loop owning the steps, from 1
+}
+
+type Runner struct {
+	target Target
+	clock  clock.Clock
+
+	mu        sync.Mutex
+	scenario  *model.Scenario
+	status    string
+	err       string
+	startTime time.Time
+	stopTime  time.Time
+	frames    []frame
+	phase     int
+	phaseTime time.Time // start of the current phase
+
+	rampFrom float32 // W, the load power at the start of the ramp
+}
+
+func New(target Target, clk clock.Clock) *Runner {
+	return &Runner{target: target, clock: clk}
+}
+
+// Upload replaces the scenario, a running one is stopped
+func (r *Runner) Upload(sc *model.Scenario) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.status == model.ScenarioRunning {
+		r.finish(model.ScenarioStopped, "")
+	}
+	r.scenario = sc
+	r.status = model.ScenarioIdle
+	r.err = ""
+	r.frames = nil
+}
+
+// Start runs the uploaded scenario from the beginning. The auto mode cycle is suspended until it ends
+func (r *Runner) Start() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.scenario == nil {
+		return errors.New("no scenario uploaded")
+	}
+	if r.status == model.ScenarioRunning {
+		return errors.New("scenario is already running")
+	}
+	log.Printf("scenario %q: started\n", r.scenario.Name)
+	r.target.SuspendCycle(true)
+	r.status = model.ScenarioRunning
+	r.err = ""
+	r.startTime = r.clock.Now()
+	r.frames = []frame{{steps: r.scenario.Steps}}
+	r.enterPhase(phaseIf)
+	return nil
+}
+
+func (r *Runner) Stop() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.status != model.ScenarioRunning {
+		return errors.New("scenario is not running")
+	}
+	r.finish(model.ScenarioStopped, "")
+	return nil
+}
+
+// IsRunning reports whether the scenario is running
+func (r *Runner) IsRunning() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.status == model.ScenarioRunning
+}
+
+func (r *Runner) GetProgress() model.ScenarioProgress {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	progress := model.ScenarioProgress{Status: r.status, Error: r.err}
+	if r.scenario == nil {
+		return progress
+	}
+	progress.Name = r.scenario.Name
+	if r.startTime.IsZero() {
+		return progress
+	}
+	end := r.clock.Now()
+	if r.status != model.ScenarioRunning {
+		end = r.stopTime
+	}
+	progress.Elapsed = float32(end.Sub(r.startTime).Seconds())
+	if r.status != model.ScenarioFinished {
+		progress.Step = r.stepPath()
+		progress.Iteration = r.frames[len(r.frames)-1].iteration
+	}
+	return progress
+}
+
+// Tick advances the running scenario, it should be called before each recalculation of the UPS params
+func (r *Runner) Tick() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for range maxStepsPerTick {
+		if r.status != model.ScenarioRunning || !r.advance() {
+			return
+		}
+	}
+}
+
+// advance performs the current phase of the current step, returns false if it has to wait
+func (r *Runner) advance() bool {
+	top := &r.frames[len(r.frames)-1]
+	if top.index >= len(top.steps) {
+		return r.popFrame()
+	}
+	step := &top.steps[top.index]
+	switch r.phase {
+	case phaseIf:
+		if step.If != nil && !step.If.Eval(r.params()) {
+			r.nextStep()
+			return true
+		}
+		r.enterPhase(phaseDelay)
+	case phaseDelay:
+		if step.At > 0 && r.clock.Since(r.startTime) < time.Duration(step.At) ||
+			r.clock.Since(r.phaseTime) < time.Duration(step.Wait) {
+			return false
+		}
+		r.enterPhase(phaseWaitUntil)
+	case phaseWaitUntil:
+		if step.WaitUntil != nil && !step.WaitUntil.Eval(r.params()) {
+			if step.Timeout > 0 && r.clock.Since(r.phaseTime) >= time.Duration(step.Timeout) {
+				r.finish(model.ScenarioFailed, fmt.Sprintf("step %v: wait_until timed out", r.stepPath()))
+			}
+			return false
+		}
+		r.rampFrom = r.target.GetLoadPower()
+		r.enterPhase(phaseAction)
+	case phaseAction:
+		done, err := r.perform(step)
+		if err != nil {
+			r.finish(model.ScenarioFailed, fmt.Sprintf("step %v: %v", r.stepPath(), err))
+			return false
+		}
+		if !done {
+			return false
+		}
+		r.enterPhase(phaseLoop)
+	case phaseLoop:
+		if len(step.Steps) == 0 || r.loopDone(step, 0) {
+			r.nextStep()
+			return true
+		}
+		r.frames = append(r.frames, frame{steps: step.Steps, iteration: 1})
+		r.enterPhase(phaseIf)
+	}
+	return true
+}
+
+// popFrame ends an iteration of the loop, the loop is repeated unless it is done
+func (r *Runner) popFrame() bool {
+	if len(r.frames) == 1 {
+		r.finish(model.ScenarioFinished, "")
+		return false
+	}
+	iteration := r.frames[len(r.frames)-1].iteration
+	r.frames = r.frames[:len(r.frames)-1]
+	top := &r.frames[len(r.frames)-1]
+	step := &top.steps[top.index]
+	if r.loopDone(step, iteration) {
+		r.nextStep()
+		return true
+	}
+	r.frames = append(r.frames, frame{steps: step.Steps, iteration: iteration + 1})
+	r.enterPhase(phaseIf)
+	return true
+}
+
+// loopDone reports whether the loop of the step is done after the iterations
+func (r *Runner) loopDone(step *model.ScenarioStep, iterations int) bool {
+	if step.Repeat > 0 && iterations >= step.Repeat {
+		return true
+	}
+	return step.Until != nil && step.Until.Eval(r.params())
+}
+
+// perform performs the action of the step, returns false while the action is in progress
+func (r *Runner) perform(step *model.ScenarioStep) (done bool, err error) {
+	switch step.Action {
+	case model.ActionMainsOff:
+		r.target.SetMains(false)
+	case model.ActionMainsOn:
+		r.target.SetMains(true)
+	case model.ActionSetInput:
+		r.target.UpdateParams(model.UpsParamsUpdateForm{InputAcVoltage: step.Voltage, InputFrequency: step.Frequency})
+	case model.ActionSetLoad:
+		sinceStart := r.clock.Since(r.phaseTime)
+		if sinceStart < time.Duration(step.Duration) {
+			fraction := float32(sinceStart) / float32(step.Duration)
+			r.target.SetLoadPower(r.rampFrom + (step.Power-r.rampFrom)*fraction)
+			return false, nil
+		}
+		r.target.SetLoadPower(step.Power)
+	case model.ActionLoadProfile:
+		err = r.target.SetLoadProfile(step.Profile)
+	case model.ActionSetBattery:
+		err = r.target.UpdateBatteryParams(step.Battery, model.BatteryParamsUpdateForm{Temp: step.Temp, Resist: step.Resist})
+	case model.ActionAddBatteryFault:
+		err = r.target.AddBatteryFault(step.Battery, step.Fault)
+	case model.ActionClearBatteryFault:
+		err = r.target.ClearBatteryFault(step.Battery, step.Fault)
+	case model.ActionRequestBypass:
+		err = r.target.RequestBypass()
+	case model.ActionReturnFromBypass:
+		err = r.target.ReturnFromBypass()
+	case model.ActionFailInverter:
+		err = r.target.FailInverter()
+	case model.ActionRepairInverter:
+		err = r.target.RepairInverter()
+	case model.ActionLog:
+		log.Printf("scenario %q: %v\n", r.scenario.Name, step.Message)
+	}
+	return err == nil, err
+}
+
+func (r *Runner) params() *model.UpsParams {
+	params := r.target.GetAllParams()
+	return &params
+}
+
+func (r *Runner) nextStep() {
+	r.frames[len(r.frames)-1].index++
+	r.enterPhase(phaseIf)
+}
+
+func (r *Runner) enterPhase(phase int) {
+	r.phase = phase
+	r.phaseTime = r.clock.Now()
+}
+
+func (r *Runner) stepPath() string {
+	var indexes []string
+	for _, f := range r.frames {
+		indexes = append(indexes, strconv.Itoa(f.index+1))
+	}
+	return strings.Join(indexes, ".")
+}
+
+func (r *Runner) finish(status, err string) {
+	log.Printf("scenario %q: %v %v\n", r.scenario.Name, status, err)
+	r.status = status
+	r.err = err
+	r.stopTime = r.clock.Now()
+	r.target.SuspendCycle(false)
+}
@@ -0,0 +1,129 @@
+package scenario
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alex11prog/ups-imitator/internal/app/clock"
+	"github.com/alex11prog/ups-imitator/internal/app/imitator/ups"
+	"github.com/alex11prog/ups-imitator/internal/app/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestRunner(t *testing.T, data string) (*Runner, *ups.Ups, *clock.Fake) {
+	sc, err := model.ParseScenario([]byte(data))
+	assert.NoError(t, err)
+	clk := clock.NewFake(time.Now())
+	u := ups.NewWithClock(model.TestConfig(t), clk)
+	r := New(u, clk)
+	r.Upload(sc)
+	assert.NoError(t, r.Start())
+	return r, u, clk
+}
+
+func Test_Runner_timeline(t *testing.T) {
+	r, u, clk := newTestRunner(t, `
+name: outage
+steps:
+  - at: 5m
+    action: mains_off
+  - wait: 90s
+    action: mains_on
+`)
+	r.Tick()
+	assert.Equal(t, "1", r.GetProgress().Step)
+	assert.NotEqual(t, model.ModeOnBattery, u.GetAllParams().OperatingMode)
+
+	clk.Advance(5 * time.Minute)
+	r.Tick()
+	assert.Equal(t, model.ModeOnBattery, u.GetAllParams().OperatingMode)
+	assert.Equal(t, "2", r.GetProgress().Step)
+
+	clk.Advance(90 * time.Second)
+	r.Tick()
+	assert.Equal(t, model.ModeOnline, u.GetAllParams().OperatingMode)
+	progress := r.GetProgress()
+	assert.Equal(t, model.ScenarioFinished, progress.Status)
+	assert.Equal(t, "outage", progress.Name)
+	assert.GreaterOrEqual(t, progress.Elapsed, float32(390))
+}
+
+func Test_Runner_ramp(t *testing.T) {
+	r, u, clk := newTestRunner(t, `
+steps:
+  - action: set_load
+    power: 3000
+    duration: 1m
+`)
+	from := u.GetLoadPower()
+	r.Tick()
+	clk.Advance(30 * time.Second)
+	r.Tick()
+	assert.InDelta(t, (from+3000)/2, u.GetLoadPower(), 10)
+	assert.Equal(t, model.ScenarioRunning, r.GetProgress().Status)
+
+	clk.Advance(30 * time.Second)
+	r.Tick()
+	assert.Equal(t, float32(3000), u.GetLoadPower())
+	assert.Equal(t, model.ScenarioFinished, r.GetProgress().Status)
+}
+
+func Test_Runner_waitUntil(t *testing.T) {
+	r, u, clk := newTestRunner(t, `
+steps:
+  - action: set_battery
+    battery: 1
+    temp: 45
+  - wait_until: {param: battery_temp, index: 1, op: "<", value: 30}
+    timeout: 1m
+    action: mains_off
+`)
+	r.Tick()
+	assert.Equal(t, float32(45), u.GetAllParams().Batteries[1].Temp)
+	assert.Equal(t, "2", r.GetProgress().Step)
+
+	clk.Advance(time.Minute)
+	r.Tick()
+	progress := r.GetProgress()
+	assert.Equal(t, model.ScenarioFailed, progress.Status)
+	assert.Contains(t, progress.Error, "timed out")
+}
+
+func Test_Runner_loop(t *testing.T) {
+	r, u, clk := newTestRunner(t, `
+steps:
+  - repeat: 3
+    steps:
+      - action: log
+        message: iteration
+      - wait: 1m
+  - if: {param: soc, op: "<", value: 0}
+    action: mains_off
+`)
+	r.Tick()
+	progress := r.GetProgress()
+	assert.Equal(t, "1.2", progress.Step)
+	assert.Equal(t, 1, progress.Iteration)
+
+	clk.Advance(time.Minute)
+	r.Tick()
+	assert.Equal(t, 2, r.GetProgress().Iteration)
+
+	clk.Advance(time.Minute)
+	r.Tick()
+	clk.Advance(time.Minute)
+	r.Tick()
+	assert.Equal(t, model.ScenarioFinished, r.GetProgress().Status)
+	assert.Equal(t, model.ModeOnline, u.GetAllParams().OperatingMode, "skipped by if")
+}
+
+func Test_Runner_Stop(t *testing.T) {
+	r, _, _ := newTestRunner(t, `steps: [{wait: 1h}]`)
+	assert.Error(t, r.Start(), "already running")
+	assert.NoError(t, r.Stop())
+	assert.Equal(t, model.ScenarioStopped, r.GetProgress().Status)
+	assert.Error(t, r.Stop())
+	assert.NoError(t, r.Start(), "restart")
+	r.Tick()
+	assert.True(t, r.IsRunning())
+}
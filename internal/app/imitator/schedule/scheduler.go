@@ -0,0 +1,158 @@
+// Package schedule runs actions on cron schedules
+package schedule
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/alex11prog/ups-imitator/internal/app/clock"
+	"github.com/alex11prog/ups-imitator/internal/app/model"
+)
+
+// Target is the imitator driven by the scheduled actions
+type Target interface {
+	SetMains(on bool)
+	SetLoadPower(power float32)
+	SetLoadProfile(profile string) error
+	SetMode(auto bool)
+	StartBatteryTest() error
+}
+
+type job struct {
+	model.ScheduledJob
+	cron    *model.CronSchedule
+	next    time.Time // zero if the schedule never fires
+	lastRun time.Time
+	lastErr string
+}
+
+type Scheduler struct {
+	target Target
+	clock  clock.Clock
+
+	mu   sync.Mutex
+	jobs []*job // in the order of the config
+}
+
+func New(target Target, clk clock.Clock) *Scheduler {
+	return &Scheduler{target: target, clock: clk}
+}
+
+// Set replaces the jobs, they are scheduled from now on
+func (s *Scheduler) Set(jobs model.ScheduledJobs) error {
+	if err := jobs.Validate(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs = nil
+	for _, j := range jobs {
+		s.jobs = append(s.jobs, s.newJob(j))
+	}
+	return nil
+}
+
+// Put adds the job or replaces the one with the same name
+func (s *Scheduler) Put(j model.ScheduledJob) error {
+	if err := j.Validate(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if i := s.index(j.Name); i >= 0 {
+		s.jobs[i] = s.newJob(j)
+	} else {
+		s.jobs = append(s.jobs, s.newJob(j))
+	}
+	return nil
+}
+
+func (s *Scheduler) Delete(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.index(name)
+	if i < 0 {
+		return fmt.Errorf("job not found: %q", name)
+	}
+	s.jobs = slices.Delete(s.jobs, i, i+1)
+	return nil
+}
+
+// List returns the jobs with their runs
+func (s *Scheduler) List() []model.ScheduledJobStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	res := []model.ScheduledJobStatus{}
+	for _, j := range s.jobs {
+		status := model.ScheduledJobStatus{ScheduledJob: j.ScheduledJob, LastError: j.lastErr}
+		if !j.next.IsZero() {
+			next := j.next
+			status.NextRun = &next
+		}
+		if !j.lastRun.IsZero() {
+			lastRun := j.lastRun
+			status.LastRun = &lastRun
+		}
+		res = append(res, status)
+	}
+	return res
+}
+
+// Tick runs the due jobs, the runs missed since the previous tick are run once
+func (s *Scheduler) Tick() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.clock.Now()
+	for _, j := range s.jobs {
+		if j.next.IsZero() || now.Before(j.next) {
+			continue
+		}
+		log.Printf("schedule %q: %v\n", j.Name, j.Action)
+		j.lastRun = now
+		j.lastErr = ""
+		if err := s.run(j.ScheduledJob); err != nil {
+			log.Printf("schedule %q: %v\n", j.Name, err)
+			j.lastErr = err.Error()
+		}
+		j.schedule(now)
+	}
+}
+
+func (s *Scheduler) run(j model.ScheduledJob) error {
+	switch j.Action {
+	case model.ActionMainsOff, model.ActionMainsOn:
+		s.target.SetMains(j.Action == model.ActionMainsOn)
+	case model.ActionSetLoad:
+		s.target.SetLoadPower(j.Power)
+	case model.ActionLoadProfile:
+		return s.target.SetLoadProfile(j.Profile)
+	case model.ActionSetMode:
+		s.target.SetMode(j.Mode == model.ModeAuto)
+	case model.ActionBatteryTest:
+		return s.target.StartBatteryTest()
+	}
+	return nil
+}
+
+// newJob parses the schedule of the validated job
+func (s *Scheduler) newJob(sj model.ScheduledJob) *job {
+	cron, _ := model.ParseCron(sj.Cron)
+	j := &job{ScheduledJob: sj, cron: cron}
+	j.schedule(s.clock.Now())
+	return j
+}
+
+func (j *job) schedule(after time.Time) {
+	next, err := j.cron.Next(after)
+	if err != nil {
+		j.lastErr = err.Error()
+	}
+	j.next = next
+}
+
+func (s *Scheduler) index(name string) int {
+	return slices.IndexFunc(s.jobs, func(j *job) bool { return j.Name == name })
+}
@@ -0,0 +1,131 @@
+package schedule
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alex11prog/ups-imitator/internal/app/clock"
+	"github.com/alex11prog/ups-imitator/internal/app/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// target records the actions
+type target struct {
+	mainsOn bool
+	power   float32
+	profile string
+	auto    bool
+	tests   int
+	runs    int
+}
+
+func (t *target) SetMains(on bool) {
+	t.mainsOn = on
+	t.runs++
+}
+
+func (t *target) SetLoadPower(power float32) {
+	t.power = power
+	t.runs++
+}
+
+func (t *target) SetLoadProfile(profile string) error {
+	if profile == "invalid" {
+		return errors.New("unknown profile")
+	}
+	t.profile = profile
+	t.runs++
+	return nil
+}
+
+func (t *target) SetMode(auto bool) {
+	t.auto = auto
+	t.runs++
+}
+
+func (t *target) StartBatteryTest() error {
+	t.tests++
+	t.runs++
+	if t.tests > 1 {
+		return errors.New("battery test is already running")
+	}
+	return nil
+}
+
+func Test_Scheduler_Tick(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 7, 5, 23, 0, 0, 0, time.UTC)) // Friday
+	tgt := &target{mainsOn: true}
+	s := New(tgt, clk)
+	require.NoError(t, s.Set(model.ScheduledJobs{
+		{Name: "off", Cron: "0 2 * * sat", Action: model.ActionMainsOff},
+		{Name: "on", Cron: "30 2 * * sat", Action: model.ActionMainsOn},
+		{Name: "load", Cron: "0 * * * *", Action: model.ActionSetLoad, Power: 1500},
+	}))
+	jobs := s.List()
+	require.Len(t, jobs, 3)
+	assert.Equal(t, time.Date(2024, 7, 6, 2, 0, 0, 0, time.UTC), *jobs[0].NextRun)
+	assert.Nil(t, jobs[0].LastRun)
+
+	clk.Advance(time.Hour)
+	s.Tick()
+	assert.True(t, tgt.mainsOn)
+	assert.Equal(t, float32(1500), tgt.power)
+	assert.Equal(t, 1, tgt.runs)
+
+	clk.Advance(2*time.Hour + 10*time.Minute) // the missed hourly run is run once
+	s.Tick()
+	assert.False(t, tgt.mainsOn)
+	assert.Equal(t, 3, tgt.runs)
+
+	clk.Advance(30 * time.Minute)
+	s.Tick()
+	assert.True(t, tgt.mainsOn)
+	jobs = s.List()
+	assert.Equal(t, clk.Now(), *jobs[1].LastRun)
+	assert.Equal(t, time.Date(2024, 7, 13, 2, 30, 0, 0, time.UTC), *jobs[1].NextRun)
+}
+
+func Test_Scheduler_edit(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC))
+	tgt := &target{}
+	s := New(tgt, clk)
+	assert.Empty(t, s.List())
+	assert.Error(t, s.Put(model.ScheduledJob{Name: "mode", Cron: "invalid", Action: model.ActionSetMode, Mode: model.ModeAuto}))
+
+	require.NoError(t, s.Put(model.ScheduledJob{Name: "mode", Cron: "0 8 * * *", Action: model.ActionSetMode, Mode: model.ModeManual}))
+	require.NoError(t, s.Put(model.ScheduledJob{Name: "mode", Cron: "0 9 * * *", Action: model.ActionSetMode, Mode: model.ModeAuto}))
+	require.NoError(t, s.Put(model.ScheduledJob{Name: "profile", Cron: "0 9 * * *", Action: model.ActionLoadProfile, Profile: "invalid"}))
+	jobs := s.List()
+	require.Len(t, jobs, 2, "replaced by name")
+	assert.Equal(t, "0 9 * * *", jobs[0].Cron)
+
+	clk.Advance(9 * time.Hour)
+	s.Tick()
+	assert.True(t, tgt.auto)
+	assert.Equal(t, "unknown profile", s.List()[1].LastError)
+
+	require.NoError(t, s.Delete("mode"))
+	assert.Error(t, s.Delete("mode"))
+	assert.Len(t, s.List(), 1)
+	assert.Error(t, s.Set(model.ScheduledJobs{{Name: "off", Cron: "@daily"}}), "no action")
+	assert.Len(t, s.List(), 1, "kept on error")
+}
+
+func Test_Scheduler_batteryTest(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC))
+	tgt := &target{}
+	s := New(tgt, clk)
+	require.NoError(t, s.Put(model.ScheduledJob{Name: "test", Cron: "@hourly", Action: model.ActionBatteryTest}))
+
+	clk.Advance(time.Hour)
+	s.Tick()
+	assert.Equal(t, 1, tgt.tests)
+	assert.Empty(t, s.List()[0].LastError)
+
+	clk.Advance(time.Hour)
+	s.Tick()
+	assert.Equal(t, 2, tgt.tests)
+	assert.Equal(t, "battery test is already running", s.List()[0].LastError)
+}
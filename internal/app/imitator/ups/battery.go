@@ -0,0 +1,76 @@
+package ups
+
+import (
+	"math"
+	"time"
+)
+
+// The battery group is modeled as the open circuit voltage source,
+// the internal (ohmic) resistance of the blocks and an optional RC polarization element:
+//
+//	V = OCV + I·R0 + Vp, dVp/dt = (I·R1 - Vp) / τ
+//
+// where I is positive while charging and negative while discharging
+
+const (
+	agingRefTemp      = 25      // °C, the self-discharge rate and the float current are given for this temperature
+	agingDoublingTemp = 10      // °C, the self-discharge and the float current double every 10 °C
+	hoursPerMonth     = 30 * 24 // h
+	overchargeVoltage = 0.05    // V per cell, the overcharge current doubles every 50 mV above the float voltage
+)
+
+// defaultBatResist is the internal resistance of a new battery block (mOhm)
+const defaultBatResist float32 = 5
+
+// internalResist returns the ohmic resistance of the battery group (Ohm)
+func (u *Ups) internalResist() float32 {
+	var sum float32
+	for _, bat := range u.params.Batteries {
+		sum += bat.Resist
+	}
+	return sum / 1000 // mOhm -> Ohm
+}
+
+// restVoltage returns the battery group voltage without the ohmic drop
+func (u *Ups) restVoltage() float32 {
+	return u.openCircuitVoltage() + u.polarizationVoltage
+}
+
+// terminalVoltage returns the battery group voltage under the current BatGroupCurrent
+func (u *Ups) terminalVoltage() float32 {
+	return u.restVoltage() + u.params.BatGroupCurrent*u.internalResist()
+}
+
+// recalcPolarization integrates the voltage of the RC polarization element
+// over the elapsed time, during which the battery current was BatGroupCurrent
+func (u *Ups) recalcPolarization(elapsed time.Duration) {
+	tau := u.conf.Battery.RcTimeConstant
+	if tau <= 0 {
+		u.polarizationVoltage = 0
+		return
+	}
+	target := u.params.BatGroupCurrent * u.conf.Battery.RcResist
+	decay := float32(math.Exp(-float64(elapsed) / float64(tau)))
+	u.polarizationVoltage = target + (u.polarizationVoltage-target)*decay
+}
+
+// agingTempFactor returns the acceleration of the self-discharge and the float current relative to 25 °C
+func (u *Ups) agingTempFactor() float32 {
+	return float32(math.Exp2(float64(u.avgBatTemp()-agingRefTemp) / agingDoublingTemp))
+}
+
+// selfDischargeCurrent returns the internal self-discharge current of the battery group (A),
+// it reduces the remaining capacity but is not seen at the terminals
+func (u *Ups) selfDischargeCurrent() float32 {
+	return u.params.BatCapacity * u.conf.Battery.MonthlySelfDischargeRate() / hoursPerMonth * u.agingTempFactor()
+}
+
+// overchargeCurrent returns the current drawn by the charged battery held at the voltage set-point (A):
+// the float current at the float voltage, doubling every 50 mV per cell above it.
+// A shorted cell raises the voltage of the remaining cells
+func (u *Ups) overchargeCurrent(setPoint float32) float32 {
+	nominalCells := float32(u.conf.Battery.CellsPerBlock * len(u.params.Batteries))
+	overvoltage := setPoint/float32(u.numOfCells()) - u.conf.Charger.FloatVoltage/nominalCells
+	floatCurrent := u.params.BatCapacity * u.conf.Battery.FloatCurrentPerAh() / 1000 // mA -> A
+	return floatCurrent * u.agingTempFactor() * float32(math.Exp2(float64(overvoltage/overchargeVoltage)))
+}
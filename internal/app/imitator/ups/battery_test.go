@@ -0,0 +1,103 @@
+package ups
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alex11prog/ups-imitator/internal/app/model"
+	"github.com/alex11prog/ups-imitator/internal/app/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_terminalVoltage(t *testing.T) {
+	conf := model.TestConfig(t)
+	ups := New(conf)
+	ocv := ups.openCircuitVoltage()
+
+	ups.params.BatGroupCurrent = -20
+	sag := ocv - ups.terminalVoltage()
+	assert.InDelta(t, 20*ups.internalResist(), sag, 0.0001)
+
+	ups.params.Batteries[0].Resist *= 2
+	assert.Greater(t, ocv-ups.terminalVoltage(), sag, "higher resistance, deeper sag")
+}
+
+func Test_recalcPolarization(t *testing.T) {
+	conf := model.TestConfig(t)
+	ups := New(conf)
+	tau := conf.Battery.RcTimeConstant
+
+	ups.params.BatGroupCurrent = -20
+	ups.recalcPolarization(tau)
+	first := ups.polarizationVoltage
+	assert.Less(t, first, float32(0))
+	ups.recalcPolarization(tau * 10)
+	assert.InDelta(t, -20*conf.Battery.RcResist, ups.polarizationVoltage, 0.001, "steady state")
+
+	ups.params.BatGroupCurrent = 0
+	ups.recalcPolarization(tau)
+	assert.Less(t, ups.polarizationVoltage, float32(0))
+	assert.Greater(t, ups.polarizationVoltage, -20*conf.Battery.RcResist, "relaxation")
+
+	conf.Battery.RcTimeConstant = 0
+	ups.recalcPolarization(time.Second)
+	assert.Equal(t, float32(0), ups.polarizationVoltage)
+}
+
+func Test_recalcBatValtages(t *testing.T) {
+	conf := model.TestConfig(t)
+	ups := New(conf)
+	ups.params.Batteries[2].Resist = 10
+	ups.params.BatGroupCurrent = -20
+	ups.recalcBatGroupVoltage()
+	ups.recalcBatValtages()
+
+	var sum float32
+	for _, bat := range ups.params.Batteries {
+		sum += bat.Voltage
+	}
+	assert.InDelta(t, ups.params.BatGroupVoltage, sum, 0.001)
+	assert.Less(t, ups.params.Batteries[2].Voltage, ups.params.Batteries[0].Voltage)
+}
+
+func Test_selfDischargeCurrent(t *testing.T) {
+	conf := model.TestConfig(t)
+	ups := New(conf)
+	for i := range ups.params.Batteries {
+		ups.params.Batteries[i].Temp = agingRefTemp
+	}
+	monthly := ups.selfDischargeCurrent() * hoursPerMonth
+	assert.InDelta(t, conf.DefaultBatCapacity*conf.Battery.MonthlySelfDischargeRate(), monthly, 0.001)
+
+	for i := range ups.params.Batteries {
+		ups.params.Batteries[i].Temp = agingRefTemp + agingDoublingTemp
+	}
+	assert.InDelta(t, 2*monthly, ups.selfDischargeCurrent()*hoursPerMonth, 0.001, "doubles every 10 °C")
+}
+
+func Test_overchargeCurrent(t *testing.T) {
+	conf := model.TestConfig(t)
+	ups := New(conf)
+	for i := range ups.params.Batteries {
+		ups.params.Batteries[i].Temp = agingRefTemp
+	}
+	float := ups.overchargeCurrent(conf.Charger.FloatVoltage)
+	assert.InDelta(t, conf.DefaultBatCapacity*conf.Battery.FloatCurrentPerAh()/1000, float, 0.0001)
+	cells := float32(conf.Battery.CellsPerBlock * len(ups.params.Batteries))
+	assert.InDelta(t, 2*float, ups.overchargeCurrent(conf.Charger.FloatVoltage+overchargeVoltage*cells), 0.0001)
+}
+
+func Test_integrateBatCapacity_selfDischarge(t *testing.T) {
+	conf := model.TestConfig(t)
+	conf.Charger.Disabled = true
+	ups := New(conf)
+	ups.UpdateParams(model.UpsParamsUpdateForm{InputAcVoltage: utils.NewP(float32(0))})
+	ups.UpdateParams(model.UpsParamsUpdateForm{InputAcVoltage: utils.NewP(conf.DefaultInputAcVoltage)})
+	assert.Equal(t, model.ChargerOff, ups.params.ChargerStage, "the charger is disabled")
+
+	ups.recalcPowerFlow()
+	assert.Zero(t, ups.params.BatGroupCurrent)
+	ups.integrateBatCapacity(hoursPerMonth * time.Hour)
+	assert.Less(t, ups.params.SOC, float32(1))
+	assert.Greater(t, ups.params.SOC, 1-2*conf.Battery.MonthlySelfDischargeRate())
+}
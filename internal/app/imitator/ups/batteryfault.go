@@ -0,0 +1,159 @@
+package ups
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/alex11prog/ups-imitator/internal/app/model"
+)
+
+const maxRunawayHeat = 100 // °C, the thermal runaway stops heating above the block temperature
+
+// batteryFaultState is the evolution of the faults injected into a battery,
+// the increments are applied to the params of the battery and removed when the fault is cleared
+type batteryFaultState struct {
+	shortHeat   float32 // °C, heat of the shorted cell
+	runawayHeat float32 // °C, heat of the thermal runaway
+	addedResist float32 // mOhm, growth of the internal resistance
+}
+
+// blockCells returns the number of working cells of the battery
+func (u *Ups) blockCells(i int) int {
+	if u.params.Batteries[i].Faults.Has(model.FaultShortedCell) {
+		return u.conf.Battery.CellsPerBlock - 1
+	}
+	return u.conf.Battery.CellsPerBlock
+}
+
+// numOfCells returns the number of working cells of the battery group
+func (u *Ups) numOfCells() int {
+	var cells int
+	for i := range u.params.Batteries {
+		cells += u.blockCells(i)
+	}
+	return cells
+}
+
+// isStringOpen reports whether the battery group is interrupted by an open cell
+func (u *Ups) isStringOpen() bool {
+	for _, bat := range u.params.Batteries {
+		if bat.Faults.Has(model.FaultOpenCell) {
+			return true
+		}
+	}
+	return false
+}
+
+// applyOpenString: no current flows through the open string, the output of the charger rises to its set-point
+// and without the charger the DC bus collapses
+func (u *Ups) applyOpenString() {
+	conf := &u.conf.Charger
+	u.params.BatGroupCurrent = 0
+	switch u.params.ChargerStage {
+	case model.ChargerOff:
+		u.params.BatGroupVoltage = 0
+	case model.ChargerFloat:
+		u.params.BatGroupVoltage = u.compensatedSetPoint(conf.FloatVoltage)
+	case model.ChargerEqualize:
+		u.params.BatGroupVoltage = u.compensatedSetPoint(conf.EqualizeVoltage)
+	default:
+		u.params.BatGroupVoltage = u.compensatedSetPoint(conf.AbsorptionVoltage)
+	}
+}
+
+// recalcBatteryFaults evolves the faults over the elapsed time: the shorted cell heats the block up to
+// ShortedCellHeat with HeatTimeConstant, the thermal runaway heats it at a rate doubling every 10 °C,
+// the high resistance grows linearly
+func (u *Ups) recalcBatteryFaults(elapsed time.Duration) {
+	conf := &u.conf.BatteryFaults
+	for i := range u.params.Batteries {
+		bat := &u.params.Batteries[i]
+		state := &u.batFaults[i]
+		old := *state
+
+		var shortTarget float32
+		if bat.Faults.Has(model.FaultShortedCell) {
+			shortTarget = conf.ShortedCellHeat
+		}
+		decay := float32(math.Exp(-float64(elapsed) / float64(conf.HeatTimeConstant)))
+		state.shortHeat = shortTarget + (state.shortHeat-shortTarget)*decay
+
+		if bat.Faults.Has(model.FaultThermalRunaway) {
+			state.runawayHeat = runawayHeat(state.runawayHeat, conf.ThermalRunawayRate, elapsed)
+		}
+		if bat.Faults.Has(model.FaultHighResistance) {
+			state.addedResist += conf.ResistanceGrowth * float32(elapsed.Hours())
+		}
+
+		bat.Temp += state.shortHeat + state.runawayHeat - old.shortHeat - old.runawayHeat
+		bat.Resist += state.addedResist - old.addedResist
+	}
+}
+
+// runawayHeat integrates dH/dt = rate·2^(H/10) over the elapsed time, limited by maxRunawayHeat
+func runawayHeat(heat, rate float32, elapsed time.Duration) float32 {
+	k := math.Ln2 / agingDoublingTemp
+	x := math.Exp(-k*float64(heat)) - k*float64(rate)*elapsed.Minutes()
+	if x <= math.Exp(-k*maxRunawayHeat) {
+		return maxRunawayHeat
+	}
+	return float32(-math.Log(x) / k)
+}
+
+// clearBatteryFault removes the fault and the increments it has made to the params of the battery
+func (u *Ups) clearBatteryFault(i int, fault model.BatteryFaults) {
+	bat := &u.params.Batteries[i]
+	state := &u.batFaults[i]
+	bat.Faults &^= fault
+	switch fault {
+	case model.FaultShortedCell:
+		bat.Temp -= state.shortHeat
+		state.shortHeat = 0
+	case model.FaultThermalRunaway:
+		bat.Temp -= state.runawayHeat
+		state.runawayHeat = 0
+	case model.FaultHighResistance:
+		bat.Resist = max(bat.Resist-state.addedResist, 0)
+		state.addedResist = 0
+	}
+}
+
+func (u *Ups) checkBatteryFault(bat_id int, name string) (model.BatteryFaults, error) {
+	if l := len(u.params.Batteries); bat_id < 0 || bat_id >= l {
+		return 0, fmt.Errorf("bat_id out of range: %d, expected less %d", bat_id, l)
+	}
+	return model.ParseBatteryFault(name)
+}
+
+// AddBatteryFault injects the fault into the battery, it evolves while the UPS is running
+func (u *Ups) AddBatteryFault(bat_id int, name string) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	fault, err := u.checkBatteryFault(bat_id, name)
+	if err != nil {
+		return err
+	}
+	if u.params.Batteries[bat_id].Faults.Has(fault) {
+		return fmt.Errorf("battery %d already has fault %q", bat_id, name)
+	}
+	u.params.Batteries[bat_id].Faults |= fault
+	u.applyInputChange()
+	return nil
+}
+
+// ClearBatteryFault clears the fault of the battery
+func (u *Ups) ClearBatteryFault(bat_id int, name string) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	fault, err := u.checkBatteryFault(bat_id, name)
+	if err != nil {
+		return err
+	}
+	if !u.params.Batteries[bat_id].Faults.Has(fault) {
+		return fmt.Errorf("battery %d has no fault %q", bat_id, name)
+	}
+	u.clearBatteryFault(bat_id, fault)
+	u.applyInputChange()
+	return nil
+}
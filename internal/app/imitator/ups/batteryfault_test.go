@@ -0,0 +1,105 @@
+package ups
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alex11prog/ups-imitator/internal/app/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_BatteryFault_shortedCell(t *testing.T) {
+	conf := model.TestConfig(t)
+	ups := New(conf)
+	voltage := ups.params.BatGroupVoltage
+	floatCurrent := ups.params.BatGroupCurrent
+
+	require.NoError(t, ups.AddBatteryFault(1, "shorted_cell"))
+	assert.Greater(t, ups.params.BatGroupCurrent, floatCurrent, "the remaining cells are overcharged")
+	ups.setMains(false)
+	ups.applyInputChange()
+	cellVoltage := voltage / float32(conf.Battery.CellsPerBlock*len(ups.params.Batteries))
+	assert.Less(t, ups.params.BatGroupVoltage, voltage-cellVoltage/2, "the block loses a cell")
+	assert.Less(t, ups.params.Batteries[1].Voltage, ups.params.Batteries[0].Voltage)
+
+	temp := ups.params.Batteries[1].Temp
+	ups.recalcBatteryFaults(conf.BatteryFaults.HeatTimeConstant * 10)
+	assert.InDelta(t, temp+conf.BatteryFaults.ShortedCellHeat, ups.params.Batteries[1].Temp, 0.01)
+	assert.Equal(t, temp, ups.params.Batteries[0].Temp)
+
+	require.NoError(t, ups.ClearBatteryFault(1, "shorted_cell"))
+	assert.InDelta(t, temp, ups.params.Batteries[1].Temp, 0.01)
+	assert.Error(t, ups.ClearBatteryFault(1, "shorted_cell"))
+}
+
+func Test_BatteryFault_openCell(t *testing.T) {
+	conf := model.TestConfig(t)
+	ups := New(conf)
+
+	require.NoError(t, ups.AddBatteryFault(2, "open_cell"))
+	assert.Equal(t, model.ModeOnline, ups.params.OperatingMode)
+	assert.Zero(t, ups.params.BatGroupCurrent)
+	assert.Zero(t, ups.params.Runtime)
+
+	ups.setMains(false)
+	ups.applyInputChange()
+	assert.Equal(t, model.ModeShutdown, ups.params.OperatingMode, "the string collapses under load")
+	assert.Zero(t, ups.params.OutputAcVoltage)
+
+	require.NoError(t, ups.ClearBatteryFault(2, "open_cell"))
+	assert.Equal(t, model.ModeShutdown, ups.params.OperatingMode)
+	ups.setMains(true)
+	ups.applyInputChange()
+	assert.Equal(t, model.ModeOnline, ups.params.OperatingMode)
+}
+
+func Test_BatteryFault_openCell_onBattery(t *testing.T) {
+	conf := model.TestConfig(t)
+	ups := New(conf)
+	ups.setMains(false)
+	ups.applyInputChange()
+	require.Equal(t, model.ModeOnBattery, ups.params.OperatingMode)
+
+	require.NoError(t, ups.AddBatteryFault(0, "open_cell"))
+	assert.Equal(t, model.ModeShutdown, ups.params.OperatingMode)
+}
+
+func Test_BatteryFault_highResistance(t *testing.T) {
+	conf := model.TestConfig(t)
+	ups := New(conf)
+	resist := ups.params.Batteries[3].Resist
+
+	require.NoError(t, ups.AddBatteryFault(3, "high_resistance"))
+	ups.recalcBatteryFaults(2 * time.Hour)
+	assert.InDelta(t, resist+2*conf.BatteryFaults.ResistanceGrowth, ups.params.Batteries[3].Resist, 0.001)
+
+	require.NoError(t, ups.ClearBatteryFault(3, "high_resistance"))
+	assert.InDelta(t, resist, ups.params.Batteries[3].Resist, 0.001)
+}
+
+func Test_BatteryFault_thermalRunaway(t *testing.T) {
+	conf := model.TestConfig(t)
+	ups := New(conf)
+	temp := ups.params.Batteries[0].Temp
+
+	require.NoError(t, ups.AddBatteryFault(0, "thermal_runaway"))
+	ups.recalcBatteryFaults(time.Minute)
+	first := ups.params.Batteries[0].Temp - temp
+	assert.InDelta(t, conf.BatteryFaults.ThermalRunawayRate, first, 0.01)
+	ups.recalcBatteryFaults(time.Minute)
+	assert.Greater(t, ups.params.Batteries[0].Temp-temp, 2*first, "the rate increases with temperature")
+
+	ups.recalcBatteryFaults(24 * time.Hour)
+	assert.Equal(t, temp+maxRunawayHeat, ups.params.Batteries[0].Temp)
+}
+
+func Test_AddBatteryFault_invalid(t *testing.T) {
+	conf := model.TestConfig(t)
+	ups := New(conf)
+	assert.Error(t, ups.AddBatteryFault(-1, "open_cell"))
+	assert.Error(t, ups.AddBatteryFault(4, "open_cell"))
+	assert.Error(t, ups.AddBatteryFault(0, "invalid"))
+	require.NoError(t, ups.AddBatteryFault(0, "open_cell"))
+	assert.Error(t, ups.AddBatteryFault(0, "open_cell"))
+}
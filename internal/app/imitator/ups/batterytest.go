@@ -0,0 +1,124 @@
+package ups
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/alex11prog/ups-imitator/internal/app/model"
+)
+
+// minTestCurrentStep is the minimal step of the battery current at the transfer to measure the resistance (A)
+const minTestCurrentStep = 1
+
+type batteryTestState struct {
+	running   bool
+	startTime time.Time
+	voltage   float32 // V, at rest of the battery group before the transfer
+	resist    float32 // Ohm, of the battery group, measured at the transfer
+}
+
+// StartBatteryTest transfers the load to battery for the test, see model.BatteryTestConfig.
+// The resistance is measured by the step of the voltage and the current at the transfer,
+// the result is evaluated by recalcBatteryTest. The test with low SOC is aborted at once
+func (u *Ups) StartBatteryTest() error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.batTest.running {
+		return errors.New("battery test is already running")
+	}
+	if mode := u.params.OperatingMode; mode != model.ModeOnline && mode != model.ModeEco {
+		return fmt.Errorf("battery test is not possible in the operating mode: %v", mode)
+	}
+	now := u.clock.Now()
+	u.params.BatteryTest = model.BatteryTestParams{Result: model.BatteryTestRunning, Time: &now}
+	log.Println("battery test: started")
+	if u.params.SOC < u.conf.BatteryTest.MinSoc {
+		u.finishBatteryTest(model.BatteryTestAborted, "low soc")
+		return nil
+	}
+
+	// the charger is stopped first, so the step is measured from the rest voltage
+	u.stopCharging()
+	u.recalcPowerFlow()
+	voltage, current := u.params.BatGroupVoltage, u.params.BatGroupCurrent
+	u.transferToBattery()
+	u.recalcPowerFlow()
+	if u.params.OperatingMode != model.ModeOnBattery {
+		u.finishBatteryTest(model.BatteryTestFailed, "the battery cannot carry the load")
+		return nil
+	}
+	step := current - u.params.BatGroupCurrent
+	if step < minTestCurrentStep {
+		u.finishBatteryTest(model.BatteryTestAborted, "the load is too low")
+		u.transferToInput()
+		u.recalcPowerFlow()
+		return nil
+	}
+	u.batTest = batteryTestState{
+		running:   true,
+		startTime: now,
+		voltage:   voltage,
+		resist:    (voltage - u.params.BatGroupVoltage) / step,
+	}
+	return nil
+}
+
+// recalcBatteryTest aborts the running test or evaluates it after the duration and returns the load to the input.
+// On an input fault the load stays on battery
+func (u *Ups) recalcBatteryTest() {
+	if !u.batTest.running {
+		return
+	}
+	switch {
+	case u.params.OperatingMode == model.ModeShutdown:
+		u.finishBatteryTest(model.BatteryTestFailed, "the battery cannot carry the load")
+	case u.params.OperatingMode != model.ModeOnBattery:
+		u.finishBatteryTest(model.BatteryTestAborted, "the load left the battery")
+	case u.params.Alarms.InputFault:
+		u.finishBatteryTest(model.BatteryTestAborted, "input fault")
+	case u.params.SOC < u.conf.BatteryTest.MinSoc:
+		u.finishBatteryTest(model.BatteryTestAborted, "low soc")
+		u.transferToInput()
+	case u.clock.Since(u.batTest.startTime) >= u.conf.BatteryTest.Duration:
+		u.evaluateBatteryTest()
+		u.transferToInput()
+	}
+}
+
+// evaluateBatteryTest estimates SOH by the measured resistance growing linearly to EolResist times the rated one,
+// limited by the capacity relative to the default one
+func (u *Ups) evaluateBatteryTest() {
+	conf := &u.conf.BatteryTest
+	rated := defaultBatResist * float32(len(u.params.Batteries)) / 1000
+	soh := 1 - (u.batTest.resist/rated-1)/(conf.EolResist-1)
+	soh = min(max(soh, 0), 1, u.params.BatCapacity/u.conf.DefaultBatCapacity)
+	drop := u.batTest.voltage - u.params.BatGroupVoltage
+
+	test := &u.params.BatteryTest
+	test.VoltageDrop = drop
+	test.Resist = u.batTest.resist * 1000
+	test.SOH = soh
+	switch {
+	case drop > conf.MaxVoltageDrop*u.batTest.voltage:
+		u.finishBatteryTest(model.BatteryTestFailed, "voltage drop")
+	case soh < conf.FailSoh:
+		u.finishBatteryTest(model.BatteryTestFailed, "low soh")
+	case soh < conf.WarningSoh:
+		u.finishBatteryTest(model.BatteryTestWarning, "")
+	default:
+		u.finishBatteryTest(model.BatteryTestPassed, "")
+	}
+}
+
+func (u *Ups) finishBatteryTest(result model.BatteryTestResult, reason string) {
+	u.batTest.running = false
+	u.params.BatteryTest.Result = result
+	u.params.BatteryTest.Reason = reason
+	if reason != "" {
+		log.Printf("battery test: %v, %s\n", result, reason)
+	} else {
+		log.Printf("battery test: %v\n", result)
+	}
+}
@@ -0,0 +1,147 @@
+package ups
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alex11prog/ups-imitator/internal/app/clock"
+	"github.com/alex11prog/ups-imitator/internal/app/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_StartBatteryTest(t *testing.T) {
+	testCases := []struct {
+		name   string
+		prep   func(u *Ups)
+		result model.BatteryTestResult
+		reason string
+		minSoh float32
+		maxSoh float32
+	}{
+		{
+			name:   "passed",
+			prep:   func(u *Ups) {},
+			result: model.BatteryTestPassed,
+			minSoh: 0.9,
+			maxSoh: 1,
+		},
+		{
+			name: "warning, aged",
+			prep: func(u *Ups) {
+				for i := range u.params.Batteries {
+					u.params.Batteries[i].Resist = 6.5
+				}
+			},
+			result: model.BatteryTestWarning,
+			minSoh: 0.5,
+			maxSoh: 0.8,
+		},
+		{
+			name: "failed, low soh",
+			prep: func(u *Ups) {
+				for i := range u.params.Batteries {
+					u.params.Batteries[i].Resist = 9
+				}
+			},
+			result: model.BatteryTestFailed,
+			reason: "low soh",
+			maxSoh: 0.5,
+		},
+		{
+			name: "failed, voltage drop",
+			prep: func(u *Ups) {
+				u.conf.BatteryTest.MaxVoltageDrop = 0.001
+			},
+			result: model.BatteryTestFailed,
+			reason: "voltage drop",
+			minSoh: 0.9,
+			maxSoh: 1,
+		},
+		{
+			name: "warning, lost capacity",
+			prep: func(u *Ups) {
+				u.params.BatCapacity = u.conf.DefaultBatCapacity * 0.7
+				u.params.RemainingBatCapacity = u.params.BatCapacity
+			},
+			result: model.BatteryTestWarning,
+			minSoh: 0.7,
+			maxSoh: 0.7,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			conf := model.TestConfig(t)
+			clk := clock.NewFake(time.Now())
+			ups := NewWithClock(conf, clk)
+			tc.prep(ups)
+			ups.applyInputChange()
+			start := clk.Now()
+
+			require.NoError(t, ups.StartBatteryTest())
+			assert.Equal(t, model.ModeOnBattery, ups.params.OperatingMode)
+			assert.Equal(t, model.BatteryTestRunning, ups.params.BatteryTest.Result)
+			assert.Error(t, ups.StartBatteryTest(), "already running")
+
+			clk.Advance(conf.BatteryTest.Duration / 2)
+			ups.RecalculateParams()
+			assert.Equal(t, model.ModeOnBattery, ups.params.OperatingMode, "the input is acceptable, but the test is running")
+
+			clk.Advance(conf.BatteryTest.Duration / 2)
+			ups.RecalculateParams()
+			test := ups.GetAllParams().BatteryTest
+			assert.Equal(t, tc.result, test.Result)
+			assert.Equal(t, tc.reason, test.Reason)
+			assert.Equal(t, start, *test.Time)
+			assert.InDelta(t, ups.internalResist()*1000, test.Resist, 1)
+			assert.Greater(t, test.VoltageDrop, float32(0))
+			assert.GreaterOrEqual(t, test.SOH, tc.minSoh)
+			assert.LessOrEqual(t, test.SOH, tc.maxSoh)
+			assert.Equal(t, model.ModeOnline, ups.params.OperatingMode)
+			assert.NotEqual(t, model.ChargerOff, ups.params.ChargerStage)
+		})
+	}
+}
+
+func Test_StartBatteryTest_aborted(t *testing.T) {
+	conf := model.TestConfig(t)
+	clk := clock.NewFake(time.Now())
+	ups := NewWithClock(conf, clk)
+
+	ups.params.RemainingBatCapacity = ups.params.BatCapacity * 0.3
+	ups.recalcSoc()
+	require.NoError(t, ups.StartBatteryTest())
+	assert.Equal(t, model.BatteryTestAborted, ups.params.BatteryTest.Result)
+	assert.Equal(t, "low soc", ups.params.BatteryTest.Reason)
+	assert.Equal(t, model.ModeOnline, ups.params.OperatingMode, "not transferred")
+
+	ups.params.RemainingBatCapacity = ups.params.BatCapacity
+	ups.recalcSoc()
+	require.NoError(t, ups.StartBatteryTest())
+	ups.SetMains(false)
+	clk.Advance(time.Second)
+	ups.RecalculateParams()
+	assert.Equal(t, model.BatteryTestAborted, ups.params.BatteryTest.Result)
+	assert.Equal(t, "input fault", ups.params.BatteryTest.Reason)
+	assert.Equal(t, model.ModeOnBattery, ups.params.OperatingMode, "stays on battery")
+
+	ups.SetMains(true)
+	assert.Equal(t, model.ModeOnline, ups.params.OperatingMode)
+}
+
+func Test_StartBatteryTest_errors(t *testing.T) {
+	conf := model.TestConfig(t)
+	ups := New(conf)
+	ups.SetMains(false)
+	assert.Error(t, ups.StartBatteryTest(), "on battery")
+	assert.Equal(t, model.BatteryTestNone, ups.params.BatteryTest.Result)
+}
+
+func Test_StartBatteryTest_openString(t *testing.T) {
+	conf := model.TestConfig(t)
+	ups := New(conf)
+	ups.params.Batteries[1].Faults = model.FaultOpenCell
+	require.NoError(t, ups.StartBatteryTest())
+	assert.Equal(t, model.BatteryTestFailed, ups.params.BatteryTest.Result)
+	assert.Equal(t, model.ModeShutdown, ups.params.OperatingMode)
+}
@@ -0,0 +1,95 @@
+package ups
+
+import (
+	"errors"
+	"log"
+	"math"
+
+	"github.com/alex11prog/ups-imitator/internal/app/model"
+)
+
+// isBypassAvailable reports whether the load can be transferred to the bypass:
+// the input voltage of all phases is within the bypass window and the inverter can be synchronized with the input
+func (u *Ups) isBypassAvailable() bool {
+	conf := &u.conf.Bypass
+	if math.Abs(float64(u.params.InputFrequency-u.conf.Input.Frequency)) > float64(conf.FrequencyTolerance) {
+		return false
+	}
+	for i := range u.numOfPhases() {
+		voltage := u.params.Phases[i].InputAcVoltage
+		if voltage < conf.VoltageLow || voltage > conf.VoltageHigh {
+			return false
+		}
+	}
+	return true
+}
+
+// isOnBypass reports whether the load is fed from the input through the static switch
+func (u *Ups) isOnBypass() bool {
+	return u.params.OperatingMode == model.ModeBypass || u.params.OperatingMode == model.ModeEco
+}
+
+// inputMode returns the operating mode for the acceptable input:
+// bypass on request, on the inverter fault or on the overload trip, ECO if enabled, otherwise double conversion
+func (u *Ups) inputMode() model.OperatingMode {
+	switch {
+	case !u.isBypassAvailable():
+		return model.ModeOnline
+	case u.bypassRequested, u.inverterFault, u.overloadTripped && u.conf.Overload.Action == model.OverloadActionBypass:
+		return model.ModeBypass
+	case u.conf.Bypass.EcoMode:
+		return model.ModeEco
+	}
+	return model.ModeOnline
+}
+
+// RequestBypass transfers the load to the static bypass until ReturnFromBypass
+func (u *Ups) RequestBypass() error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if !u.isBypassAvailable() {
+		return errors.New("bypass unavailable")
+	}
+	u.bypassRequested = true
+	u.applyInputChange()
+	return nil
+}
+
+// ReturnFromBypass transfers the load back to the inverter
+func (u *Ups) ReturnFromBypass() error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if !u.bypassRequested {
+		return errors.New("bypass not requested")
+	}
+	u.bypassRequested = false
+	u.applyInputChange()
+	return nil
+}
+
+// FailInverter fails the inverter until RepairInverter: the load is transferred to the bypass,
+// the output is shut down if the bypass is unavailable
+func (u *Ups) FailInverter() error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.inverterFault {
+		return errors.New("inverter already failed")
+	}
+	log.Println("inverter fault")
+	u.inverterFault = true
+	u.applyInputChange()
+	return nil
+}
+
+// RepairInverter returns the load to the repaired inverter
+func (u *Ups) RepairInverter() error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if !u.inverterFault {
+		return errors.New("inverter not failed")
+	}
+	log.Println("inverter repaired")
+	u.inverterFault = false
+	u.applyInputChange()
+	return nil
+}
@@ -0,0 +1,84 @@
+package ups
+
+import (
+	"testing"
+
+	"github.com/alex11prog/ups-imitator/internal/app/model"
+	"github.com/alex11prog/ups-imitator/internal/app/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_RequestBypass(t *testing.T) {
+	conf := model.TestConfig(t)
+	ups := New(conf)
+	assert.Error(t, ups.ReturnFromBypass(), "not requested")
+
+	require.NoError(t, ups.RequestBypass())
+	assert.Equal(t, model.ModeBypass, ups.params.OperatingMode)
+	assert.True(t, ups.params.Alarms.OnBypass)
+	assert.False(t, ups.params.Alarms.UpcInBatteryMode)
+	assert.Equal(t, conf.DefaultInputAcVoltage, ups.params.OutputAcVoltage)
+	chargerPower := ups.params.BatGroupVoltage * ups.params.BatGroupCurrent / conf.Output.RectifierEfficiency
+	assert.InDelta(t, conf.LoadPower+chargerPower, ups.params.InputActivePower, 0.1, "no inverter losses")
+	assert.InDelta(t, ups.params.InputApparentPower, ups.params.InputAcVoltage*ups.params.InputAcCurrent, 0.1)
+
+	ups.UpdateParams(model.UpsParamsUpdateForm{InputAcVoltage: utils.NewP(float32(170))})
+	assert.Equal(t, model.ModeBypass, ups.params.OperatingMode, "within the bypass window")
+	assert.Equal(t, float32(170), ups.params.OutputAcVoltage)
+
+	ups.UpdateParams(model.UpsParamsUpdateForm{InputAcVoltage: utils.NewP(float32(0))})
+	assert.Equal(t, model.ModeOnBattery, ups.params.OperatingMode)
+	assert.False(t, ups.params.Alarms.OnBypass)
+	assert.True(t, ups.params.Alarms.BypassUnavailable)
+	assert.Error(t, ups.RequestBypass())
+
+	ups.UpdateParams(model.UpsParamsUpdateForm{InputAcVoltage: utils.NewP(conf.DefaultInputAcVoltage)})
+	assert.Equal(t, model.ModeBypass, ups.params.OperatingMode, "the request is kept")
+
+	require.NoError(t, ups.ReturnFromBypass())
+	assert.Equal(t, model.ModeOnline, ups.params.OperatingMode)
+	assert.Equal(t, conf.Output.Voltage, ups.params.OutputAcVoltage)
+}
+
+func Test_FailInverter(t *testing.T) {
+	conf := model.TestConfig(t)
+	ups := New(conf)
+	assert.Error(t, ups.RepairInverter(), "not failed")
+
+	require.NoError(t, ups.FailInverter())
+	assert.Error(t, ups.FailInverter(), "already failed")
+	assert.Equal(t, model.ModeBypass, ups.params.OperatingMode)
+	assert.True(t, ups.params.Alarms.OnBypass)
+
+	ups.UpdateParams(model.UpsParamsUpdateForm{InputAcVoltage: utils.NewP(float32(0))})
+	assert.Equal(t, model.ModeShutdown, ups.params.OperatingMode, "no inverter to feed the load from the battery")
+	assert.Equal(t, float32(0), ups.params.OutputAcVoltage)
+
+	ups.UpdateParams(model.UpsParamsUpdateForm{InputAcVoltage: utils.NewP(conf.DefaultInputAcVoltage)})
+	assert.Equal(t, model.ModeBypass, ups.params.OperatingMode)
+
+	require.NoError(t, ups.RepairInverter())
+	assert.Equal(t, model.ModeOnline, ups.params.OperatingMode)
+	assert.Equal(t, conf.Output.Voltage, ups.params.OutputAcVoltage)
+}
+
+func Test_EcoMode(t *testing.T) {
+	conf := model.TestConfig(t)
+	conf.Bypass.EcoMode = true
+	ups := New(conf)
+	assert.Equal(t, model.ModeEco, ups.params.OperatingMode)
+	assert.False(t, ups.params.Alarms.OnBypass)
+
+	ups.params.InputFrequency = conf.Input.Frequency + 2.5
+	ups.recalcTransfer(false)
+	assert.Equal(t, model.ModeOnline, ups.params.OperatingMode, "not synchronized, the input is acceptable")
+
+	ups.params.InputFrequency = conf.Input.Frequency
+	ups.recalcTransfer(false)
+	assert.Equal(t, model.ModeEco, ups.params.OperatingMode)
+
+	ups.setInputVoltage(170)
+	ups.recalcTransfer(false)
+	assert.Equal(t, model.ModeOnBattery, ups.params.OperatingMode, "no transfer delay in ECO mode")
+}
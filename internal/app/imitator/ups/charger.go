@@ -0,0 +1,109 @@
+package ups
+
+import (
+	"log"
+
+	"github.com/alex11prog/ups-imitator/internal/app/model"
+)
+
+const (
+	tempCompensationRefTemp = 25    // °C, set-points in the config are given for this temperature
+	minSocGap               = 0.005 // keeps the polarization resistance finite at SOC = 1
+)
+
+// startCharging puts the charger into the bulk stage unless it is disabled
+func (u *Ups) startCharging() {
+	if u.conf.Charger.Disabled {
+		return
+	}
+	u.setChargerStage(model.ChargerBulk)
+}
+
+// stopCharging turns the charger off, e.g. when the input power is lost
+func (u *Ups) stopCharging() {
+	u.setChargerStage(model.ChargerOff)
+}
+
+// recalcCharger recalculates BatGroupCurrent and BatGroupVoltage depending on the charger stage.
+// While charging the battery behaves as its rest voltage plus the internal resistance and a charge acceptance
+// resistance that grows when the battery approaches full charge, so the current decays naturally in the CV stages
+func (u *Ups) recalcCharger() {
+	rest := u.restVoltage()
+	resist := u.internalResist() + u.chargeResist()
+	limit := u.conf.ChargeCurrentLimit
+	conf := &u.conf.Charger
+
+	switch u.params.ChargerStage {
+	case model.ChargerOff:
+		u.params.BatGroupCurrent = 0
+		u.params.BatGroupVoltage = u.terminalVoltage()
+
+	case model.ChargerBulk:
+		voltage := rest + limit*resist
+		absorption := u.compensatedSetPoint(conf.AbsorptionVoltage)
+		if voltage < absorption {
+			u.params.BatGroupCurrent = limit
+			u.params.BatGroupVoltage = voltage
+			return
+		}
+		u.setChargerStage(model.ChargerAbsorption)
+		u.applyConstantVoltage(absorption, rest, resist)
+
+	case model.ChargerAbsorption:
+		u.applyConstantVoltage(u.compensatedSetPoint(conf.AbsorptionVoltage), rest, resist)
+		if u.params.BatGroupCurrent < conf.FloatCurrentThreshold {
+			u.setChargerStage(model.ChargerFloat)
+		}
+
+	case model.ChargerFloat:
+		u.params.BatGroupCurrent = u.overchargeCurrent(conf.FloatVoltage)
+		u.params.BatGroupVoltage = u.compensatedSetPoint(conf.FloatVoltage)
+		if conf.EqualizeInterval > 0 && u.clock.Since(u.lastEqualizeTime) > conf.EqualizeInterval {
+			u.setChargerStage(model.ChargerEqualize)
+		}
+
+	case model.ChargerEqualize:
+		u.params.BatGroupCurrent = u.overchargeCurrent(conf.EqualizeVoltage)
+		u.params.BatGroupVoltage = u.compensatedSetPoint(conf.EqualizeVoltage)
+		if u.clock.Since(u.chargerStageTime) > conf.EqualizeDuration {
+			u.lastEqualizeTime = u.clock.Now()
+			u.setChargerStage(model.ChargerFloat)
+		}
+	}
+}
+
+// applyConstantVoltage holds the battery group at the set-point and
+// calculates the current accepted by the battery, limited by ChargeCurrentLimit
+func (u *Ups) applyConstantVoltage(setPoint, rest, resist float32) {
+	current := (setPoint - rest) / resist
+	current = min(max(current, 0), u.conf.ChargeCurrentLimit)
+	u.params.BatGroupCurrent = current
+	u.params.BatGroupVoltage = rest + current*resist
+}
+
+// chargeResist returns the charge acceptance resistance of the battery group
+func (u *Ups) chargeResist() float32 {
+	return u.conf.Charger.PolarizationResist / (1 - u.params.SOC + minSocGap)
+}
+
+// compensatedSetPoint applies temperature compensation to the charger voltage set-point
+func (u *Ups) compensatedSetPoint(voltage float32) float32 {
+	return voltage + u.conf.Charger.TempCompensation*(u.avgBatTemp()-tempCompensationRefTemp)
+}
+
+func (u *Ups) avgBatTemp() float32 {
+	var sum float32
+	for _, bat := range u.params.Batteries {
+		sum += bat.Temp
+	}
+	return sum / float32(len(u.params.Batteries))
+}
+
+func (u *Ups) setChargerStage(s model.ChargerStage) {
+	if u.params.ChargerStage == s {
+		return
+	}
+	log.Printf("charger stage: %v\n", s)
+	u.params.ChargerStage = s
+	u.chargerStageTime = u.clock.Now()
+}
@@ -0,0 +1,53 @@
+package ups
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alex11prog/ups-imitator/internal/app/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_recalcCharger(t *testing.T) {
+	conf := model.TestConfig(t)
+	ups := New(conf)
+	ups.params.SOC = 0.2
+	ups.startCharging()
+
+	ups.recalcCharger()
+	assert.Equal(t, model.ChargerBulk, ups.params.ChargerStage)
+	assert.Equal(t, conf.ChargeCurrentLimit, ups.params.BatGroupCurrent)
+	assert.Less(t, ups.params.BatGroupVoltage, conf.Charger.AbsorptionVoltage)
+
+	ups.params.SOC = 0.95
+	ups.recalcCharger()
+	assert.Equal(t, model.ChargerAbsorption, ups.params.ChargerStage)
+	assert.Less(t, ups.params.BatGroupCurrent, conf.ChargeCurrentLimit)
+	assert.InDelta(t, ups.compensatedSetPoint(conf.Charger.AbsorptionVoltage), ups.params.BatGroupVoltage, 0.01)
+
+	ups.params.SOC = 1
+	ups.recalcCharger()
+	assert.Equal(t, model.ChargerFloat, ups.params.ChargerStage)
+
+	ups.recalcCharger()
+	assert.InDelta(t, ups.compensatedSetPoint(conf.Charger.FloatVoltage), ups.params.BatGroupVoltage, 0.01)
+	assert.Greater(t, ups.params.BatGroupCurrent, float32(0), "float maintenance current")
+	assert.Less(t, ups.params.BatGroupCurrent, conf.Charger.FloatCurrentThreshold)
+
+	ups.lastEqualizeTime = time.Now().Add(-conf.Charger.EqualizeInterval * 2)
+	ups.recalcCharger()
+	assert.Equal(t, model.ChargerEqualize, ups.params.ChargerStage)
+
+	ups.chargerStageTime = time.Now().Add(-conf.Charger.EqualizeDuration * 2)
+	ups.recalcCharger()
+	assert.Equal(t, model.ChargerFloat, ups.params.ChargerStage)
+}
+
+func Test_compensatedSetPoint(t *testing.T) {
+	conf := model.TestConfig(t)
+	ups := New(conf)
+	for i := range ups.params.Batteries {
+		ups.params.Batteries[i].Temp = tempCompensationRefTemp + 10
+	}
+	assert.InDelta(t, conf.Charger.FloatVoltage+10*conf.Charger.TempCompensation, ups.compensatedSetPoint(conf.Charger.FloatVoltage), 0.001)
+}
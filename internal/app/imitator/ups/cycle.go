@@ -0,0 +1,64 @@
+package ups
+
+import (
+	"log"
+
+	"github.com/alex11prog/ups-imitator/internal/app/model"
+)
+
+// recalcCycle drives the input of the UPS through the states of the auto mode cycle.
+// Returns true if the state has been changed
+func (u *Ups) recalcCycle() bool {
+	for _, tr := range u.conf.Cycle.States[u.cycleState].Transitions {
+		if u.isTransitionReady(&tr) {
+			u.enterCycleState(u.conf.Cycle.StateIndex(tr.To))
+			return true
+		}
+	}
+	return false
+}
+
+func (u *Ups) isTransitionReady(tr *model.CycleTransition) bool {
+	if u.clock.Since(u.cycleStateTime) < tr.After {
+		return false
+	}
+	if tr.When != nil && !tr.When.Eval(&u.params) {
+		return false
+	}
+	switch tr.Event {
+	case model.CycleEventShutdown:
+		return u.params.OperatingMode == model.ModeShutdown
+	case model.CycleEventCharged:
+		return !u.isOnBattery() && u.params.ChargerStage == model.ChargerFloat
+	case model.CycleEventGeneratorRunning:
+		return u.params.Generator.State == model.GeneratorRunning
+	}
+	return true
+}
+
+// enterCycleState switches the mains and the load as the state declares
+func (u *Ups) enterCycleState(i int) {
+	state := &u.conf.Cycle.States[i]
+	log.Printf("\nnew state: %v\n\n", state.Name)
+	u.cycleState = i
+	u.cycleStateTime = u.clock.Now()
+
+	u.mainsVoltage = u.conf.DefaultInputAcVoltage
+	if state.InputVoltage > 0 {
+		u.mainsVoltage = state.InputVoltage
+	}
+	u.setMains(state.Mains)
+
+	switch {
+	case state.LoadPower > 0:
+		if u.cycleLoadProfile == "" {
+			u.cycleLoadProfile = u.loadProfile
+			u.setLoadProfile(model.LoadProfileConstant)
+		}
+		u.constantLoadPower = state.LoadPower
+		u.recalcLoadPower(0)
+	case u.cycleLoadProfile != "": // back to the replaced profile
+		u.setLoadProfile(u.cycleLoadProfile)
+		u.cycleLoadProfile = ""
+	}
+}
@@ -0,0 +1,57 @@
+package ups
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alex11prog/ups-imitator/internal/app/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func (u *Ups) cycleStateName() string {
+	return u.conf.Cycle.States[u.cycleState].Name
+}
+
+func Test_recalcCycle_partialDischarge(t *testing.T) {
+	conf := model.TestConfig(t)
+	conf.Cycle = model.CycleConfig{
+		Initial: "idle",
+		States: []model.CycleState{
+			{Name: "idle", Mains: true, Transitions: []model.CycleTransition{{To: "discharging", After: time.Minute}}},
+			{Name: "discharging", LoadPower: 3000, Transitions: []model.CycleTransition{
+				{To: "idle", When: &model.Condition{Param: model.SensorSOC, Op: "<", Value: 0.4}},
+			}},
+		},
+	}
+	ups := New(conf)
+	assert.False(t, ups.recalcCycle(), "dwell time")
+
+	ups.cycleStateTime = time.Now().Add(-time.Minute)
+	assert.True(t, ups.recalcCycle())
+	assert.Equal(t, "discharging", ups.cycleStateName())
+	assert.False(t, ups.mainsOn)
+	assert.Equal(t, float32(3000), ups.loadPower)
+	assert.Equal(t, model.LoadProfileConstant, ups.loadProfile)
+
+	ups.params.SOC = 0.5
+	assert.False(t, ups.recalcCycle())
+	ups.params.SOC = 0.39
+	assert.True(t, ups.recalcCycle())
+	assert.Equal(t, "idle", ups.cycleStateName())
+	assert.True(t, ups.mainsOn)
+	assert.Equal(t, conf.LoadPower, ups.loadPower, "the load profile is restored")
+}
+
+func Test_enterCycleState_inputVoltage(t *testing.T) {
+	conf := model.TestConfig(t)
+	conf.Cycle = model.CycleConfig{
+		Initial: "brownout",
+		States:  []model.CycleState{{Name: "brownout", Mains: true, InputVoltage: 190}},
+	}
+	ups := New(conf)
+	assert.Equal(t, float32(190), ups.params.InputAcVoltage)
+	assert.False(t, ups.recalcCycle(), "no transitions")
+
+	ups.Reset()
+	assert.Equal(t, float32(190), ups.params.InputAcVoltage)
+}
@@ -0,0 +1,155 @@
+package ups
+
+import (
+	"errors"
+	"log"
+	"math/rand"
+	"time"
+
+	"github.com/alex11prog/ups-imitator/internal/app/model"
+)
+
+// setMains turns the mains on or off, the input follows it through the ATS
+func (u *Ups) setMains(on bool) {
+	u.mainsOn = on
+	u.recalcInputSource()
+}
+
+// SetMains turns the mains on or off, the UPS reacts at once
+func (u *Ups) SetMains(on bool) {
+	u.mu.Lock()
+	u.setMains(on)
+	u.applyInputChange()
+	u.mu.Unlock()
+}
+
+// recalcInputSource feeds the input from the source selected by the ATS:
+// nominal voltage and frequency of the mains, the output of the generator or nothing
+func (u *Ups) recalcInputSource() {
+	switch {
+	case u.params.Generator.Source == model.SourceGenerator:
+		u.setInputVoltage(u.params.Generator.Voltage)
+		u.params.InputFrequency = u.params.Generator.Frequency
+	case u.mainsOn:
+		u.setInputVoltage(u.mainsVoltage)
+		u.params.InputFrequency = u.conf.Input.Frequency
+	default:
+		u.setInputVoltage(0)
+		u.params.InputFrequency = 0
+	}
+}
+
+// recalcGenerator drives the generator and the ATS: on a mains outage the generator is started after StartDelay,
+// a failed start is retried up to StartAttempts, after WarmUp the load is transferred to the generator.
+// When the mains has been back for RetransferDelay the load is transferred to it and the generator is stopped
+func (u *Ups) recalcGenerator(elapsed time.Duration) {
+	conf := &u.conf.Generator
+	if !conf.Enabled {
+		return
+	}
+	gen := &u.params.Generator
+	u.consumeFuel(elapsed)
+
+	switch gen.State {
+	case model.GeneratorStandby:
+		if !u.mainsOn {
+			gen.StartAttempts = 0
+			u.setGeneratorState(model.GeneratorStarting)
+		}
+	case model.GeneratorStarting:
+		switch {
+		case u.mainsOn:
+			u.setGeneratorState(model.GeneratorStandby)
+		case u.clock.Since(u.generatorStateTime) >= conf.StartDelay:
+			gen.StartAttempts++
+			switch {
+			case gen.FuelLevel > 0 && rand.Float32() >= conf.StartFailureProbability:
+				u.setGeneratorState(model.GeneratorWarmUp)
+			case int(gen.StartAttempts) >= conf.StartAttempts:
+				u.setGeneratorState(model.GeneratorFailed)
+			default: // the next attempt after StartDelay
+				log.Printf("generator start attempt %d failed\n", gen.StartAttempts)
+				u.generatorStateTime = u.clock.Now()
+			}
+		}
+	case model.GeneratorWarmUp:
+		switch {
+		case u.mainsOn:
+			u.setGeneratorState(model.GeneratorStandby)
+		case u.clock.Since(u.generatorStateTime) >= conf.WarmUp:
+			u.mainsReturnTime = time.Time{}
+			u.setGeneratorState(model.GeneratorRunning)
+		}
+	case model.GeneratorRunning:
+		switch {
+		case gen.FuelLevel <= 0:
+			u.setGeneratorState(model.GeneratorFailed)
+		case !u.mainsOn:
+			u.mainsReturnTime = time.Time{}
+		case u.mainsReturnTime.IsZero():
+			u.mainsReturnTime = u.clock.Now()
+		case u.clock.Since(u.mainsReturnTime) >= conf.RetransferDelay:
+			u.setGeneratorState(model.GeneratorStandby)
+		}
+	case model.GeneratorFailed:
+		if u.mainsOn {
+			u.setGeneratorState(model.GeneratorStandby)
+		}
+	}
+
+	gen.Source = model.SourceMains
+	if gen.State == model.GeneratorRunning {
+		gen.Source = model.SourceGenerator
+	}
+	gen.Voltage, gen.Frequency = 0, 0
+	if gen.State == model.GeneratorWarmUp || gen.State == model.GeneratorRunning {
+		gen.Voltage = u.conf.DefaultInputAcVoltage
+		gen.Frequency = u.conf.Input.Frequency + conf.FrequencyDeviation*(2*rand.Float32()-1)
+	}
+	u.recalcInputSource()
+}
+
+// consumeFuel reduces the fuel level of the running generator: the idle consumption and the consumption
+// proportional to the energy delivered to the UPS
+func (u *Ups) consumeFuel(elapsed time.Duration) {
+	conf := &u.conf.Generator
+	gen := &u.params.Generator
+	if gen.State != model.GeneratorWarmUp && gen.State != model.GeneratorRunning {
+		return
+	}
+	var power float32 // kW
+	if gen.Source == model.SourceGenerator {
+		power = u.params.InputActivePower / 1000
+	}
+	fuel := (conf.IdleFuelConsumption + conf.FuelConsumption*power) * float32(elapsed.Hours())
+	gen.FuelLevel = max(gen.FuelLevel-fuel/conf.FuelTank, 0)
+}
+
+func (u *Ups) setGeneratorState(s model.GeneratorState) {
+	log.Printf("generator state: %v\n", s)
+	u.params.Generator.State = s
+	u.generatorStateTime = u.clock.Now()
+}
+
+// GetGenerator returns the params of the generator and the ATS
+func (u *Ups) GetGenerator() model.GeneratorParams {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.params.Generator
+}
+
+// UpdateGenerator refuels the generator
+func (u *Ups) UpdateGenerator(form model.GeneratorUpdateForm) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if !u.conf.Generator.Enabled {
+		return errors.New("generator disabled")
+	}
+	if form.FuelLevel != nil {
+		if *form.FuelLevel < 0 || *form.FuelLevel > 1 {
+			return errors.New("fuel_level must be from 0 to 1")
+		}
+		u.params.Generator.FuelLevel = *form.FuelLevel
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package ups
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alex11prog/ups-imitator/internal/app/model"
+	"github.com/alex11prog/ups-imitator/internal/app/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newGeneratorUps(t *testing.T) (*Ups, *model.Config) {
+	conf := model.TestConfig(t)
+	conf.Generator.Enabled = true
+	return New(conf), conf
+}
+
+// passGeneratorState shifts the start of the current generator state to the past
+func (u *Ups) passGeneratorState(d time.Duration) {
+	u.generatorStateTime = u.generatorStateTime.Add(-d)
+}
+
+func Test_recalcGenerator(t *testing.T) {
+	ups, conf := newGeneratorUps(t)
+	gen := &ups.params.Generator
+
+	ups.setMains(false)
+	ups.recalcGenerator(time.Second)
+	assert.Equal(t, model.GeneratorStarting, gen.State)
+	assert.Zero(t, ups.params.InputAcVoltage)
+
+	ups.passGeneratorState(conf.Generator.StartDelay)
+	ups.recalcGenerator(time.Second)
+	assert.Equal(t, model.GeneratorWarmUp, gen.State)
+	assert.Equal(t, uint16(1), gen.StartAttempts)
+	assert.Equal(t, model.SourceMains, gen.Source)
+	assert.Zero(t, ups.params.InputAcVoltage, "no load during the warm-up")
+
+	ups.passGeneratorState(conf.Generator.WarmUp)
+	ups.recalcGenerator(time.Second)
+	assert.Equal(t, model.GeneratorRunning, gen.State)
+	assert.Equal(t, model.SourceGenerator, gen.Source)
+	assert.Equal(t, conf.DefaultInputAcVoltage, ups.params.InputAcVoltage)
+	assert.InDelta(t, conf.Input.Frequency, ups.params.InputFrequency, float64(conf.Generator.FrequencyDeviation))
+	ups.recalcTransfer(false)
+	assert.Equal(t, model.ModeOnline, ups.params.OperatingMode)
+
+	ups.setMains(true)
+	assert.Equal(t, model.SourceGenerator, gen.Source, "retransfer delay")
+	ups.recalcGenerator(time.Second)
+	ups.mainsReturnTime = ups.mainsReturnTime.Add(-conf.Generator.RetransferDelay)
+	ups.recalcGenerator(time.Second)
+	assert.Equal(t, model.GeneratorStandby, gen.State)
+	assert.Equal(t, model.SourceMains, gen.Source)
+	assert.Equal(t, conf.Input.Frequency, ups.params.InputFrequency)
+}
+
+func Test_recalcGenerator_startFailure(t *testing.T) {
+	ups, conf := newGeneratorUps(t)
+	conf.Generator.StartFailureProbability = 1
+	gen := &ups.params.Generator
+
+	ups.setMains(false)
+	ups.recalcGenerator(time.Second)
+	for range conf.Generator.StartAttempts - 1 {
+		ups.passGeneratorState(conf.Generator.StartDelay)
+		ups.recalcGenerator(time.Second)
+		assert.Equal(t, model.GeneratorStarting, gen.State)
+	}
+	ups.passGeneratorState(conf.Generator.StartDelay)
+	ups.recalcGenerator(time.Second)
+	assert.Equal(t, model.GeneratorFailed, gen.State)
+	assert.Equal(t, uint16(conf.Generator.StartAttempts), gen.StartAttempts)
+
+	ups.setMains(true)
+	ups.recalcGenerator(time.Second)
+	assert.Equal(t, model.GeneratorStandby, gen.State)
+}
+
+func Test_recalcGenerator_fuel(t *testing.T) {
+	ups, conf := newGeneratorUps(t)
+	gen := &ups.params.Generator
+	ups.setMains(false)
+	ups.recalcGenerator(time.Second)
+	ups.passGeneratorState(conf.Generator.StartDelay)
+	ups.recalcGenerator(time.Second)
+	ups.passGeneratorState(conf.Generator.WarmUp)
+	ups.recalcGenerator(time.Second)
+	ups.applyInputChange()
+	require.Equal(t, model.GeneratorRunning, gen.State)
+
+	level := gen.FuelLevel
+	ups.recalcGenerator(time.Hour)
+	kw := ups.params.InputActivePower / 1000
+	expected := (conf.Generator.IdleFuelConsumption + conf.Generator.FuelConsumption*kw) / conf.Generator.FuelTank
+	assert.InDelta(t, expected, level-gen.FuelLevel, 0.001)
+
+	ups.recalcGenerator(1000 * time.Hour)
+	assert.Zero(t, gen.FuelLevel)
+	ups.recalcGenerator(time.Second)
+	assert.Equal(t, model.GeneratorFailed, gen.State)
+	assert.Zero(t, ups.params.InputAcVoltage)
+
+	require.NoError(t, ups.UpdateGenerator(model.GeneratorUpdateForm{FuelLevel: utils.NewP(float32(1))}))
+	assert.Equal(t, float32(1), gen.FuelLevel)
+	assert.Error(t, ups.UpdateGenerator(model.GeneratorUpdateForm{FuelLevel: utils.NewP(float32(2))}))
+}
+
+func Test_recalcCycle_generator(t *testing.T) {
+	ups, conf := newGeneratorUps(t)
+	ups.enterCycleState(conf.Cycle.StateIndex("discharging"))
+	ups.params.Generator.State = model.GeneratorRunning
+	ups.cycleStateTime = time.Now().Add(-conf.CycleChangeTimeout * 2)
+	assert.True(t, ups.recalcCycle())
+	assert.Equal(t, "charging", ups.cycleStateName())
+	assert.True(t, ups.mainsOn)
+}
+
+func Test_UpdateGenerator_disabled(t *testing.T) {
+	conf := model.TestConfig(t)
+	ups := New(conf)
+	assert.Error(t, ups.UpdateGenerator(model.GeneratorUpdateForm{FuelLevel: utils.NewP(float32(1))}))
+	ups.setMains(false)
+	ups.recalcGenerator(time.Hour)
+	assert.Equal(t, model.GeneratorStandby, ups.params.Generator.State)
+}
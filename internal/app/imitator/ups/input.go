@@ -0,0 +1,163 @@
+package ups
+
+import (
+	"log"
+	"math"
+	"time"
+
+	"github.com/alex11prog/ups-imitator/internal/app/model"
+)
+
+// minThdLoadFraction limits the growth of the input current THD at light load
+const minThdLoadFraction = 0.1
+
+// inputQuality checks the input voltage of all phases and the frequency.
+// acceptable - the input is within the windows, severe - sag or swell, the transfer must be immediate
+func (u *Ups) inputQuality() (acceptable, severe bool) {
+	conf := &u.conf.Input
+	acceptable = u.params.InputFrequency >= conf.FrequencyLow && u.params.InputFrequency <= conf.FrequencyHigh
+	for i := range u.numOfPhases() {
+		voltage := u.params.Phases[i].InputAcVoltage
+		if voltage < conf.SagVoltage || voltage > conf.SwellVoltage {
+			severe = true
+		}
+		if voltage < conf.VoltageLow || voltage > conf.VoltageHigh {
+			acceptable = false
+		}
+	}
+	return acceptable && !severe, severe
+}
+
+// rectifierInputPower returns the active power drawn by the rectifier feeding the inverter and the charger (W)
+func (u *Ups) rectifierInputPower() float32 {
+	if u.params.InputAcVoltage <= 0 {
+		return 0
+	}
+	dcPower := u.inverterInputPower() + u.params.BatGroupVoltage*max(u.params.BatGroupCurrent, 0)
+	return dcPower / u.conf.Output.RectifierEfficiency
+}
+
+// rectifierThd returns the input current THD of the rectifier (percent) at the active power:
+// the harmonic currents change little with the load, so THD grows as the fundamental falls
+func (u *Ups) rectifierThd(power float32) float32 {
+	fraction := min(max(power/u.conf.Output.RatedActivePower, minThdLoadFraction), 1)
+	return u.conf.Output.RectifierRatedThd() / float32(math.Sqrt(float64(fraction)))
+}
+
+// recalcInputPower recalculates the active and apparent input power, the power factor and the current THD.
+// The power factor of the rectifier is its displacement factor times the distortion factor 1/sqrt(1 + THD²),
+// on bypass the load current passes through with the power factor of the load
+func (u *Ups) recalcInputPower() {
+	rectifierPower := u.rectifierInputPower()
+	var bypassPower float32
+	if u.isOnBypass() && u.params.InputAcVoltage > 0 {
+		bypassPower = u.outputActivePower()
+	}
+	var thd, rectifierApparentPower float32
+	if rectifierPower > 0 {
+		thd = u.rectifierThd(rectifierPower)
+		distortionFactor := 1 / float32(math.Sqrt(1+float64(thd*thd)/1e4))
+		rectifierApparentPower = rectifierPower / (u.conf.Output.RectifierDisplacementFactor() * distortionFactor)
+	}
+	apparentPower := rectifierApparentPower + bypassPower/u.conf.Output.LoadPowerFactor
+	u.params.InputActivePower = rectifierPower + bypassPower
+	u.params.InputApparentPower = apparentPower
+	u.params.InputPowerFactor = 0
+	u.params.InputCurrentThd = 0
+	if apparentPower > 0 {
+		u.params.InputPowerFactor = u.params.InputActivePower / apparentPower
+		u.params.InputCurrentThd = thd * rectifierApparentPower / apparentPower
+	}
+}
+
+// recalcTransfer decides whether the load is fed from the input or from the battery.
+// The transfer to battery is immediate on sag or swell, otherwise the input must be out of the windows
+// longer than TransferDelay, and it must be back within the windows longer than RetransferDelay to return.
+// If immediate is true the delays are ignored, e.g. when the input is changed manually.
+// The load stays on battery while the battery test is running
+func (u *Ups) recalcTransfer(immediate bool) {
+	u.applyPins() // the input may be pinned
+	acceptable, severe := u.inputQuality()
+	if acceptable {
+		u.inputFaultTime = time.Time{}
+		if u.inputOkTime.IsZero() {
+			u.inputOkTime = u.clock.Now()
+		}
+	} else {
+		u.inputOkTime = time.Time{}
+		if u.inputFaultTime.IsZero() {
+			u.inputFaultTime = u.clock.Now()
+		}
+	}
+	u.params.Alarms.InputFault = !acceptable
+
+	if (u.overloadTripped || u.inverterFault) && u.inputMode() != model.ModeBypass {
+		u.shutdown() // until the overload is cleared or the inverter is repaired
+		return
+	}
+
+	switch mode := u.params.OperatingMode; mode {
+	case model.ModeOnline, model.ModeEco, model.ModeBypass:
+		target := u.inputMode()
+		switch {
+		case target == model.ModeBypass: // the load stays on bypass regardless of the input windows
+			u.setOperatingMode(target)
+		case !acceptable:
+			// through the bypass the load is exposed to the input, so the transfer is immediate
+			if immediate || severe || mode != model.ModeOnline || u.clock.Since(u.inputFaultTime) >= u.conf.Input.TransferDelay {
+				u.transferToBattery()
+			}
+		default:
+			u.setOperatingMode(target)
+		}
+	case model.ModeOnBattery:
+		if u.inputMode() == model.ModeBypass ||
+			acceptable && !u.batTest.running && (immediate || u.clock.Since(u.inputOkTime) >= u.conf.Input.RetransferDelay) {
+			u.transferToInput()
+		} else if u.isStringOpen() { // the string collapses under the load
+			u.shutdown()
+		}
+	case model.ModeShutdown:
+		if acceptable || u.inputMode() == model.ModeBypass { // automatic restart
+			u.transferToInput()
+		}
+	}
+}
+
+func (u *Ups) transferToBattery() {
+	if u.params.RemainingBatCapacity <= 0 || u.isStringOpen() {
+		u.shutdown()
+		return
+	}
+	u.stopCharging()
+	u.setOperatingMode(model.ModeOnBattery)
+}
+
+func (u *Ups) transferToInput() {
+	u.startCharging()
+	u.setOperatingMode(u.inputMode())
+}
+
+func (u *Ups) shutdown() {
+	u.stopCharging()
+	u.setOperatingMode(model.ModeShutdown)
+}
+
+func (u *Ups) setOperatingMode(m model.OperatingMode) {
+	if u.params.OperatingMode == m {
+		return
+	}
+	log.Printf("operating mode: %v\n", m)
+	u.params.OperatingMode = m
+}
+
+// isOnBattery reports whether the input does not feed the UPS: on battery or shut down
+func (u *Ups) isOnBattery() bool {
+	return u.params.OperatingMode == model.ModeOnBattery || u.params.OperatingMode == model.ModeShutdown
+}
+
+// applyInputChange makes the UPS react to a manual change of the input at once
+func (u *Ups) applyInputChange() {
+	u.recalcTransfer(true)
+	u.recalcPowerFlow()
+}
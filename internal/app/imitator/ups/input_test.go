@@ -0,0 +1,136 @@
+package ups
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alex11prog/ups-imitator/internal/app/model"
+	"github.com/alex11prog/ups-imitator/internal/app/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_recalcTransfer(t *testing.T) {
+	conf := model.TestConfig(t)
+	ups := New(conf)
+
+	ups.setInputVoltage(170) // below the window, above sag
+	ups.recalcTransfer(false)
+	assert.Equal(t, model.ModeOnline, ups.params.OperatingMode, "transfer delay")
+	assert.True(t, ups.params.Alarms.InputFault)
+
+	ups.inputFaultTime = time.Now().Add(-conf.Input.TransferDelay * 2)
+	ups.recalcTransfer(false)
+	assert.Equal(t, model.ModeOnBattery, ups.params.OperatingMode)
+
+	ups.setInputVoltage(conf.DefaultInputAcVoltage)
+	ups.recalcTransfer(false)
+	assert.Equal(t, model.ModeOnBattery, ups.params.OperatingMode, "retransfer delay")
+	assert.False(t, ups.params.Alarms.InputFault)
+
+	ups.inputOkTime = time.Now().Add(-conf.Input.RetransferDelay * 2)
+	ups.recalcTransfer(false)
+	assert.Equal(t, model.ModeOnline, ups.params.OperatingMode)
+
+	ups.setInputVoltage(100) // sag
+	ups.recalcTransfer(false)
+	assert.Equal(t, model.ModeOnBattery, ups.params.OperatingMode)
+}
+
+func Test_recalcTransfer_frequency(t *testing.T) {
+	conf := model.TestConfig(t)
+	ups := New(conf)
+
+	ups.params.InputFrequency = 45
+	ups.recalcTransfer(false)
+	assert.True(t, ups.params.Alarms.InputFault)
+	ups.inputFaultTime = time.Now().Add(-conf.Input.TransferDelay * 2)
+	ups.recalcTransfer(false)
+	assert.Equal(t, model.ModeOnBattery, ups.params.OperatingMode)
+}
+
+func Test_recalcTransfer_shutdown(t *testing.T) {
+	conf := model.TestConfig(t)
+	ups := New(conf)
+
+	ups.params.RemainingBatCapacity = 0
+	ups.setMains(false)
+	ups.recalcTransfer(false)
+	assert.Equal(t, model.ModeShutdown, ups.params.OperatingMode)
+
+	ups.setMains(true)
+	ups.recalcTransfer(false)
+	assert.Equal(t, model.ModeOnline, ups.params.OperatingMode, "automatic restart")
+}
+
+func Test_UpdateParams_input(t *testing.T) {
+	conf := model.TestConfig(t)
+	ups := New(conf)
+
+	ups.UpdateParams(model.UpsParamsUpdateForm{InputAcVoltage: utils.NewP(float32(0))})
+	assert.Equal(t, model.ModeOnBattery, ups.params.OperatingMode)
+	assert.True(t, ups.params.Alarms.UpcInBatteryMode)
+	assert.Equal(t, model.ChargerOff, ups.params.ChargerStage)
+	assert.Less(t, ups.params.BatGroupCurrent, float32(0))
+	assert.Zero(t, ups.params.InputAcCurrent)
+
+	ups.UpdateParams(model.UpsParamsUpdateForm{InputAcVoltage: utils.NewP(conf.DefaultInputAcVoltage)})
+	assert.Equal(t, model.ModeOnline, ups.params.OperatingMode, "no retransfer delay")
+	assert.False(t, ups.params.Alarms.UpcInBatteryMode)
+	assert.NotEqual(t, model.ChargerOff, ups.params.ChargerStage)
+}
+func Test_recalcInputPower(t *testing.T) {
+	testCases := []struct {
+		name      string
+		rectifier string
+		minPF     float32
+		maxPF     float32
+		minThd    float32
+		maxThd    float32
+	}{
+		{"igbt", model.RectifierIgbt, 0.95, 1, 3, 10},
+		{"six pulse", model.RectifierSixPulse, 0.6, 0.85, 30, 100},
+		{"twelve pulse", model.RectifierTwelvePulse, 0.8, 0.9, 10, 32},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			conf := model.TestConfig(t)
+			conf.Output.Rectifier = tc.rectifier
+			ups := New(conf)
+			assert.Greater(t, ups.params.InputActivePower, conf.LoadPower)
+			assert.Greater(t, ups.params.InputApparentPower, ups.params.InputActivePower)
+			assert.InDelta(t, ups.params.InputActivePower/ups.params.InputApparentPower, ups.params.InputPowerFactor, 0.0001)
+			assert.GreaterOrEqual(t, ups.params.InputPowerFactor, tc.minPF)
+			assert.LessOrEqual(t, ups.params.InputPowerFactor, tc.maxPF)
+			assert.GreaterOrEqual(t, ups.params.InputCurrentThd, tc.minThd)
+			assert.LessOrEqual(t, ups.params.InputCurrentThd, tc.maxThd)
+			assert.InDelta(t, ups.params.InputApparentPower, ups.params.InputAcVoltage*ups.params.InputAcCurrent, 0.1)
+		})
+	}
+}
+
+func Test_rectifierThd(t *testing.T) {
+	conf := model.TestConfig(t)
+	ups := New(conf)
+	rated := conf.Output.RectifierRatedThd()
+	assert.Equal(t, rated, ups.rectifierThd(conf.Output.RatedActivePower))
+	assert.InDelta(t, rated*2, ups.rectifierThd(conf.Output.RatedActivePower/4), 0.001, "grows at light load")
+	assert.Equal(t, ups.rectifierThd(conf.Output.RatedActivePower*minThdLoadFraction), ups.rectifierThd(0))
+}
+
+func Test_recalcInputPower_bypass(t *testing.T) {
+	conf := model.TestConfig(t)
+	ups := New(conf)
+	ups.bypassRequested = true
+	ups.applyInputChange()
+	assert.Equal(t, model.ModeBypass, ups.params.OperatingMode)
+	assert.InDelta(t, conf.LoadPower, ups.params.InputActivePower, 5, "the load and the float current of the charger")
+	assert.InDelta(t, conf.Output.LoadPowerFactor, ups.params.InputPowerFactor, 0.001, "the load passes through")
+	assert.Less(t, ups.params.InputCurrentThd, float32(0.1))
+
+	ups.setMains(false)
+	ups.applyInputChange()
+	assert.Zero(t, ups.params.InputActivePower)
+	assert.Zero(t, ups.params.InputApparentPower)
+	assert.Zero(t, ups.params.InputPowerFactor)
+	assert.Equal(t, conf.Output.LoadPowerFactor, ups.params.OutputPowerFactor)
+}
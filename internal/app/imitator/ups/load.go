@@ -0,0 +1,84 @@
+package ups
+
+import (
+	"log"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/alex11prog/ups-imitator/internal/app/model"
+)
+
+// recalcLoadPower updates the power consumed by the load according to the active load profile
+func (u *Ups) recalcLoadPower(elapsed time.Duration) {
+	conf := &u.conf.Load
+	sinceStart := u.clock.Since(u.loadProfileStartTime)
+	switch u.loadProfile {
+	case model.LoadProfileDaily:
+		u.loadPower = conf.DailyCurve.Power(u.clock.Now())
+	case model.LoadProfileSteps:
+		u.loadPower = conf.Steps.Power(sinceStart)
+	case model.LoadProfileRandomWalk:
+		u.recalcRandomWalk(elapsed)
+	case model.LoadProfileCsv:
+		u.loadPower = conf.Series.Power(sinceStart)
+	default:
+		u.loadPower = u.constantLoadPower
+	}
+}
+
+// recalcRandomWalk moves the load power by a normally distributed step,
+// its deviation grows with the square root of the elapsed time
+func (u *Ups) recalcRandomWalk(elapsed time.Duration) {
+	conf := &u.conf.Load
+	deviation := conf.RandomWalkStep * float32(math.Sqrt(elapsed.Minutes()))
+	power := u.loadPower + float32(rand.NormFloat64())*deviation
+	u.loadPower = min(max(power, conf.RandomWalkMin), conf.RandomWalkMax)
+}
+
+// setLoadProfile activates the load profile from the start, the random walk starts from LoadPower
+func (u *Ups) setLoadProfile(profile string) {
+	log.Printf("load profile: %v\n", profile)
+	u.loadProfile = profile
+	u.loadProfileStartTime = u.clock.Now()
+	u.loadPower = u.conf.LoadPower
+	u.constantLoadPower = u.conf.LoadPower
+	u.recalcLoadPower(0)
+}
+
+func (u *Ups) GetLoadProfile() string {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.loadProfile
+}
+
+// SetLoadProfile switches the load profile, it must be configured
+func (u *Ups) SetLoadProfile(profile string) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if err := u.conf.Load.CheckProfile(profile); err != nil {
+		return err
+	}
+	u.setLoadProfile(profile)
+	u.recalcPowerFlow()
+	return nil
+}
+
+// GetLoadPower returns the power consumed by the load (W)
+func (u *Ups) GetLoadPower() float32 {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.loadPower
+}
+
+// SetLoadPower switches to the constant load profile with the power instead of LoadPower
+func (u *Ups) SetLoadPower(power float32) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.loadProfile != model.LoadProfileConstant {
+		u.setLoadProfile(model.LoadProfileConstant)
+	}
+	u.constantLoadPower = power
+	u.recalcLoadPower(0)
+	u.recalcPowerFlow()
+}
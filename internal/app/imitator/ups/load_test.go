@@ -0,0 +1,45 @@
+package ups
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alex11prog/ups-imitator/internal/app/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SetLoadProfile(t *testing.T) {
+	conf := model.TestConfig(t)
+	ups := New(conf)
+	assert.Equal(t, model.LoadProfileConstant, ups.GetLoadProfile())
+	assert.Equal(t, conf.LoadPower, ups.params.OutputActivePower)
+
+	assert.Error(t, ups.SetLoadProfile(model.LoadProfileCsv))
+	assert.Error(t, ups.SetLoadProfile("invalid"))
+
+	require.NoError(t, ups.SetLoadProfile(model.LoadProfileSteps))
+	assert.Equal(t, conf.Load.Steps[0].Power, ups.params.OutputActivePower)
+	full := ups.params.Runtime
+
+	ups.loadProfileStartTime = time.Now().Add(-conf.Load.Steps[0].Duration)
+	ups.RecalculateParams()
+	assert.Equal(t, conf.Load.Steps[1].Power, ups.params.OutputActivePower)
+	assert.Less(t, ups.params.Runtime, full, "higher load")
+}
+
+func Test_recalcRandomWalk(t *testing.T) {
+	conf := model.TestConfig(t)
+	ups := New(conf)
+	require.NoError(t, ups.SetLoadProfile(model.LoadProfileRandomWalk))
+	assert.Equal(t, conf.LoadPower, ups.loadPower)
+
+	changed := false
+	for range 100 {
+		ups.recalcLoadPower(time.Hour)
+		assert.GreaterOrEqual(t, ups.loadPower, conf.Load.RandomWalkMin)
+		assert.LessOrEqual(t, ups.loadPower, conf.Load.RandomWalkMax)
+		changed = changed || ups.loadPower != conf.LoadPower
+	}
+	assert.True(t, changed)
+}
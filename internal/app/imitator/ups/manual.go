@@ -0,0 +1,94 @@
+package ups
+
+import "github.com/alex11prog/ups-imitator/internal/app/model"
+
+// manualEdits are the values set by the user in manual mode, held over the recalculations.
+// The input voltage and frequency are not held, they drive the model
+type manualEdits struct {
+	params    model.UpsParamsUpdateForm
+	alarms    model.AlarmsUpdateForm
+	batteries [4]model.BatteryParamsUpdateForm
+	phases    [3]model.PhaseParamsUpdateForm
+}
+
+// SetManual switches the manual mode. In manual mode the values set by the user are held over the recalculations
+// triggered by the changes of the input, the load, the bypass or the faults. The held values are released on switch
+func (u *Ups) SetManual(manual bool) {
+	u.mu.Lock()
+	u.manual = manual
+	u.edits = manualEdits{}
+	u.mu.Unlock()
+}
+
+// hold copies the value set by the user to the held one
+func hold[T any](held **T, value *T) {
+	if value != nil {
+		v := *value
+		*held = &v
+	}
+}
+
+func (u *Ups) holdParams(form model.UpsParamsUpdateForm) {
+	if !u.manual {
+		return
+	}
+	hold(&u.edits.params.InputAcCurrent, form.InputAcCurrent)
+	hold(&u.edits.params.BatGroupVoltage, form.BatGroupVoltage)
+	hold(&u.edits.params.BatGroupCurrent, form.BatGroupCurrent)
+}
+
+func (u *Ups) holdAlarms(form model.AlarmsUpdateForm) {
+	if !u.manual {
+		return
+	}
+	held := &u.edits.alarms
+	hold(&held.UpcInBatteryMode, form.UpcInBatteryMode)
+	hold(&held.LowBattery, form.LowBattery)
+	hold(&held.Overload, form.Overload)
+	hold(&held.PhaseLoss, form.PhaseLoss)
+	hold(&held.PhaseImbalance, form.PhaseImbalance)
+	hold(&held.InputFault, form.InputFault)
+	hold(&held.OnBypass, form.OnBypass)
+	hold(&held.BypassUnavailable, form.BypassUnavailable)
+}
+
+func (u *Ups) holdBatteryParams(bat_id int, form model.BatteryParamsUpdateForm) {
+	if !u.manual {
+		return
+	}
+	held := &u.edits.batteries[bat_id]
+	hold(&held.Voltage, form.Voltage)
+	hold(&held.Temp, form.Temp)
+	hold(&held.Resist, form.Resist)
+}
+
+func (u *Ups) holdPhaseParams(phase_id int, form model.PhaseParamsUpdateForm) {
+	if !u.manual {
+		return
+	}
+	held := &u.edits.phases[phase_id]
+	hold(&held.InputAcCurrent, form.InputAcCurrent)
+	hold(&held.OutputAcVoltage, form.OutputAcVoltage)
+	hold(&held.OutputAcCurrent, form.OutputAcCurrent)
+}
+
+// applyManualEdits sets the held values over the computed ones
+func (u *Ups) applyManualEdits() {
+	if !u.manual {
+		return
+	}
+	for i := range u.params.Phases {
+		u.params.Phases[i].Update(u.edits.phases[i])
+	}
+	if u.conf.ThreePhase.Enabled {
+		u.syncPhases()
+	}
+	u.params.Update(u.edits.params)
+	if !u.conf.ThreePhase.Enabled {
+		u.syncPhases()
+	}
+	u.params.Alarms.Update(u.edits.alarms)
+	for i := range u.params.Batteries {
+		u.params.Batteries[i].Update(u.edits.batteries[i])
+	}
+}
@@ -0,0 +1,43 @@
+package ups
+
+import (
+	"testing"
+
+	"github.com/alex11prog/ups-imitator/internal/app/model"
+	"github.com/alex11prog/ups-imitator/internal/app/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SetManual_holdsEdits(t *testing.T) {
+	conf := model.TestConfig(t)
+	ups := New(conf)
+	ups.SetManual(true)
+
+	ups.UpdateAlarms(model.AlarmsUpdateForm{LowBattery: utils.NewP(true), Overload: utils.NewP(true)})
+	ups.UpdateParams(model.UpsParamsUpdateForm{
+		InputAcVoltage:  utils.NewP(float32(0)),
+		InputAcCurrent:  utils.NewP(float32(1.5)),
+		BatGroupCurrent: utils.NewP(float32(-9)),
+	})
+	params := ups.GetAllParams()
+	assert.Equal(t, model.ModeOnBattery, params.OperatingMode, "the model reacts to the input")
+	assert.True(t, params.Alarms.UpcInBatteryMode)
+	assert.True(t, params.Alarms.LowBattery, "held")
+	assert.True(t, params.Alarms.Overload, "held")
+	assert.Equal(t, float32(1.5), params.InputAcCurrent, "held")
+	assert.Equal(t, float32(1.5), params.Phases[0].InputAcCurrent)
+	assert.Equal(t, float32(-9), params.BatGroupCurrent, "held")
+
+	assert.NoError(t, ups.UpdateBatteryParams(2, model.BatteryParamsUpdateForm{Voltage: utils.NewP(float32(11))}))
+	ups.SetMains(false)
+	ups.SetLoadPower(2000)
+	params = ups.GetAllParams()
+	assert.Equal(t, float32(11), params.Batteries[2].Voltage, "held over the load change")
+	assert.Equal(t, float32(-9), params.BatGroupCurrent)
+
+	ups.SetManual(false)
+	ups.SetMains(true)
+	params = ups.GetAllParams()
+	assert.False(t, params.Alarms.Overload, "released")
+	assert.NotEqual(t, float32(-9), params.BatGroupCurrent)
+}
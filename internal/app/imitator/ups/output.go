@@ -0,0 +1,115 @@
+package ups
+
+import (
+	"math"
+
+	"github.com/alex11prog/ups-imitator/internal/app/model"
+)
+
+// isOutputOn reports whether the inverter feeds the load
+func (u *Ups) isOutputOn() bool {
+	return u.params.OperatingMode != model.ModeShutdown
+}
+
+// outputActivePower returns the power consumed by the load (W)
+func (u *Ups) outputActivePower() float32 {
+	if !u.isOutputOn() {
+		return 0
+	}
+	return u.loadPower
+}
+
+// loadFraction returns the load relative to the rating of the UPS,
+// the greater of the active and apparent power ratios
+func (u *Ups) loadFraction() float32 {
+	return u.loadFractionOf(u.outputActivePower())
+}
+
+func (u *Ups) loadFractionOf(activePower float32) float32 {
+	apparentPower := activePower / u.conf.Output.LoadPowerFactor
+	return max(activePower/u.conf.Output.RatedActivePower, apparentPower/u.conf.Output.RatedApparentPower)
+}
+
+func (u *Ups) inverterEfficiency() float32 {
+	return u.efficiencyCurve.Efficiency(u.loadFraction())
+}
+
+// inverterInputPower returns the DC power consumed by the inverter (W), zero while the load is on bypass
+func (u *Ups) inverterInputPower() float32 {
+	if u.isOnBypass() {
+		return 0
+	}
+	return u.outputActivePower() / u.inverterEfficiency()
+}
+
+// projectedInverterInputPower returns the DC power the inverter would consume feeding the load from the battery (W),
+// also while the UPS is on mains or on bypass
+func (u *Ups) projectedInverterInputPower() float32 {
+	return u.loadPower / u.efficiencyCurve.Efficiency(u.loadFractionOf(u.loadPower))
+}
+
+// recalcDischarge recalculates BatGroupCurrent and BatGroupVoltage while the inverter is fed by the battery.
+// The current delivers the DC power of the inverter at the voltage under this current: P = (rest - I·R)·I,
+// so the ohmic drop appears at the same step as the current. Beyond the maximum power of the battery
+// the current stays at the maximum power point
+func (u *Ups) recalcDischarge() {
+	power := u.inverterInputPower()
+	rest := u.restVoltage()
+	resist := u.internalResist()
+	var current float32 // of the discharge, positive
+	switch d := rest*rest - 4*resist*power; {
+	case power <= 0:
+	case d >= 0:
+		current = 2 * power / (rest + float32(math.Sqrt(float64(d))))
+	default:
+		current = rest / (2 * resist)
+	}
+	u.params.BatGroupCurrent = -current
+	u.params.BatGroupVoltage = rest - current*resist
+}
+
+// outputVoltage returns the output voltage of the phase: the input voltage on bypass, the nominal voltage of the inverter otherwise
+func (u *Ups) outputVoltage(phase int) float32 {
+	switch {
+	case !u.isOutputOn():
+		return 0
+	case u.isOnBypass():
+		return u.params.Phases[phase].InputAcVoltage
+	}
+	return u.conf.Output.Voltage
+}
+
+func (u *Ups) outputFrequency() float32 {
+	switch {
+	case !u.isOutputOn():
+		return 0
+	case u.isOnBypass():
+		return u.params.InputFrequency
+	}
+	return u.conf.Output.Frequency
+}
+
+// recalcOutput recalculates the output params
+func (u *Ups) recalcOutput() {
+	activePower := u.outputActivePower()
+	apparentPower := activePower / u.conf.Output.LoadPowerFactor
+	u.params.OutputActivePower = activePower
+	u.params.OutputApparentPower = apparentPower
+	u.params.OutputPowerFactor = 0
+	if activePower > 0 {
+		u.params.OutputPowerFactor = u.conf.Output.LoadPowerFactor
+	}
+	u.params.LoadPercent = u.loadFraction() * 100
+	u.params.InverterEfficiency = u.inverterEfficiency()
+	u.params.OutputFrequency = u.outputFrequency()
+	if u.conf.ThreePhase.Enabled {
+		u.recalcOutputPhases(apparentPower)
+		return
+	}
+	voltage := u.outputVoltage(0)
+	u.params.OutputAcVoltage = voltage
+	u.params.OutputAcCurrent = 0
+	if voltage > 0 {
+		u.params.OutputAcCurrent = apparentPower / voltage
+	}
+}
@@ -0,0 +1,57 @@
+package ups
+
+import (
+	"testing"
+
+	"github.com/alex11prog/ups-imitator/internal/app/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_recalcOutput(t *testing.T) {
+	conf := model.TestConfig(t)
+	ups := New(conf)
+
+	ups.recalcOutput()
+	apparentPower := conf.LoadPower / conf.Output.LoadPowerFactor
+	assert.Equal(t, conf.Output.Voltage, ups.params.OutputAcVoltage)
+	assert.Equal(t, conf.Output.Frequency, ups.params.OutputFrequency)
+	assert.Equal(t, conf.LoadPower, ups.params.OutputActivePower)
+	assert.InDelta(t, apparentPower, ups.params.OutputApparentPower, 0.01)
+	assert.InDelta(t, apparentPower/conf.Output.Voltage, ups.params.OutputAcCurrent, 0.01)
+	assert.InDelta(t, apparentPower/conf.Output.RatedApparentPower*100, ups.params.LoadPercent, 0.01)
+	assert.Equal(t, model.DefaultEfficiencyCurve.Efficiency(ups.params.LoadPercent/100), ups.params.InverterEfficiency)
+
+	ups.params.OperatingMode = model.ModeShutdown
+	ups.recalcOutput()
+	assert.Equal(t, float32(0), ups.params.OutputAcVoltage)
+	assert.Equal(t, float32(0), ups.params.OutputAcCurrent)
+	assert.Equal(t, float32(0), ups.params.LoadPercent)
+}
+
+func Test_recalcDischarge(t *testing.T) {
+	conf := model.TestConfig(t)
+	ups := New(conf)
+	ups.SetMains(false)
+	require.Equal(t, model.ModeOnBattery, ups.params.OperatingMode)
+
+	// the ohmic drop appears right after the transfer, with the current
+	current, voltage := ups.params.BatGroupCurrent, ups.params.BatGroupVoltage
+	assert.Less(t, current, float32(0))
+	assert.InDelta(t, ups.restVoltage()+current*ups.internalResist(), voltage, 0.001)
+	assert.Less(t, voltage, ups.openCircuitVoltage()-0.1)
+	assert.InDelta(t, ups.inverterInputPower(), -current*voltage, 0.1)
+	assert.Greater(t, -current*voltage, conf.LoadPower, "inverter losses")
+
+	ups.loadPower = conf.Output.RatedActivePower
+	ups.recalcDischarge()
+	assert.Less(t, ups.params.BatGroupCurrent, current)
+	assert.Less(t, ups.params.BatGroupVoltage, voltage)
+
+	// beyond the maximum power of the battery
+	for i := range ups.params.Batteries {
+		ups.params.Batteries[i].Resist = 1000
+	}
+	ups.recalcDischarge()
+	assert.InDelta(t, ups.restVoltage()/2, ups.params.BatGroupVoltage, 0.001)
+}
@@ -0,0 +1,37 @@
+package ups
+
+import (
+	"log"
+	"time"
+
+	"github.com/alex11prog/ups-imitator/internal/app/model"
+)
+
+// isOverloaded reports whether the load demand exceeds the rating of the UPS
+func (u *Ups) isOverloaded() bool {
+	return u.loadFractionOf(u.loadPower) > 1
+}
+
+// recalcOverload integrates the overload of the inverter over the elapsed time:
+// the overload level grows by elapsed / trip time of the present load and trips the inverter at 1,
+// without overload it decays to zero within Cooldown. The trip is cleared when the load drops back
+func (u *Ups) recalcOverload(elapsed time.Duration) {
+	conf := &u.conf.Overload
+	onInverter := u.params.OperatingMode == model.ModeOnline || u.params.OperatingMode == model.ModeOnBattery
+	tripTime, trips := conf.TripCurve.TripTime(u.loadFractionOf(u.loadPower))
+	if onInverter && trips {
+		u.overloadLevel += float32(elapsed) / float32(tripTime)
+	} else {
+		u.overloadLevel = max(u.overloadLevel-float32(elapsed)/float32(conf.Cooldown), 0)
+	}
+
+	switch {
+	case !u.overloadTripped && u.overloadLevel >= 1:
+		log.Println("overload trip")
+		u.overloadTripped = true
+	case u.overloadTripped && !u.isOverloaded():
+		log.Println("overload cleared")
+		u.overloadTripped = false
+		u.overloadLevel = 0
+	}
+}
@@ -0,0 +1,62 @@
+package ups
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alex11prog/ups-imitator/internal/app/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_recalcOverload(t *testing.T) {
+	conf := model.TestConfig(t)
+	ups := New(conf)
+	ups.loadPower = conf.Output.RatedActivePower * 1.5 // 10 sec
+	ups.recalcPowerFlow()
+	assert.True(t, ups.params.Alarms.Overload)
+
+	ups.recalcOverload(5 * time.Second)
+	assert.False(t, ups.overloadTripped)
+	ups.recalcOverload(5 * time.Second)
+	assert.True(t, ups.overloadTripped)
+	ups.recalcTransfer(false)
+	assert.Equal(t, model.ModeBypass, ups.params.OperatingMode)
+
+	ups.loadPower = conf.LoadPower
+	ups.recalcOverload(time.Second)
+	assert.False(t, ups.overloadTripped)
+	ups.recalcTransfer(false)
+	ups.recalcPowerFlow()
+	assert.Equal(t, model.ModeOnline, ups.params.OperatingMode)
+	assert.False(t, ups.params.Alarms.Overload)
+}
+
+func Test_recalcOverload_cooldown(t *testing.T) {
+	conf := model.TestConfig(t)
+	ups := New(conf)
+	ups.loadPower = conf.Output.RatedActivePower * 1.5
+	ups.recalcOverload(5 * time.Second)
+	ups.loadPower = conf.LoadPower
+	ups.recalcOverload(conf.Overload.Cooldown / 4)
+	assert.InDelta(t, 0.25, ups.overloadLevel, 0.001)
+	ups.recalcOverload(conf.Overload.Cooldown)
+	assert.Zero(t, ups.overloadLevel)
+}
+
+func Test_recalcOverload_shutdown(t *testing.T) {
+	conf := model.TestConfig(t)
+	conf.Overload.Action = model.OverloadActionShutdown
+	ups := New(conf)
+	ups.loadPower = conf.Output.RatedActivePower * 1.5
+	ups.recalcOverload(time.Minute)
+	ups.recalcTransfer(false)
+	assert.Equal(t, model.ModeShutdown, ups.params.OperatingMode)
+	ups.recalcOverload(time.Minute)
+	ups.recalcTransfer(false)
+	assert.Equal(t, model.ModeShutdown, ups.params.OperatingMode, "no restart while overloaded")
+
+	ups.loadPower = conf.LoadPower
+	ups.recalcOverload(time.Second)
+	ups.recalcTransfer(false)
+	assert.Equal(t, model.ModeOnline, ups.params.OperatingMode)
+}
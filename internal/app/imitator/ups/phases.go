@@ -0,0 +1,144 @@
+package ups
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/alex11prog/ups-imitator/internal/app/model"
+)
+
+func (u *Ups) numOfPhases() int {
+	if u.conf.ThreePhase.Enabled {
+		return len(u.params.Phases)
+	}
+	return 1
+}
+
+// setInputVoltage sets the input voltage of all phases
+func (u *Ups) setInputVoltage(voltage float32) {
+	u.params.InputAcVoltage = voltage
+	for i := range u.numOfPhases() {
+		u.params.Phases[i].InputAcVoltage = voltage
+	}
+}
+
+// isPhaseLive reports whether the input voltage of the phase is high enough for the rectifier
+func (u *Ups) isPhaseLive(i int) bool {
+	return u.params.Phases[i].InputAcVoltage > u.conf.ThreePhase.PhaseLossVoltage
+}
+
+// recalcInputPhases distributes the apparent input power between the live phases of the three-phase rectifier
+func (u *Ups) recalcInputPhases(inputPower float32) {
+	live := 0
+	for i := range u.params.Phases {
+		if u.isPhaseLive(i) {
+			live++
+		}
+	}
+	for i := range u.params.Phases {
+		phase := &u.params.Phases[i]
+		if !u.isPhaseLive(i) {
+			phase.InputAcCurrent = 0
+			continue
+		}
+		phase.InputAcCurrent = inputPower / float32(live) / phase.InputAcVoltage
+	}
+}
+
+// recalcOutputPhases distributes the apparent power between the output phases according to LoadDistribution
+func (u *Ups) recalcOutputPhases(apparentPower float32) {
+	for i, share := range u.conf.ThreePhase.LoadDistribution {
+		phase := &u.params.Phases[i]
+		voltage := u.outputVoltage(i)
+		phase.OutputAcVoltage = voltage
+		if voltage == 0 {
+			phase.OutputAcCurrent = 0
+			continue
+		}
+		phase.OutputAcCurrent = apparentPower * share / voltage
+	}
+}
+
+// syncPhases keeps the per-phase and the common params consistent:
+// in single-phase mode L1 mirrors the common params, in three-phase mode the common params are averages of the phases
+func (u *Ups) syncPhases() {
+	if !u.conf.ThreePhase.Enabled {
+		u.params.Phases[0] = model.PhaseParams{
+			InputAcVoltage:  u.params.InputAcVoltage,
+			InputAcCurrent:  u.params.InputAcCurrent,
+			OutputAcVoltage: u.params.OutputAcVoltage,
+			OutputAcCurrent: u.params.OutputAcCurrent,
+		}
+		return
+	}
+	var avg model.PhaseParams
+	n := float32(len(u.params.Phases))
+	for _, phase := range u.params.Phases {
+		avg.InputAcVoltage += phase.InputAcVoltage / n
+		avg.InputAcCurrent += phase.InputAcCurrent / n
+		avg.OutputAcVoltage += phase.OutputAcVoltage / n
+		avg.OutputAcCurrent += phase.OutputAcCurrent / n
+	}
+	u.params.InputAcVoltage = avg.InputAcVoltage
+	u.params.InputAcCurrent = avg.InputAcCurrent
+	u.params.OutputAcVoltage = avg.OutputAcVoltage
+	u.params.OutputAcCurrent = avg.OutputAcCurrent
+}
+
+// recalcPhaseAlarms raises PhaseLoss if some, but not all, input phases are lost
+// and PhaseImbalance if the input voltages or the output currents deviate too much
+func (u *Ups) recalcPhaseAlarms() {
+	if !u.conf.ThreePhase.Enabled {
+		return
+	}
+	var voltages, currents [3]float32
+	live := 0
+	for i, phase := range u.params.Phases {
+		if u.isPhaseLive(i) {
+			live++
+		}
+		voltages[i] = phase.InputAcVoltage
+		currents[i] = phase.OutputAcCurrent
+	}
+	u.params.Alarms.PhaseLoss = live > 0 && live < len(u.params.Phases)
+	u.params.Alarms.PhaseImbalance = live == len(u.params.Phases) &&
+		(imbalance(voltages) > u.conf.ThreePhase.VoltageImbalanceAlarm ||
+			imbalance(currents) > u.conf.ThreePhase.CurrentImbalanceAlarm)
+}
+
+// imbalance returns the max deviation from the average (percent)
+func imbalance(values [3]float32) float32 {
+	var avg float32
+	for _, v := range values {
+		avg += v / float32(len(values))
+	}
+	if avg == 0 {
+		return 0
+	}
+	var maxDeviation float64
+	for _, v := range values {
+		maxDeviation = math.Max(maxDeviation, math.Abs(float64(v-avg)))
+	}
+	return float32(maxDeviation) / avg * 100
+}
+
+func (u *Ups) UpdatePhaseParams(phase_id int, form model.PhaseParamsUpdateForm) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if !u.conf.ThreePhase.Enabled {
+		return errors.New("single-phase mode")
+	}
+	if l := len(u.params.Phases); phase_id < 0 || phase_id >= l {
+		return fmt.Errorf("phase_id out of range: %d, expected less %d", phase_id, l)
+	}
+	u.params.Phases[phase_id].Update(form)
+	u.holdPhaseParams(phase_id, form)
+	u.syncPhases()
+	if form.InputAcVoltage != nil {
+		u.applyInputChange()
+	}
+	u.recalcPhaseAlarms()
+	u.applyManualEdits()
+	return nil
+}
@@ -0,0 +1,69 @@
+package ups
+
+import (
+	"testing"
+
+	"github.com/alex11prog/ups-imitator/internal/app/model"
+	"github.com/alex11prog/ups-imitator/internal/app/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ThreePhase_RecalculateParams(t *testing.T) {
+	conf := model.TestConfig(t)
+	conf.ThreePhase.Enabled = true
+	ups := New(conf)
+	ups.RecalculateParams()
+
+	var inputCurrent float32
+	for i, phase := range ups.params.Phases {
+		assert.Equal(t, conf.DefaultInputAcVoltage, phase.InputAcVoltage)
+		assert.Equal(t, conf.Output.Voltage, phase.OutputAcVoltage)
+		expected := ups.params.OutputApparentPower * conf.ThreePhase.LoadDistribution[i] / conf.Output.Voltage
+		assert.InDelta(t, expected, phase.OutputAcCurrent, 0.001)
+		inputCurrent += phase.InputAcCurrent / 3
+	}
+	assert.InDelta(t, inputCurrent, ups.params.InputAcCurrent, 0.001)
+	assert.False(t, ups.params.Alarms.PhaseLoss)
+	assert.False(t, ups.params.Alarms.PhaseImbalance)
+}
+
+func Test_UpdatePhaseParams(t *testing.T) {
+	conf := model.TestConfig(t)
+	ups := New(conf)
+	assert.Error(t, ups.UpdatePhaseParams(0, model.PhaseParamsUpdateForm{}), "single-phase mode")
+
+	conf.ThreePhase.Enabled = true
+	ups = New(conf)
+	assert.Error(t, ups.UpdatePhaseParams(3, model.PhaseParamsUpdateForm{}))
+	assert.Error(t, ups.UpdatePhaseParams(-1, model.PhaseParamsUpdateForm{}))
+
+	require.NoError(t, ups.UpdatePhaseParams(1, model.PhaseParamsUpdateForm{InputAcVoltage: utils.NewP(float32(200))}))
+	assert.False(t, ups.params.Alarms.PhaseLoss)
+	assert.True(t, ups.params.Alarms.PhaseImbalance)
+	assert.InDelta(t, (2*conf.DefaultInputAcVoltage+200)/3, ups.params.InputAcVoltage, 0.001)
+
+	require.NoError(t, ups.UpdatePhaseParams(1, model.PhaseParamsUpdateForm{InputAcVoltage: utils.NewP(float32(0))}))
+	assert.True(t, ups.params.Alarms.PhaseLoss)
+	assert.False(t, ups.params.Alarms.PhaseImbalance)
+
+	ups.UpdateParams(model.UpsParamsUpdateForm{InputAcVoltage: utils.NewP(conf.DefaultInputAcVoltage)})
+	assert.False(t, ups.params.Alarms.PhaseLoss)
+	assert.Equal(t, conf.DefaultInputAcVoltage, ups.params.Phases[1].InputAcVoltage)
+}
+
+func Test_SinglePhase_syncPhases(t *testing.T) {
+	conf := model.TestConfig(t)
+	ups := New(conf)
+	ups.RecalculateParams()
+	assert.Equal(t, ups.params.InputAcVoltage, ups.params.Phases[0].InputAcVoltage)
+	assert.Equal(t, ups.params.InputAcCurrent, ups.params.Phases[0].InputAcCurrent)
+	assert.Equal(t, ups.params.OutputAcCurrent, ups.params.Phases[0].OutputAcCurrent)
+	assert.Equal(t, model.PhaseParams{}, ups.params.Phases[1])
+}
+
+func Test_imbalance(t *testing.T) {
+	assert.Equal(t, float32(0), imbalance([3]float32{}))
+	assert.Equal(t, float32(0), imbalance([3]float32{220, 220, 220}))
+	assert.InDelta(t, 10, imbalance([3]float32{110, 90, 100}), 0.001)
+}
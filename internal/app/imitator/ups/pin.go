@@ -0,0 +1,104 @@
+package ups
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/alex11prog/ups-imitator/internal/app/model"
+)
+
+// pin holds a param at the value, see model.Pin
+type pin struct {
+	param   model.ParamRef
+	value   float32
+	expires time.Time // zero if never
+}
+
+// Pin holds the param at the value while the rest of the model keeps running and reacts to it,
+// e.g. a pinned input voltage out of the window transfers the load to battery.
+// The pin expires after the duration of the simulated time, never if zero.
+// The operating mode and the charger stage can't be pinned, they are driven by the state machines
+func (u *Ups) Pin(name string, value float32, duration time.Duration) error {
+	param, err := model.ParseParamRef(name)
+	if err != nil {
+		return err
+	}
+	if param.IsState() {
+		return fmt.Errorf("param can't be pinned: %q", name)
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	p := pin{param: param, value: value}
+	if duration > 0 {
+		p.expires = u.clock.Now().Add(duration)
+	}
+	if u.pins == nil {
+		u.pins = map[string]pin{}
+	}
+	u.pins[param.String()] = p
+	u.applyInputChange()
+	return nil
+}
+
+// Unpin releases the param, it follows the model again
+func (u *Ups) Unpin(name string) error {
+	param, err := model.ParseParamRef(name)
+	if err != nil {
+		return err
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if _, ok := u.pins[param.String()]; !ok {
+		return fmt.Errorf("param not pinned: %q", name)
+	}
+	u.unpin(param.String())
+	u.applyInputChange()
+	return nil
+}
+
+func (u *Ups) unpin(key string) {
+	param := u.pins[key].param
+	delete(u.pins, key)
+	if isInputParam(param.Name) {
+		u.recalcInputSource() // the input follows its source again
+	}
+}
+
+// isInputParam reports whether the param is driven by the input source
+func isInputParam(name string) bool {
+	return name == model.SensorInputAcVoltage || name == model.SensorInputFrequency || name == model.SensorPhaseInputAcVoltage
+}
+
+// applyPins releases the expired pins and sets the pinned params
+func (u *Ups) applyPins() {
+	for key, p := range u.pins {
+		if !p.expires.IsZero() && !u.clock.Now().Before(p.expires) {
+			log.Printf("pin expired: %s\n", key)
+			u.unpin(key)
+		}
+	}
+	for _, p := range u.pins {
+		if p.param.Name == model.SensorInputAcVoltage {
+			u.setInputVoltage(p.value) // of all phases
+		} else {
+			p.param.Set(&u.params, p.value)
+		}
+	}
+}
+
+// getPins returns the pins sorted by param
+func (u *Ups) getPins() []model.Pin {
+	pins := []model.Pin{}
+	for key, p := range u.pins {
+		pin := model.Pin{Param: key, Value: p.value}
+		if !p.expires.IsZero() {
+			pin.Expires = &p.expires
+		}
+		pins = append(pins, pin)
+	}
+	slices.SortFunc(pins, func(a, b model.Pin) int { return strings.Compare(a.Param, b.Param) })
+	return pins
+}
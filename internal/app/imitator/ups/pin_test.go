@@ -0,0 +1,68 @@
+package ups
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alex11prog/ups-imitator/internal/app/clock"
+	"github.com/alex11prog/ups-imitator/internal/app/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Pin_inputVoltage(t *testing.T) {
+	conf := model.TestConfig(t)
+	clk := clock.NewFake(time.Now())
+	ups := NewWithClock(conf, clk)
+
+	require.NoError(t, ups.Pin("input_ac_voltage", 170, 0))
+	assert.Equal(t, model.ModeOnBattery, ups.params.OperatingMode, "the model reacts to the pinned input")
+
+	clk.Advance(time.Minute)
+	ups.RecalculateParams()
+	assert.Equal(t, float32(170), ups.params.InputAcVoltage)
+	assert.Equal(t, float32(170), ups.params.Phases[0].InputAcVoltage)
+	assert.Equal(t, model.ModeOnBattery, ups.params.OperatingMode)
+	assert.Less(t, ups.params.SOC, float32(1), "the battery keeps discharging")
+	assert.Equal(t, []model.Pin{{Param: "input_ac_voltage", Value: 170}}, ups.GetAllParams().Pins)
+
+	require.NoError(t, ups.Unpin("input_ac_voltage"))
+	assert.Equal(t, conf.DefaultInputAcVoltage, ups.params.InputAcVoltage, "the input follows the mains again")
+	assert.Equal(t, model.ModeOnline, ups.params.OperatingMode)
+	assert.Empty(t, ups.GetAllParams().Pins)
+}
+
+func Test_Pin_expires(t *testing.T) {
+	conf := model.TestConfig(t)
+	clk := clock.NewFake(time.Now())
+	ups := NewWithClock(conf, clk)
+
+	require.NoError(t, ups.Pin("battery_temp[2]", 45, 10*time.Minute))
+	pins := ups.GetAllParams().Pins
+	require.Len(t, pins, 1)
+	assert.Equal(t, "battery_temp[2]", pins[0].Param)
+	assert.Equal(t, clk.Now().Add(10*time.Minute), *pins[0].Expires)
+
+	clk.Advance(5 * time.Minute)
+	ups.RecalculateParams()
+	assert.Equal(t, float32(45), ups.params.Batteries[2].Temp)
+
+	clk.Advance(5 * time.Minute)
+	ups.RecalculateParams()
+	assert.Empty(t, ups.GetAllParams().Pins)
+}
+
+func Test_Pin_errors(t *testing.T) {
+	conf := model.TestConfig(t)
+	ups := New(conf)
+	assert.Error(t, ups.Pin("invalid", 1, 0))
+	assert.Error(t, ups.Pin("battery_temp[4]", 1, 0))
+	assert.Error(t, ups.Pin("operating_mode", 1.5, 0), "state")
+	assert.Error(t, ups.Pin("charger_stage", 3, 0), "state")
+	assert.Empty(t, ups.GetAllParams().Pins)
+	assert.Error(t, ups.Unpin("soc"), "not pinned")
+
+	require.NoError(t, ups.Pin("soc", 0.5, 0))
+	ups.Reset()
+	assert.Empty(t, ups.GetAllParams().Pins)
+}
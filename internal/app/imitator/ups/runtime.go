@@ -0,0 +1,38 @@
+package ups
+
+import "math"
+
+// runtimeSteps is the number of SOC steps of the runtime estimation
+const runtimeSteps = 20
+
+// maxRuntime limits the estimated runtime (min) at no or a very light load
+const maxRuntime = 7 * 24 * 60
+
+// estimateRuntime returns the estimated time to empty (min) at the present load.
+// The remaining capacity is discharged in SOC steps, the current of each step delivers the DC power
+// of the inverter at the open circuit voltage of the step minus the drop on the internal
+// and polarization resistance: P = (OCV - I·R)·I. The estimation is limited by maxRuntime
+func (u *Ups) estimateRuntime() float32 {
+	power := u.projectedInverterInputPower()
+	if u.params.RemainingBatCapacity <= 0 || u.isStringOpen() {
+		return 0
+	}
+	if power <= 0 {
+		return maxRuntime
+	}
+	cells := float32(u.numOfCells())
+	resist := u.internalResist() + u.conf.Battery.RcResist
+	stepCapacity := u.params.RemainingBatCapacity / runtimeSteps // Ah
+	var hours float32
+	for i := range runtimeSteps {
+		soc := u.params.SOC * (1 - (float32(i)+0.5)/runtimeSteps)
+		ocv := u.ocvCurve.Voltage(soc) * cells
+		d := ocv*ocv - 4*resist*power
+		if d < 0 { // the battery can't deliver the power at this SOC
+			break
+		}
+		current := 2 * power / (ocv + float32(math.Sqrt(float64(d))))
+		hours += stepCapacity / current
+	}
+	return min(hours*60, maxRuntime)
+}
@@ -0,0 +1,43 @@
+package ups
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/alex11prog/ups-imitator/internal/app/model"
+	"github.com/alex11prog/ups-imitator/internal/app/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_estimateRuntime(t *testing.T) {
+	conf := model.TestConfig(t)
+	ups := New(conf)
+	full := ups.params.Runtime
+	ideal := conf.DefaultBatCapacity * ups.openCircuitVoltage() / ups.projectedInverterInputPower() * 60
+	assert.Greater(t, full, float32(0))
+	assert.Less(t, full, ideal, "voltage drops with SOC and under the load")
+
+	ups.UpdateParams(model.UpsParamsUpdateForm{InputAcVoltage: utils.NewP(float32(0))})
+	assert.InDelta(t, full, ups.params.Runtime, 0.5, "the projected load is the present load")
+
+	ups.params.RemainingBatCapacity = conf.DefaultBatCapacity / 2
+	ups.recalcSoc()
+	half := ups.estimateRuntime()
+	assert.Less(t, half, full/2, "the second half is discharged at lower voltage")
+
+	ups.params.RemainingBatCapacity = 0
+	ups.recalcSoc()
+	assert.Zero(t, ups.estimateRuntime())
+}
+
+func Test_estimateRuntime_noLoad(t *testing.T) {
+	conf := model.TestConfig(t)
+	ups := New(conf)
+	ups.SetLoadPower(0)
+	assert.Equal(t, float32(maxRuntime), ups.params.Runtime)
+	_, err := json.Marshal(ups.GetAllParams())
+	assert.NoError(t, err)
+
+	ups.SetLoadPower(1)
+	assert.Equal(t, float32(maxRuntime), ups.params.Runtime, "limited at a very light load")
+}
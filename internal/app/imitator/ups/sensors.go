@@ -0,0 +1,119 @@
+package ups
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/alex11prog/ups-imitator/internal/app/model"
+)
+
+// sensorState is the state of the measurement of a param
+type sensorState struct {
+	drift      float32 // relative
+	last       float32 // the last reading
+	valid      bool    // there is the last reading
+	stuck      bool
+	stuckValue float32 // the reading of the stuck sensor
+}
+
+// measure returns the reading of the value
+func (s *sensorState) measure(conf model.SensorConfig, value float32, elapsed time.Duration) float32 {
+	if conf.Stuck {
+		if !s.stuck {
+			s.stuck = true
+			s.stuckValue = value
+			if s.valid {
+				s.stuckValue = s.last
+			}
+		}
+		return s.stuckValue
+	}
+	s.stuck = false
+	if rand.Float32() < conf.DropoutProbability {
+		return 0
+	}
+	s.drift += conf.Drift * float32(math.Sqrt(elapsed.Hours())*rand.NormFloat64())
+	reading := value*(1+conf.Gain+s.drift) + conf.Offset
+	reading += value * conf.Noise * float32(rand.NormFloat64())
+	if rand.Float32() < conf.SpikeProbability {
+		spike := value * conf.SpikeAmplitude
+		if rand.Intn(2) == 0 {
+			spike = -spike
+		}
+		reading += spike
+	}
+	if conf.Resolution > 0 {
+		reading = float32(math.Round(float64(reading/conf.Resolution))) * conf.Resolution
+	}
+	s.last, s.valid = reading, true
+	return reading
+}
+
+// initSensors sets the sensors from the config, RuntimeError of the battery config is the noise of the runtime sensor
+func (u *Ups) initSensors() {
+	u.sensors = maps.Clone(u.conf.Sensors)
+	if u.sensors == nil {
+		u.sensors = model.SensorsConfig{}
+	}
+	if _, ok := u.sensors[model.SensorRuntime]; !ok && u.conf.Battery.RuntimeError > 0 {
+		u.sensors[model.SensorRuntime] = model.SensorConfig{Noise: u.conf.Battery.RuntimeError}
+	}
+	u.sensorStates = map[string][]sensorState{}
+	u.lastMeasTime = u.clock.Now()
+}
+
+// measureParams replaces the params with the readings of the sensors
+func (u *Ups) measureParams(params *model.UpsParams) {
+	elapsed := u.clock.Since(u.lastMeasTime)
+	u.lastMeasTime = u.clock.Now()
+	for name, values := range params.MeasuredParams() {
+		conf, ok := u.sensors[name]
+		if !ok {
+			continue
+		}
+		states := u.sensorStates[name]
+		if states == nil {
+			states = make([]sensorState, len(values))
+			u.sensorStates[name] = states
+		}
+		for i, value := range values {
+			*value = states[i].measure(conf, *value, elapsed)
+		}
+	}
+}
+
+// GetSensors returns the configured sensors
+func (u *Ups) GetSensors() model.SensorsConfig {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return maps.Clone(u.sensors)
+}
+
+// SetSensor replaces the sensor, the drift and the stuck reading are kept
+func (u *Ups) SetSensor(name string, conf model.SensorConfig) error {
+	if err := model.CheckSensorName(name); err != nil {
+		return err
+	}
+	if err := conf.Validate(); err != nil {
+		return err
+	}
+	u.mu.Lock()
+	u.sensors[name] = conf
+	u.mu.Unlock()
+	return nil
+}
+
+// DeleteSensor removes the sensor, the param is measured exactly
+func (u *Ups) DeleteSensor(name string) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if _, ok := u.sensors[name]; !ok {
+		return fmt.Errorf("sensor %q is not configured", name)
+	}
+	delete(u.sensors, name)
+	delete(u.sensorStates, name)
+	return nil
+}
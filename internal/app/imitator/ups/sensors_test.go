@@ -0,0 +1,94 @@
+package ups
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/alex11prog/ups-imitator/internal/app/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_sensorState_measure(t *testing.T) {
+	testCases := []struct {
+		name     string
+		conf     model.SensorConfig
+		value    float32
+		expected float32
+	}{
+		{"exact", model.SensorConfig{}, 220, 220},
+		{"offset and gain", model.SensorConfig{Offset: 1, Gain: 0.01}, 200, 203},
+		{"quantization", model.SensorConfig{Resolution: 0.5}, 220.3, 220.5},
+		{"dropout", model.SensorConfig{DropoutProbability: 1}, 220, 0},
+		{"spike", model.SensorConfig{SpikeProbability: 1, SpikeAmplitude: 0.5}, 200, 100},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var s sensorState
+			reading := s.measure(tc.conf, tc.value, time.Second)
+			if tc.conf.SpikeProbability > 0 { // up or down
+				assert.InDelta(t, tc.value-tc.expected, math.Abs(float64(reading-tc.value)), 0.001)
+				return
+			}
+			assert.InDelta(t, tc.expected, reading, 0.001)
+		})
+	}
+}
+
+func Test_sensorState_noise(t *testing.T) {
+	conf := model.SensorConfig{Noise: 0.01}
+	var s sensorState
+	var sum, sumSq float64
+	const n = 10000
+	for range n {
+		reading := float64(s.measure(conf, 100, time.Second))
+		sum += reading
+		sumSq += reading * reading
+	}
+	mean := sum / n
+	assert.InDelta(t, 100, mean, 0.1)
+	assert.InDelta(t, 1, math.Sqrt(sumSq/n-mean*mean), 0.1)
+}
+
+func Test_sensorState_drift(t *testing.T) {
+	conf := model.SensorConfig{Drift: 0.01}
+	var s sensorState
+	s.measure(conf, 100, 100*time.Hour)
+	assert.NotZero(t, s.drift)
+	drift := s.drift
+	s.measure(conf, 100, 0)
+	assert.Equal(t, drift, s.drift, "no drift without time")
+}
+
+func Test_sensorState_stuck(t *testing.T) {
+	var s sensorState
+	s.measure(model.SensorConfig{}, 220, time.Second)
+	stuck := model.SensorConfig{Stuck: true}
+	assert.Equal(t, float32(220), s.measure(stuck, 230, time.Second))
+	assert.Equal(t, float32(220), s.measure(stuck, 240, time.Second))
+	assert.Equal(t, float32(240), s.measure(model.SensorConfig{}, 240, time.Second), "released")
+}
+
+func Test_GetParamsWithSimulatedMeasErr(t *testing.T) {
+	conf := model.TestConfig(t)
+	conf.Battery.RuntimeError = 0.1
+	ups := New(conf)
+	assert.Equal(t, model.SensorConfig{Noise: 0.1}, ups.GetSensors()[model.SensorRuntime], "runtime_error")
+
+	require.NoError(t, ups.SetSensor(model.SensorBatteryVoltage, model.SensorConfig{Offset: 1}))
+	require.NoError(t, ups.SetSensor(model.SensorSOC, model.SensorConfig{DropoutProbability: 1}))
+	params := ups.GetParamsWithSimulatedMeasErr()
+	for i, bat := range ups.params.Batteries {
+		assert.Equal(t, bat.Voltage+1, params.Batteries[i].Voltage)
+	}
+	assert.Zero(t, params.SOC)
+	assert.Equal(t, ups.params.BatGroupVoltage, params.BatGroupVoltage, "no sensor")
+
+	require.NoError(t, ups.DeleteSensor(model.SensorSOC))
+	assert.Error(t, ups.DeleteSensor(model.SensorSOC))
+	assert.Equal(t, ups.params.SOC, ups.GetParamsWithSimulatedMeasErr().SOC)
+
+	assert.Error(t, ups.SetSensor("invalid", model.SensorConfig{}))
+	assert.Error(t, ups.SetSensor(model.SensorSOC, model.SensorConfig{Noise: -1}))
+}
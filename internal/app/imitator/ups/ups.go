@@ -2,161 +2,231 @@ package ups
 
 import (
 	"fmt"
-	"log"
 	"sync"
 	"time"
 
+	"github.com/alex11prog/ups-imitator/internal/app/clock"
 	"github.com/alex11prog/ups-imitator/internal/app/model"
-	"github.com/alex11prog/ups-imitator/internal/app/utils"
-)
-
-type chargeState uint8
-
-const (
-	chargedState chargeState = iota
-	dischargingState
-	dischargedState
-	chargingState
 )
 
 type Ups struct {
-	conf *model.Config
+	conf  *model.Config
+	clock clock.Clock
 
 	mu             sync.Mutex
-	state          chargeState
 	lastUpdateTime time.Time
-	cycleDoneTime  time.Time // charge or discharge
 	params         model.UpsParams
+	ocvCurve       model.OcvCurve // per cell
+
+	efficiencyCurve model.EfficiencyCurve // inverter
+
+	polarizationVoltage float32 // V, RC polarization element of the battery group
+
+	chargerStageTime time.Time // start of the current charger stage
+	lastEqualizeTime time.Time
+
+	inputFaultTime time.Time // the input is out of the windows since, zero if acceptable
+	inputOkTime    time.Time // the input is within the windows since, zero if not acceptable
+
+	bypassRequested bool // manual transfer to the bypass
+	inverterFault   bool // the failed inverter can't feed the load
+
+	cycleState       int       // auto mode cycle, index of the state in the config
+	cycleStateTime   time.Time // start of the current cycle state
+	cycleSuspended   bool      // the auto mode cycle does not drive the input, e.g. while a scenario is running
+	cycleLoadProfile string    // the load profile replaced by the load power of the cycle state, empty if none
+
+	loadProfile          string
+	loadProfileStartTime time.Time
+	loadPower            float32 // W, consumed by the load according to the load profile
+	constantLoadPower    float32 // W, of the constant load profile
+
+	overloadLevel   float32 // accumulated overload, trips at 1
+	overloadTripped bool    // the inverter is tripped by the overload until the load drops back
+
+	batFaults [4]batteryFaultState
+
+	mainsOn            bool      // the utility power, the input is fed from it unless the ATS is on the generator
+	mainsVoltage       float32   // V, of the mains
+	generatorStateTime time.Time // start of the current generator state or start attempt
+	mainsReturnTime    time.Time // the mains is back since while the load is on the generator
+
+	pins map[string]pin // by canonical param name, see ParamRef.String
+
+	batTest batteryTestState
+
+	manual bool        // the values set by the user are held, see SetManual
+	edits  manualEdits // held in manual mode
+
+	sensors      model.SensorsConfig
+	sensorStates map[string][]sensorState // by sensor, a state per measured param
+	lastMeasTime time.Time
 }
 
 func New(conf *model.Config) *Ups {
+	return NewWithClock(conf, clock.NewScaled(conf.ClockSpeed))
+}
+
+// NewWithClock creates the UPS living by the clock, e.g. a fake one in tests
+func NewWithClock(conf *model.Config, clk clock.Clock) *Ups {
 	u := &Ups{
-		conf:           conf,
-		lastUpdateTime: time.Now(),
-		cycleDoneTime:  time.Now(),
+		conf:             conf,
+		clock:            clk,
+		lastUpdateTime:   clk.Now(),
+		mainsVoltage:     conf.DefaultInputAcVoltage,
+		chargerStageTime: clk.Now(),
+		lastEqualizeTime: clk.Now(),
 	}
+	u.ocvCurve = conf.OcvCurve(len(u.params.Batteries))
+	u.efficiencyCurve = conf.Output.InverterEfficiencyCurve()
+	u.setLoadProfile(conf.Load.Profile)
+	u.initSensors()
 	u.setDefaultUpsParams()
+	u.enterCycleState(conf.Cycle.StateIndex(conf.Cycle.Initial))
+	u.applyInputChange()
 	return u
 }
 
 // Reset sets the default value for all params
 func (u *Ups) Reset() {
 	u.mu.Lock()
-	u.state = chargedState
-	u.lastUpdateTime = time.Now()
-	u.cycleDoneTime = time.Now()
+	u.bypassRequested = false
+	u.inverterFault = false
+	u.overloadLevel = 0
+	u.overloadTripped = false
+	u.inputFaultTime = time.Time{}
+	u.inputOkTime = time.Time{}
+	u.lastUpdateTime = u.clock.Now()
+	u.mainsVoltage = u.conf.DefaultInputAcVoltage
+	u.chargerStageTime = u.clock.Now()
+	u.lastEqualizeTime = u.clock.Now()
+	u.polarizationVoltage = 0
+	u.batFaults = [4]batteryFaultState{}
+	u.pins = nil
+	u.batTest = batteryTestState{}
+	if u.cycleLoadProfile != "" {
+		u.loadProfile = u.cycleLoadProfile
+		u.cycleLoadProfile = ""
+	}
+	u.setLoadProfile(u.loadProfile)
 	u.setDefaultUpsParams()
+	u.enterCycleState(u.conf.Cycle.StateIndex(u.conf.Cycle.Initial))
+	u.applyInputChange()
 	u.mu.Unlock()
 }
 
 // RecalculateParams recalculates parameters depending on the ups state
 func (u *Ups) RecalculateParams() {
 	u.mu.Lock()
-	switch u.state {
-	case chargedState:
-		if time.Since(u.cycleDoneTime) > u.conf.CycleChangeTimeout {
-			u.params.InputAcVoltage = 0
-			u.params.InputAcCurrent = 0
-			u.recalcLoadCurrent()
-			u.params.BatGroupCurrent = -u.params.LoadCurrent * 1.1
-			u.params.Alarms.UpcInBatteryMode = true
-
-			u.setState(dischargingState)
-		}
-
-	case dischargingState:
-		elapsedTimeH := float32(time.Since(u.lastUpdateTime)) / float32(time.Hour) // elapsed time in hours
-		spentCapacity := u.params.BatGroupCurrent * elapsedTimeH                   // Ah
-		u.params.RemainingBatCapacity += spentCapacity
-
-		if u.params.RemainingBatCapacity < 0 {
-			u.params.RemainingBatCapacity = 0
-			u.params.SOC = 0
-			u.cycleDoneTime = time.Now()
-			u.params.LoadCurrent = 0
-			u.params.BatGroupCurrent = 0
-			u.setState(dischargedState)
-			break
-		}
-		u.recalcSoc()
-		u.recalcBatGroupVoltage()
-		u.recalcLoadCurrent()
-		u.params.BatGroupCurrent = -u.params.LoadCurrent * 1.1
-		u.recalcInputAcCurrent()
-
-		if u.params.SOC < u.conf.LowSocTriggerAlarm {
-			u.params.Alarms.LowBattery = true
-		}
+	elapsed := u.clock.Since(u.lastUpdateTime)
+	u.recalcPolarization(elapsed)
+	u.recalcBatteryFaults(elapsed)
+	u.integrateBatCapacity(elapsed)
+	u.recalcLoadPower(elapsed)
+	u.recalcOverload(elapsed)
+	u.recalcGenerator(elapsed)
+	u.recalcBatteryTest()
+	u.recalcTransfer(false)
+	u.recalcPowerFlow()
+	if !u.cycleSuspended && u.recalcCycle() {
+		u.recalcTransfer(false)
+		u.recalcPowerFlow()
+	}
+	u.lastUpdateTime = u.clock.Now()
+	u.mu.Unlock()
+}
 
-	case dischargedState:
-		if time.Since(u.cycleDoneTime) > u.conf.CycleChangeTimeout {
-			u.params.InputAcVoltage = u.conf.DefaultInputAcVoltage
-			u.params.BatGroupCurrent = u.conf.ChargeCurrentLimit
-			u.recalcLoadCurrent()
-			u.recalcInputAcCurrent()
-			u.params.Alarms = model.Alarms{}
+// integrateBatCapacity integrates the battery current and the self-discharge over the elapsed time
+func (u *Ups) integrateBatCapacity(elapsed time.Duration) {
+	elapsedTimeH := float32(elapsed) / float32(time.Hour) // elapsed time in hours
+	u.params.RemainingBatCapacity += (u.params.BatGroupCurrent - u.selfDischargeCurrent()) * elapsedTimeH
 
-			u.setState(chargingState)
+	switch {
+	case u.params.RemainingBatCapacity <= 0:
+		u.params.RemainingBatCapacity = 0
+		if u.params.OperatingMode == model.ModeOnBattery {
+			u.shutdown()
 		}
-
-	case chargingState:
-		elapsedTimeH := float32(time.Since(u.lastUpdateTime)) / float32(time.Hour) // elapsed time in hours
-		receivedCapacity := u.params.BatGroupCurrent * elapsedTimeH                // Ah
-		u.params.RemainingBatCapacity += receivedCapacity
-
-		if u.params.RemainingBatCapacity > u.params.BatCapacity {
-			u.params.RemainingBatCapacity = u.params.BatCapacity
-			u.params.SOC = 1
-			u.cycleDoneTime = time.Now()
-			u.params.BatGroupCurrent = 0
-
-			u.setState(chargedState)
-			break
+	case u.params.RemainingBatCapacity >= u.params.BatCapacity:
+		u.params.RemainingBatCapacity = u.params.BatCapacity
+		if u.params.ChargerStage == model.ChargerBulk || u.params.ChargerStage == model.ChargerAbsorption {
+			u.setChargerStage(model.ChargerFloat)
 		}
+	}
+	u.recalcSoc()
+}
 
-		u.recalcSoc()
-		u.recalcBatGroupVoltage()
-		u.recalcLoadCurrent()
-		u.recalcChargingCurrent()
-		u.recalcInputAcCurrent()
+// recalcPowerFlow recalculates currents and voltages depending on the power state
+func (u *Ups) recalcPowerFlow() {
+	switch u.params.OperatingMode {
+	case model.ModeOnline, model.ModeBypass, model.ModeEco:
+		u.recalcCharger()
+	case model.ModeOnBattery:
+		u.recalcDischarge()
+	case model.ModeShutdown:
+		u.params.BatGroupCurrent = 0
+		u.recalcBatGroupVoltage() // relaxation after the discharge
 	}
+	if u.isStringOpen() {
+		u.applyOpenString()
+	}
+	u.recalcLoadCurrent()
+	u.recalcOutput()
+	u.params.Runtime = u.estimateRuntime()
+	u.recalcInputAcCurrent()
+	u.syncPhases()
+	u.recalcAlarms()
 	u.recalcBatValtages()
-	u.lastUpdateTime = time.Now()
-	u.mu.Unlock()
+	u.applyManualEdits()
+	u.applyPins() // over the computed values
+}
+
+func (u *Ups) recalcAlarms() {
+	u.params.Alarms.UpcInBatteryMode = u.isOnBattery()
+	u.params.Alarms.LowBattery = u.isOnBattery() && u.params.SOC < u.conf.LowSocTriggerAlarm
+	u.params.Alarms.Overload = u.isOverloaded()
+	u.params.Alarms.OnBypass = u.params.OperatingMode == model.ModeBypass
+	u.params.Alarms.BypassUnavailable = !u.isBypassAvailable()
+	u.recalcPhaseAlarms()
 }
 
 func (u *Ups) GetAllParams() (params model.UpsParams) {
 	u.mu.Lock()
 	params = u.params
+	params.Pins = u.getPins()
 	u.mu.Unlock()
 	return
 }
 
+// GetParamsWithSimulatedMeasErr returns the params as read by the sensors, see SensorConfig
 func (u *Ups) GetParamsWithSimulatedMeasErr() (params model.UpsParams) {
 	u.mu.Lock()
-	params = model.UpsParams{
-		InputAcVoltage:       utils.SimulateMeasErr(0.02, u.params.InputAcVoltage),
-		InputAcCurrent:       utils.SimulateMeasErr(0.02, u.params.InputAcCurrent),
-		BatGroupVoltage:      utils.SimulateMeasErr(0.02, u.params.BatGroupVoltage),
-		BatGroupCurrent:      utils.SimulateMeasErr(0.02, u.params.BatGroupCurrent),
-		LoadCurrent:          utils.SimulateMeasErr(0.02, u.params.LoadCurrent),
-		BatCapacity:          u.params.BatCapacity,
-		RemainingBatCapacity: u.params.RemainingBatCapacity,
-		SOC:                  u.params.SOC,
-		Alarms:               u.params.Alarms,
-	}
-	for i, bat := range u.params.Batteries {
-		params.Batteries[i].Voltage = utils.SimulateMeasErr(0.04, bat.Voltage)
-		params.Batteries[i].Temp = utils.SimulateMeasErr(0.04, bat.Temp)
-		params.Batteries[i].Resist = utils.SimulateMeasErr(0.04, bat.Resist)
-	}
+	params = u.params
+	u.measureParams(&params)
 	u.mu.Unlock()
 	return
 }
 
+// SuspendCycle stops or resumes driving the input by the auto mode cycle
+func (u *Ups) SuspendCycle(suspend bool) {
+	u.mu.Lock()
+	u.cycleSuspended = suspend
+	u.mu.Unlock()
+}
+
 func (u *Ups) UpdateParams(params model.UpsParamsUpdateForm) {
 	u.mu.Lock()
 	u.params.Update(params)
+	u.holdParams(params)
+	if !u.conf.ThreePhase.Enabled {
+		u.syncPhases()
+	}
+	if params.InputAcVoltage != nil {
+		u.setInputVoltage(*params.InputAcVoltage)
+	}
+	if params.InputAcVoltage != nil || params.InputFrequency != nil {
+		u.applyInputChange()
+	}
 	u.mu.Unlock()
 }
 
@@ -167,96 +237,104 @@ func (u *Ups) UpdateBatteryParams(bat_id int, batParams model.BatteryParamsUpdat
 		return fmt.Errorf("bat_id out of range: %d, expected less %d", bat_id, l)
 	}
 	u.params.Batteries[bat_id].Update(batParams)
+	u.holdBatteryParams(bat_id, batParams)
 	return nil
 }
 
 func (u *Ups) UpdateAlarms(alarms model.AlarmsUpdateForm) {
 	u.mu.Lock()
 	u.params.Alarms.Update(alarms)
+	u.holdAlarms(alarms)
 	u.mu.Unlock()
 }
 
 func (u *Ups) setDefaultUpsParams() {
 	u.params = model.UpsParams{
-		InputAcVoltage:       u.conf.DefaultInputAcVoltage,
-		InputAcCurrent:       u.conf.LoadPower * 1.1 / u.conf.DefaultInputAcVoltage,
-		BatGroupVoltage:      u.conf.MaxBatGroupVoltage,
 		BatGroupCurrent:      0,
-		LoadCurrent:          u.conf.LoadPower / u.conf.MaxBatGroupVoltage,
 		BatCapacity:          u.conf.DefaultBatCapacity,
 		RemainingBatCapacity: u.conf.DefaultBatCapacity,
 		SOC:                  1,
+		ChargerStage:         model.ChargerFloat,
+		Generator:            model.GeneratorParams{FuelLevel: 1},
 		Batteries: [4]model.BatteryParams{
 			{
-				Voltage: 13.5,
-				Temp:    24,
-				Resist:  5,
+				Temp:   24,
+				Resist: defaultBatResist,
 			},
 			{
-				Voltage: 13.5,
-				Temp:    24,
-				Resist:  5,
+				Temp:   24,
+				Resist: defaultBatResist,
 			},
 			{
-				Voltage: 13.5,
-				Temp:    24,
-				Resist:  5,
+				Temp:   24,
+				Resist: defaultBatResist,
 			},
 			{
-				Voltage: 13.5,
-				Temp:    24,
-				Resist:  5,
+				Temp:   24,
+				Resist: defaultBatResist,
 			},
 		},
 	}
-}
-
-func (u *Ups) setState(s chargeState) {
-	log.Printf("\nnew state: %v\n\n", s)
-	u.state = s
+	u.setMains(true)
+	u.applyInputChange()
 }
 
 func (u *Ups) recalcLoadCurrent() {
-	u.params.LoadCurrent = u.conf.LoadPower / u.params.BatGroupVoltage
+	u.params.LoadCurrent = 0
+	if u.params.BatGroupVoltage > 0 {
+		u.params.LoadCurrent = u.outputActivePower() / u.params.BatGroupVoltage
+	}
 }
 
 func (u *Ups) recalcSoc() {
 	u.params.SOC = u.params.RemainingBatCapacity / u.params.BatCapacity
 }
 
-// recalcBatGroupVoltage recalculates BatGroupVoltage depending on battery current and SOC (state of charge)
+// recalcBatGroupVoltage recalculates BatGroupVoltage of the discharging or idle battery
+// depending on SOC (state of charge) and the current. While charging the voltage is set by the charger, see recalcCharger
 func (u *Ups) recalcBatGroupVoltage() {
-	if u.params.BatGroupCurrent < 0 { // discharge
-		u.params.BatGroupVoltage = u.conf.MinBatGroupVoltage + u.params.SOC*(u.conf.MaxBatGroupVoltage-u.conf.MinBatGroupVoltage)
-	} else { //charge
-		u.params.BatGroupVoltage = u.conf.MinBatGroupVoltage + 1.25*u.params.SOC*(u.conf.MaxBatGroupVoltage-u.conf.MinBatGroupVoltage)
-	}
-	if u.params.BatGroupVoltage > u.conf.MaxBatGroupVoltage {
-		u.params.BatGroupVoltage = u.conf.MaxBatGroupVoltage
-	}
+	u.params.BatGroupVoltage = u.terminalVoltage()
 }
 
+// openCircuitVoltage returns the battery group voltage without current depending on SOC.
+// The cell voltage is taken from the OCV curve of the chemistry and scaled by the number of cells
+func (u *Ups) openCircuitVoltage() float32 {
+	return u.ocvCurve.Voltage(u.params.SOC) * float32(u.numOfCells())
+}
+
+// recalcInputAcCurrent recalculates InputAcCurrent from the apparent input power, see recalcInputPower
 func (u *Ups) recalcInputAcCurrent() {
-	if u.params.InputAcVoltage == 0 {
-		u.params.InputAcCurrent = 0
+	u.recalcInputPower()
+	apparentPower := u.params.InputApparentPower
+	if u.conf.ThreePhase.Enabled {
+		u.recalcInputPhases(apparentPower)
 		return
 	}
-	totalower := 1.1 * (u.conf.LoadPower + u.params.BatGroupVoltage*u.params.BatGroupCurrent)
-	u.params.InputAcCurrent = totalower / u.params.InputAcVoltage
-}
-
-func (u *Ups) recalcChargingCurrent() {
-	if u.params.SOC < 0.8 {
-		u.params.BatGroupCurrent = u.conf.ChargeCurrentLimit
-	} else {
-		cf := 1 - 4*(u.params.SOC-0.8)
-		u.params.BatGroupCurrent = u.conf.ChargeCurrentLimit * cf
+	u.params.InputAcCurrent = 0
+	if apparentPower > 0 {
+		u.params.InputAcCurrent = apparentPower / u.params.InputAcVoltage
 	}
 }
 
+// recalcBatValtages splits BatGroupVoltage between the batteries:
+// the rest voltage according to the number of working cells, the ohmic drop according to the resistance of each battery.
+// The open battery takes the difference between the group voltage and the open circuit voltage of the rest
 func (u *Ups) recalcBatValtages() {
-	avgBatVoltage := u.params.BatGroupVoltage / float32(len(u.params.Batteries))
-	for i := range u.params.Batteries {
-		u.params.Batteries[i].Voltage = avgBatVoltage
+	cellVoltage := (u.params.BatGroupVoltage - u.params.BatGroupCurrent*u.internalResist()) / float32(u.numOfCells())
+	if u.isStringOpen() {
+		cellVoltage = u.ocvCurve.Voltage(u.params.SOC)
+	}
+	openBat := -1
+	rest := u.params.BatGroupVoltage
+	for i, bat := range u.params.Batteries {
+		if bat.Faults.Has(model.FaultOpenCell) && openBat < 0 {
+			openBat = i
+			continue
+		}
+		u.params.Batteries[i].Voltage = cellVoltage*float32(u.blockCells(i)) + u.params.BatGroupCurrent*bat.Resist/1000
+		rest -= u.params.Batteries[i].Voltage
+	}
+	if openBat >= 0 {
+		u.params.Batteries[openBat].Voltage = rest
 	}
 }
@@ -11,21 +11,35 @@ func Test_RecalculateParams(t *testing.T) {
 	conf := model.TestConfig(t)
 	ups := New(conf)
 	ups.RecalculateParams()
-	assert.Equal(t, chargedState, ups.state)
+	assert.Equal(t, "charged", ups.cycleStateName())
 
-	ups.cycleDoneTime = ups.cycleDoneTime.Add(-conf.CycleChangeTimeout * 2)
+	ups.cycleStateTime = ups.cycleStateTime.Add(-conf.CycleChangeTimeout * 2)
 	ups.RecalculateParams()
-	assert.Equal(t, dischargingState, ups.state)
+	assert.Equal(t, "discharging", ups.cycleStateName())
 
 	ups.params.RemainingBatCapacity = -1
 	ups.RecalculateParams()
-	assert.Equal(t, dischargedState, ups.state)
+	assert.Equal(t, "discharged", ups.cycleStateName())
 
-	ups.cycleDoneTime = ups.cycleDoneTime.Add(-conf.CycleChangeTimeout * 2)
+	ups.cycleStateTime = ups.cycleStateTime.Add(-conf.CycleChangeTimeout * 2)
 	ups.RecalculateParams()
-	assert.Equal(t, chargingState, ups.state)
+	assert.Equal(t, "charging", ups.cycleStateName())
 
 	ups.params.RemainingBatCapacity = conf.DefaultBatCapacity + 1
 	ups.RecalculateParams()
-	assert.Equal(t, chargedState, ups.state)
+	assert.Equal(t, "charged", ups.cycleStateName())
+}
+
+func Test_openCircuitVoltage(t *testing.T) {
+	conf := model.TestConfig(t)
+	conf.Battery.Chemistry = model.ChemistryLiFePO4
+	conf.Battery.CellsPerBlock = 4
+	ups := New(conf)
+
+	ups.params.SOC = 0.3
+	low := ups.openCircuitVoltage()
+	ups.params.SOC = 0.7
+	high := ups.openCircuitVoltage()
+	assert.InDelta(t, 16*3.28, low, 0.001)
+	assert.Less(t, high-low, float32(1), "LiFePO4 plateau")
 }
@@ -0,0 +1,63 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// BatteryFaults is a set of faults injected into a battery, a bit per fault
+type BatteryFaults uint16
+
+const (
+	FaultShortedCell    BatteryFaults = 1 << iota // the block loses a cell and heats
+	FaultOpenCell                                 // the string is open, no current flows
+	FaultHighResistance                           // the internal resistance rises
+	FaultThermalRunaway                           // the temperature climbs
+)
+
+var batteryFaultNames = [...]string{"shorted_cell", "open_cell", "high_resistance", "thermal_runaway"}
+
+// ParseBatteryFault returns the fault by its name
+func ParseBatteryFault(name string) (BatteryFaults, error) {
+	for i, faultName := range batteryFaultNames {
+		if faultName == name {
+			return 1 << i, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown battery fault: %q", name)
+}
+
+func (f BatteryFaults) Has(fault BatteryFaults) bool {
+	return f&fault != 0
+}
+
+// Names returns the names of the faults in the set
+func (f BatteryFaults) Names() []string {
+	names := []string{}
+	for i, name := range batteryFaultNames {
+		if f.Has(1 << i) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func (f BatteryFaults) MarshalJSON() ([]byte, error) {
+	return json.Marshal(f.Names())
+}
+
+func (f *BatteryFaults) UnmarshalJSON(data []byte) error {
+	var names []string
+	if err := json.Unmarshal(data, &names); err != nil {
+		return err
+	}
+	*f = 0
+	for _, name := range names {
+		fault, err := ParseBatteryFault(name)
+		if err != nil {
+			return err
+		}
+		*f |= fault
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package model_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/alex11prog/ups-imitator/internal/app/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ParseBatteryFault(t *testing.T) {
+	fault, err := model.ParseBatteryFault("high_resistance")
+	require.NoError(t, err)
+	assert.Equal(t, model.FaultHighResistance, fault)
+	_, err = model.ParseBatteryFault("invalid")
+	assert.Error(t, err)
+}
+
+func Test_BatteryFaults_JSON(t *testing.T) {
+	faults := model.FaultShortedCell | model.FaultThermalRunaway
+	b, err := json.Marshal(faults)
+	require.NoError(t, err)
+	assert.JSONEq(t, `["shorted_cell","thermal_runaway"]`, string(b))
+	var received model.BatteryFaults
+	require.NoError(t, json.Unmarshal(b, &received))
+	assert.Equal(t, faults, received)
+
+	b, err = json.Marshal(model.BatteryFaults(0))
+	require.NoError(t, err)
+	assert.JSONEq(t, `[]`, string(b))
+	assert.Error(t, json.Unmarshal([]byte(`["invalid"]`), &received))
+}
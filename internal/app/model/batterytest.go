@@ -0,0 +1,76 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+)
+
+// BatteryTestResult is the result of the battery self-test
+type BatteryTestResult uint16
+
+const (
+	BatteryTestNone    BatteryTestResult = iota // never run
+	BatteryTestRunning                          // the load is on battery
+	BatteryTestPassed
+	BatteryTestWarning // the battery ages, SOH below WarningSoh
+	BatteryTestFailed  // SOH below FailSoh, the voltage drops too much or the UPS shut down
+	BatteryTestAborted // low SOC or the input failed during the test
+)
+
+var batteryTestResultNames = [...]string{"none", "running", "passed", "warning", "failed", "aborted"}
+
+func (r BatteryTestResult) String() string {
+	if int(r) < len(batteryTestResultNames) {
+		return batteryTestResultNames[r]
+	}
+	return fmt.Sprintf("unknown(%d)", uint16(r))
+}
+
+func (r BatteryTestResult) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+func (r *BatteryTestResult) UnmarshalText(text []byte) error {
+	for i, name := range batteryTestResultNames {
+		if name == string(text) {
+			*r = BatteryTestResult(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown battery test result: %q", text)
+}
+
+// BatteryTestParams are params of the last battery self-test
+type BatteryTestParams struct {
+	Result      BatteryTestResult `json:"result" swaggertype:"string" enums:"none,running,passed,warning,failed,aborted" example:"passed"`
+	Time        *time.Time        `json:"time,omitempty"`                     // simulated, of the start
+	VoltageDrop float32           `json:"voltage_drop" example:"1.2"`         // V, of the battery group at the end of the test
+	Resist      float32           `json:"resist" example:"20"`                // mOhm, of the battery group, measured at the transfer
+	SOH         float32           `json:"soh" example:"1"`                    // from 0 to 1, state of health
+	Reason      string            `json:"reason,omitempty" example:"low soc"` // of the failed or aborted test
+}
+
+// BatteryTestConfig describes the battery self-test: the load is transferred to battery for Duration,
+// the step of the voltage at the transfer gives the internal resistance, SOH is estimated from it and the capacity
+type BatteryTestConfig struct {
+	Duration       time.Duration `toml:"duration"`         // sec on battery
+	MinSoc         float32       `toml:"min_soc"`          // from 0 to 1, the test is aborted below
+	EolResist      float32       `toml:"eol_resist"`       // the resistance at the end of life relative to the rated one, SOH is 0 there
+	WarningSoh     float32       `toml:"warning_soh"`      // from 0 to 1
+	FailSoh        float32       `toml:"fail_soh"`         // from 0 to 1
+	MaxVoltageDrop float32       `toml:"max_voltage_drop"` // from 0 to 1, relative to the voltage before the test, failed above
+}
+
+func (conf BatteryTestConfig) Validate() error {
+	return validation.ValidateStruct(
+		&conf,
+		validation.Field(&conf.Duration, validation.Required, validation.Min(time.Second)),
+		validation.Field(&conf.MinSoc, validation.Max(float32(1))),
+		validation.Field(&conf.EolResist, validation.Required, validation.Min(float32(1.1))),
+		validation.Field(&conf.WarningSoh, validation.Required, validation.Max(float32(1))),
+		validation.Field(&conf.FailSoh, validation.Required, validation.Max(conf.WarningSoh)),
+		validation.Field(&conf.MaxVoltageDrop, validation.Required, validation.Max(float32(0.5))),
+	)
+}
@@ -0,0 +1,68 @@
+package model
+
+import (
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+)
+
+// ChaosConfig describes random mains outages: the events arrive as a Poisson process, an event is
+// an outage of the lognormal duration or a cluster of short flickers
+type ChaosConfig struct {
+	Enabled            bool          `toml:"enabled"`             // started with the imitator
+	Seed               int64         `toml:"seed"`                // the same seed reproduces the run, random if 0
+	EventRate          float64       `toml:"event_rate"`          // mean number of events per hour, not configured if 0
+	DurationMedian     time.Duration `toml:"duration_median"`     // sec, of the outage
+	DurationSigma      float64       `toml:"duration_sigma"`      // standard deviation of the log of the outage duration
+	MaxDuration        time.Duration `toml:"max_duration"`        // sec, the longer outages are cut, no limit if 0
+	FlickerProbability float64       `toml:"flicker_probability"` // from 0 to 1, of an event being a cluster of flickers
+	FlickerCount       int           `toml:"flicker_count"`       // max flickers in a cluster, at least 2
+	FlickerDuration    time.Duration `toml:"flicker_duration"`    // sec, max of a flicker
+	FlickerGap         time.Duration `toml:"flicker_gap"`         // sec, max between the flickers of a cluster
+}
+
+// IsConfigured reports whether the chaos mode can be started
+func (conf ChaosConfig) IsConfigured() bool {
+	return conf.EventRate > 0
+}
+
+func (conf ChaosConfig) Validate() error {
+	if !conf.Enabled && !conf.IsConfigured() {
+		return nil
+	}
+	flickers := conf.FlickerProbability > 0
+	return validation.ValidateStruct(
+		&conf,
+		validation.Field(&conf.EventRate, validation.Required, validation.Max(float64(3600))),
+		validation.Field(&conf.DurationMedian, validation.Required, validation.Min(time.Second)),
+		validation.Field(&conf.DurationSigma, validation.Min(float64(0)), validation.Max(float64(3))),
+		validation.Field(&conf.MaxDuration, validation.Min(time.Duration(0))),
+		validation.Field(&conf.FlickerProbability, validation.Min(float64(0)), validation.Max(float64(1))),
+		validation.Field(&conf.FlickerCount, requiredIf(flickers), validation.Min(2), validation.Max(100)),
+		validation.Field(&conf.FlickerDuration, requiredIf(flickers), validation.Min(time.Second)),
+		validation.Field(&conf.FlickerGap, requiredIf(flickers), validation.Min(time.Second)),
+	)
+}
+
+// Chaos statuses
+const (
+	ChaosIdle    = "idle" // not started
+	ChaosRunning = "running"
+	ChaosStopped = "stopped"
+)
+
+// ChaosStartForm overrides the seed of the config
+type ChaosStartForm struct {
+	Seed *int64 `json:"seed" example:"42"`
+}
+
+// ChaosProgress is the state of the chaos mode
+type ChaosProgress struct {
+	Status    string     `json:"status" enums:"idle,running,stopped" example:"running"`
+	Seed      int64      `json:"seed" example:"42"`                                   // reproduces the run
+	Elapsed   float32    `json:"elapsed" example:"86400"`                             // sec since the start
+	Outages   int        `json:"outages" example:"12"`                                // started so far
+	Flickers  int        `json:"flickers" example:"30"`                               // started so far
+	MainsOn   bool       `json:"mains_on" example:"true"`                             // as driven by the chaos mode
+	NextEvent *time.Time `json:"next_event,omitempty" example:"2024-07-01T12:00:00Z"` // simulated time of the next change of the mains
+}
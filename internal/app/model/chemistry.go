@@ -0,0 +1,99 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/alex11prog/ups-imitator/internal/app/utils"
+)
+
+// Battery chemistries with built-in OCV–SOC curves
+const (
+	ChemistryLinear  = "linear" // straight line between MinBatGroupVoltage and MaxBatGroupVoltage
+	ChemistryVRLA    = "vrla"
+	ChemistryFlooded = "flooded"
+	ChemistryLiFePO4 = "lifepo4"
+	ChemistryNMC     = "nmc"
+)
+
+// OcvPoint is a point of the open circuit voltage curve
+type OcvPoint struct {
+	SOC     float32 `toml:"soc"`     // from 0 to 1
+	Voltage float32 `toml:"voltage"` // V per cell
+}
+
+// OcvCurve is a table of open circuit voltage of a cell depending on SOC, sorted by SOC
+type OcvCurve []OcvPoint
+
+var builtinOcvCurves = map[string]OcvCurve{
+	ChemistryVRLA: {
+		{0, 1.94}, {0.1, 1.97}, {0.2, 2.00}, {0.3, 2.02}, {0.4, 2.04}, {0.5, 2.06},
+		{0.6, 2.08}, {0.7, 2.10}, {0.8, 2.12}, {0.9, 2.13}, {1, 2.14},
+	},
+	ChemistryFlooded: {
+		{0, 1.75}, {0.1, 1.885}, {0.2, 1.93}, {0.3, 1.958}, {0.4, 1.983}, {0.5, 2.01},
+		{0.6, 2.033}, {0.7, 2.053}, {0.8, 2.07}, {0.9, 2.083}, {1, 2.117},
+	},
+	ChemistryLiFePO4: {
+		{0, 2.5}, {0.05, 3.0}, {0.1, 3.2}, {0.2, 3.25}, {0.3, 3.28}, {0.4, 3.29}, {0.5, 3.3},
+		{0.6, 3.31}, {0.7, 3.32}, {0.8, 3.33}, {0.9, 3.35}, {0.95, 3.37}, {1, 3.4},
+	},
+	ChemistryNMC: {
+		{0, 3.0}, {0.05, 3.3}, {0.1, 3.45}, {0.2, 3.55}, {0.3, 3.62}, {0.4, 3.68}, {0.5, 3.74},
+		{0.6, 3.82}, {0.7, 3.9}, {0.8, 3.98}, {0.9, 4.07}, {1, 4.18},
+	},
+}
+
+// chemistryParams are the defaults of a chemistry at 25 °C
+type chemistryParams struct {
+	selfDischargeRate float32 // from 0 to 1 per month
+	floatCurrent      float32 // mA per Ah, held at the float voltage
+}
+
+var builtinChemistryParams = map[string]chemistryParams{
+	ChemistryLinear:  {selfDischargeRate: 0.03, floatCurrent: 1},
+	ChemistryVRLA:    {selfDischargeRate: 0.03, floatCurrent: 1},
+	ChemistryFlooded: {selfDischargeRate: 0.05, floatCurrent: 2},
+	ChemistryLiFePO4: {selfDischargeRate: 0.02, floatCurrent: 0.1},
+	ChemistryNMC:     {selfDischargeRate: 0.02, floatCurrent: 0.1},
+}
+
+// BuiltinOcvCurve returns the built-in curve of the chemistry
+func BuiltinOcvCurve(chemistry string) (OcvCurve, bool) {
+	curve, ok := builtinOcvCurves[chemistry]
+	return curve, ok
+}
+
+// Voltage returns the cell voltage for the given SOC using linear interpolation between the points
+func (c OcvCurve) Voltage(soc float32) float32 {
+	if soc <= c[0].SOC {
+		return c[0].Voltage
+	}
+	for i := 1; i < len(c); i++ {
+		if soc <= c[i].SOC {
+			return utils.LinearInterpolate(c[i-1].SOC, c[i-1].Voltage, c[i].SOC, c[i].Voltage, soc)
+		}
+	}
+	return c[len(c)-1].Voltage
+}
+
+func (c OcvCurve) Validate() error {
+	if len(c) < 2 {
+		return errors.New("at least 2 points required")
+	}
+	if c[0].SOC != 0 || c[len(c)-1].SOC != 1 {
+		return errors.New("the curve must cover SOC from 0 to 1")
+	}
+	for i := 1; i < len(c); i++ {
+		if c[i].SOC <= c[i-1].SOC {
+			return fmt.Errorf("point %d: SOC must be increasing", i)
+		}
+		if c[i].Voltage < c[i-1].Voltage {
+			return fmt.Errorf("point %d: voltage must not decrease", i)
+		}
+	}
+	if c[0].Voltage <= 0 {
+		return errors.New("voltage must be positive")
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/alex11prog/ups-imitator/internal/app/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_OcvCurve_Voltage(t *testing.T) {
+	curve := model.OcvCurve{{SOC: 0, Voltage: 2}, {SOC: 0.5, Voltage: 3}, {SOC: 1, Voltage: 4}}
+	testCases := []struct {
+		name     string
+		soc      float32
+		expected float32
+	}{
+		{"below range", -0.1, 2},
+		{"first point", 0, 2},
+		{"interpolated", 0.25, 2.5},
+		{"middle point", 0.5, 3},
+		{"interpolated, second segment", 0.75, 3.5},
+		{"above range", 1.1, 4},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.InDelta(t, tc.expected, curve.Voltage(tc.soc), 0.0001)
+		})
+	}
+}
+
+func Test_OcvCurve_Validate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		curve   model.OcvCurve
+		isValid bool
+	}{
+		{"valid", model.OcvCurve{{SOC: 0, Voltage: 2}, {SOC: 1, Voltage: 2.2}}, true},
+		{"one point", model.OcvCurve{{SOC: 0, Voltage: 2}}, false},
+		{"not from 0", model.OcvCurve{{SOC: 0.1, Voltage: 2}, {SOC: 1, Voltage: 2.2}}, false},
+		{"not to 1", model.OcvCurve{{SOC: 0, Voltage: 2}, {SOC: 0.9, Voltage: 2.2}}, false},
+		{"unsorted", model.OcvCurve{{SOC: 0, Voltage: 2}, {SOC: 0.6, Voltage: 2.1}, {SOC: 0.5, Voltage: 2.1}, {SOC: 1, Voltage: 2.2}}, false},
+		{"decreasing voltage", model.OcvCurve{{SOC: 0, Voltage: 2}, {SOC: 0.5, Voltage: 1.9}, {SOC: 1, Voltage: 2.2}}, false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.isValid {
+				assert.NoError(t, tc.curve.Validate())
+			} else {
+				assert.Error(t, tc.curve.Validate())
+			}
+		})
+	}
+}
+
+func Test_BuiltinOcvCurve(t *testing.T) {
+	for _, chemistry := range []string{model.ChemistryVRLA, model.ChemistryFlooded, model.ChemistryLiFePO4, model.ChemistryNMC} {
+		t.Run(chemistry, func(t *testing.T) {
+			curve, ok := model.BuiltinOcvCurve(chemistry)
+			require.True(t, ok)
+			assert.NoError(t, curve.Validate())
+		})
+	}
+	_, ok := model.BuiltinOcvCurve(model.ChemistryLinear)
+	assert.False(t, ok)
+}
+
+func Test_Config_OcvCurve(t *testing.T) {
+	conf := model.TestConfig(t)
+	conf.Battery.Chemistry = model.ChemistryLinear
+	curve := conf.OcvCurve(4)
+	cells := float32(conf.Battery.CellsPerBlock * 4)
+	assert.InDelta(t, conf.MinBatGroupVoltage, curve.Voltage(0)*cells, 0.001)
+	assert.InDelta(t, conf.MaxBatGroupVoltage, curve.Voltage(1)*cells, 0.001)
+
+	conf.Battery.Chemistry = model.ChemistryLiFePO4
+	builtin, _ := model.BuiltinOcvCurve(model.ChemistryLiFePO4)
+	assert.Equal(t, builtin, conf.OcvCurve(4))
+
+	conf.Battery.OcvCurve = model.OcvCurve{{SOC: 0, Voltage: 3}, {SOC: 1, Voltage: 3.4}}
+	assert.Equal(t, conf.Battery.OcvCurve, conf.OcvCurve(4))
+}
+
+func Test_BatteryConfig_SelfDischarge(t *testing.T) {
+	conf := model.TestConfig(t).Battery
+	conf.Chemistry = model.ChemistryFlooded
+	flooded := conf.MonthlySelfDischargeRate()
+	conf.Chemistry = model.ChemistryLiFePO4
+	assert.Less(t, conf.MonthlySelfDischargeRate(), flooded)
+	assert.Greater(t, conf.FloatCurrentPerAh(), float32(0))
+
+	conf.SelfDischargeRate = 0.1
+	conf.FloatCurrent = 3
+	assert.Equal(t, float32(0.1), conf.MonthlySelfDischargeRate())
+	assert.Equal(t, float32(3), conf.FloatCurrentPerAh())
+}
@@ -0,0 +1,10 @@
+package model
+
+import "time"
+
+// ClockStatus is the state of the simulated time
+type ClockStatus struct {
+	Time   time.Time `json:"time" example:"2024-07-01T12:00:00Z"`
+	Speed  float64   `json:"speed" example:"60"` // the simulated time runs faster than the wall clock
+	Paused bool      `json:"paused" example:"false"`
+}
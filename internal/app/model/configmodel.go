@@ -1,7 +1,9 @@
 package model
 
 import (
+	"errors"
 	"fmt"
+	"path/filepath"
 	"time"
 
 	"github.com/BurntSushi/toml"
@@ -11,17 +13,371 @@ import (
 type Config struct {
 	UpsAddr         string        `toml:"ups_addr"`
 	RestApiBindAddr string        `toml:"rest_api_bind_addr"`
-	UpsSyncInterval time.Duration `toml:"ups_sync_interval"` // sec
+	UpsSyncInterval time.Duration `toml:"ups_sync_interval"` // sec of the simulated time
+	ClockSpeed      float64       `toml:"clock_speed"`       // the simulated time runs faster than the wall clock, 1 if not set
+	ManualSyncExact bool          `toml:"manual_sync_exact"` // in manual mode the params are sent without the sensor errors
 
-	CycleChangeTimeout time.Duration `toml:"cycle_change_timeout"` // charge or discharge (sec)
+	CycleChangeTimeout time.Duration `toml:"cycle_change_timeout"` // charge or discharge (sec), of the default cycle
+	Cycle              CycleConfig   `toml:"cycle"`                // auto mode state machine, DefaultCycle if no states
 
 	DefaultInputAcVoltage float32 `toml:"default_input_ac_voltage"` // V
-	MaxBatGroupVoltage    float32 `toml:"max_bat_group_voltage"`    // V
-	MinBatGroupVoltage    float32 `toml:"min_bat_group_voltage"`    // V
+	MaxBatGroupVoltage    float32 `toml:"max_bat_group_voltage"`    // V, used by the linear chemistry
+	MinBatGroupVoltage    float32 `toml:"min_bat_group_voltage"`    // V, used by the linear chemistry
 	LoadPower             float32 `toml:"load_power"`               // W
 	DefaultBatCapacity    float32 `toml:"default_bat_capacity"`     // Ah
 	ChargeCurrentLimit    float32 `toml:"charge_current_limit"`     // A
 	LowSocTriggerAlarm    float32 `toml:"low_soc_trigger_alarm"`    // percent
+
+	Battery       BatteryConfig       `toml:"battery"`
+	BatteryFaults BatteryFaultsConfig `toml:"battery_faults"`
+	Charger       ChargerConfig       `toml:"charger"`
+	Output        OutputConfig        `toml:"output"`
+	Input         InputConfig         `toml:"input"`
+	Bypass        BypassConfig        `toml:"bypass"`
+	Load          LoadConfig          `toml:"load"`
+	Overload      OverloadConfig      `toml:"overload"`
+	Generator     GeneratorConfig     `toml:"generator"`
+	BatteryTest   BatteryTestConfig   `toml:"battery_test"`
+
+	ThreePhase ThreePhaseConfig `toml:"three_phase"`
+	Sensors    SensorsConfig    `toml:"sensors"`
+	Replay     ReplayConfig     `toml:"replay"`
+	Chaos      ChaosConfig      `toml:"chaos"`
+	Schedule   ScheduleConfig   `toml:"schedule"`
+}
+
+// InputConfig describes the input power quality windows and the transfer to battery
+type InputConfig struct {
+	Frequency       float32       `toml:"frequency"`        // Hz, nominal
+	VoltageLow      float32       `toml:"voltage_low"`      // V, input voltage window
+	VoltageHigh     float32       `toml:"voltage_high"`     // V
+	SagVoltage      float32       `toml:"sag_voltage"`      // V, immediate transfer below
+	SwellVoltage    float32       `toml:"swell_voltage"`    // V, immediate transfer above
+	FrequencyLow    float32       `toml:"frequency_low"`    // Hz, input frequency window
+	FrequencyHigh   float32       `toml:"frequency_high"`   // Hz
+	TransferDelay   time.Duration `toml:"transfer_delay"`   // sec, out of the windows longer -> battery
+	RetransferDelay time.Duration `toml:"retransfer_delay"` // sec, within the windows longer -> input
+}
+
+func (conf InputConfig) Validate() error {
+	return validation.ValidateStruct(
+		&conf,
+		validation.Field(&conf.Frequency, validation.Required, validation.In(float32(50), float32(60))),
+		validation.Field(&conf.VoltageLow, validation.Required, validation.Min(conf.SagVoltage)),
+		validation.Field(&conf.VoltageHigh, validation.Required, validation.Min(conf.VoltageLow), validation.Max(conf.SwellVoltage)),
+		validation.Field(&conf.SagVoltage, validation.Required),
+		validation.Field(&conf.SwellVoltage, validation.Required),
+		validation.Field(&conf.FrequencyLow, validation.Required, validation.Max(conf.Frequency)),
+		validation.Field(&conf.FrequencyHigh, validation.Required, validation.Min(conf.Frequency)),
+		validation.Field(&conf.TransferDelay, validation.Min(time.Duration(0))),
+		validation.Field(&conf.RetransferDelay, validation.Min(time.Duration(0))),
+	)
+}
+
+// BypassConfig describes the static bypass and ECO mode
+type BypassConfig struct {
+	VoltageLow         float32 `toml:"voltage_low"`         // V, bypass input window
+	VoltageHigh        float32 `toml:"voltage_high"`        // V
+	FrequencyTolerance float32 `toml:"frequency_tolerance"` // Hz, max deviation from the nominal frequency to stay synchronized
+	EcoMode            bool    `toml:"eco_mode"`            // feed the load through the bypass while it is available
+}
+
+func (conf BypassConfig) Validate() error {
+	return validation.ValidateStruct(
+		&conf,
+		validation.Field(&conf.VoltageLow, validation.Required),
+		validation.Field(&conf.VoltageHigh, validation.Required, validation.Min(conf.VoltageLow)),
+		validation.Field(&conf.FrequencyTolerance, validation.Required),
+	)
+}
+
+// LoadConfig describes the load profiles, the active one is selected by Profile
+type LoadConfig struct {
+	Profile        string         `toml:"profile"`          // constant, daily, steps, random_walk, csv
+	DailyCurve     DailyLoadCurve `toml:"daily_curve"`      // daily profile
+	Steps          LoadSchedule   `toml:"steps"`            // steps profile
+	RandomWalkStep float32        `toml:"random_walk_step"` // W per minute, standard deviation of the random walk
+	RandomWalkMin  float32        `toml:"random_walk_min"`  // W
+	RandomWalkMax  float32        `toml:"random_walk_max"`  // W
+	CsvFile        string         `toml:"csv_file"`         // csv profile, relative to the config file, rows: time (sec), power (W)
+
+	Series LoadSeries `toml:"-"` // read from CsvFile
+}
+
+func (conf LoadConfig) Validate() error {
+	randomWalk := conf.Profile == LoadProfileRandomWalk || conf.RandomWalkStep > 0
+	return validation.ValidateStruct(
+		&conf,
+		validation.Field(&conf.Profile, validation.Required, validation.In(
+			LoadProfileConstant, LoadProfileDaily, LoadProfileSteps, LoadProfileRandomWalk, LoadProfileCsv,
+		), validation.By(func(any) error {
+			return conf.CheckProfile(conf.Profile)
+		})),
+		validation.Field(&conf.DailyCurve, requiredIf(len(conf.DailyCurve) > 0)),
+		validation.Field(&conf.Steps, requiredIf(len(conf.Steps) > 0)),
+		validation.Field(&conf.RandomWalkStep, requiredIf(randomWalk)),
+		validation.Field(&conf.RandomWalkMin, requiredIf(randomWalk)),
+		validation.Field(&conf.RandomWalkMax, requiredIf(randomWalk), validation.Min(conf.RandomWalkMin)),
+	)
+}
+
+// CheckProfile returns an error if the load profile is unknown or not configured
+func (conf *LoadConfig) CheckProfile(profile string) error {
+	var configured bool
+	switch profile {
+	case LoadProfileConstant:
+		configured = true
+	case LoadProfileDaily:
+		configured = len(conf.DailyCurve) > 0
+	case LoadProfileSteps:
+		configured = len(conf.Steps) > 0
+	case LoadProfileRandomWalk:
+		configured = conf.RandomWalkStep > 0
+	case LoadProfileCsv:
+		configured = len(conf.Series) > 0
+	default:
+		return fmt.Errorf("unknown load profile: %q", profile)
+	}
+	if !configured {
+		return fmt.Errorf("load profile %q is not configured", profile)
+	}
+	return nil
+}
+
+// OverloadConfig describes the overload protection of the inverter
+type OverloadConfig struct {
+	TripCurve TripCurve     `toml:"trip_curve"` // empty - the overload never trips
+	Action    string        `toml:"action"`     // bypass, shutdown
+	Cooldown  time.Duration `toml:"cooldown"`   // sec, the accumulated overload decays from the trip level to zero within
+}
+
+func (conf OverloadConfig) Validate() error {
+	return validation.ValidateStruct(
+		&conf,
+		validation.Field(&conf.TripCurve, requiredIf(len(conf.TripCurve) > 0)),
+		validation.Field(&conf.Action, validation.Required, validation.In(OverloadActionBypass, OverloadActionShutdown)),
+		validation.Field(&conf.Cooldown, validation.Required, validation.Min(time.Second)),
+	)
+}
+
+// GeneratorConfig describes the standby generator started by the automatic transfer switch on a mains outage
+type GeneratorConfig struct {
+	Enabled                 bool          `toml:"enabled"`
+	StartDelay              time.Duration `toml:"start_delay"`               // sec, from the outage or the previous attempt to the start
+	StartAttempts           int           `toml:"start_attempts"`            // the generator fails after
+	StartFailureProbability float32       `toml:"start_failure_probability"` // from 0 to 1, of each attempt
+	WarmUp                  time.Duration `toml:"warm_up"`                   // sec, before the transfer of the load
+	RetransferDelay         time.Duration `toml:"retransfer_delay"`          // sec, the mains must be back before the transfer to it
+	FuelTank                float32       `toml:"fuel_tank"`                 // L
+	FuelConsumption         float32       `toml:"fuel_consumption"`          // L per kWh
+	IdleFuelConsumption     float32       `toml:"idle_fuel_consumption"`     // L per hour
+	FrequencyDeviation      float32       `toml:"frequency_deviation"`       // Hz, max random deviation from the nominal frequency
+}
+
+func (conf GeneratorConfig) Validate() error {
+	if !conf.Enabled {
+		return nil
+	}
+	return validation.ValidateStruct(
+		&conf,
+		validation.Field(&conf.StartAttempts, validation.Required),
+		validation.Field(&conf.StartFailureProbability, validation.Min(float32(0)), validation.Max(float32(1))),
+		validation.Field(&conf.FuelTank, validation.Required),
+		validation.Field(&conf.FuelConsumption, validation.Min(float32(0))),
+		validation.Field(&conf.IdleFuelConsumption, validation.Min(float32(0))),
+		validation.Field(&conf.FrequencyDeviation, validation.Min(float32(0)), validation.Max(float32(5))),
+	)
+}
+
+// ThreePhaseConfig describes the three-phase mode of the UPS
+type ThreePhaseConfig struct {
+	Enabled               bool       `toml:"enabled"`
+	LoadDistribution      [3]float32 `toml:"load_distribution"`       // share of the load on L1, L2, L3, sum is 1
+	PhaseLossVoltage      float32    `toml:"phase_loss_voltage"`      // V, the phase is lost below
+	VoltageImbalanceAlarm float32    `toml:"voltage_imbalance_alarm"` // percent, max deviation of the input voltage from the average
+	CurrentImbalanceAlarm float32    `toml:"current_imbalance_alarm"` // percent, max deviation of the output current from the average
+}
+
+func (conf ThreePhaseConfig) Validate() error {
+	if !conf.Enabled {
+		return nil
+	}
+	return validation.ValidateStruct(
+		&conf,
+		validation.Field(&conf.LoadDistribution, validation.By(func(value interface{}) error {
+			var sum float32
+			for _, share := range value.([3]float32) {
+				if share < 0 {
+					return errors.New("must not be negative")
+				}
+				sum += share
+			}
+			if sum < 0.99 || sum > 1.01 {
+				return errors.New("sum must be 1")
+			}
+			return nil
+		})),
+		validation.Field(&conf.PhaseLossVoltage, validation.Required, validation.Max(float32(300))),
+		validation.Field(&conf.VoltageImbalanceAlarm, validation.Required, validation.Max(float32(100))),
+		validation.Field(&conf.CurrentImbalanceAlarm, validation.Required, validation.Max(float32(100))),
+	)
+}
+
+// OutputConfig describes the output (inverter) side of the UPS
+type OutputConfig struct {
+	Voltage             float32         `toml:"voltage"`              // V
+	Frequency           float32         `toml:"frequency"`            // Hz
+	RatedApparentPower  float32         `toml:"rated_apparent_power"` // VA
+	RatedActivePower    float32         `toml:"rated_active_power"`   // W
+	LoadPowerFactor     float32         `toml:"load_power_factor"`    // from 0 to 1
+	RectifierEfficiency float32         `toml:"rectifier_efficiency"` // from 0 to 1
+	EfficiencyCurve     EfficiencyCurve `toml:"efficiency_curve"`     // optional, inverter efficiency depending on load fraction
+
+	Rectifier            string  `toml:"rectifier"`              // igbt, six_pulse, twelve_pulse
+	RectifierPowerFactor float32 `toml:"rectifier_power_factor"` // from 0 to 1, displacement factor, 0 - rectifier default
+	RectifierThd         float32 `toml:"rectifier_thd"`          // percent, input current THD at the rated load, 0 - rectifier default
+}
+
+// RectifierDisplacementFactor returns cos φ of the fundamental input current of the rectifier
+func (conf OutputConfig) RectifierDisplacementFactor() float32 {
+	if conf.RectifierPowerFactor > 0 {
+		return conf.RectifierPowerFactor
+	}
+	return builtinRectifierParams[conf.Rectifier].displacementFactor
+}
+
+// RectifierRatedThd returns the input current THD of the rectifier at the rated load, percent
+func (conf OutputConfig) RectifierRatedThd() float32 {
+	if conf.RectifierThd > 0 {
+		return conf.RectifierThd
+	}
+	return builtinRectifierParams[conf.Rectifier].thd
+}
+
+func (conf OutputConfig) Validate() error {
+	return validation.ValidateStruct(
+		&conf,
+		validation.Field(&conf.Voltage, validation.Required, validation.Min(float32(100)), validation.Max(float32(400))),
+		validation.Field(&conf.Frequency, validation.Required, validation.In(float32(50), float32(60))),
+		validation.Field(&conf.RatedApparentPower, validation.Required, validation.Min(float32(100))),
+		validation.Field(&conf.RatedActivePower, validation.Required, validation.Min(float32(100)), validation.Max(conf.RatedApparentPower)),
+		validation.Field(&conf.LoadPowerFactor, validation.Required, validation.Min(float32(0.3)), validation.Max(float32(1))),
+		validation.Field(&conf.RectifierEfficiency, validation.Required, validation.Min(float32(0.5)), validation.Max(float32(1))),
+		validation.Field(&conf.EfficiencyCurve, requiredIf(len(conf.EfficiencyCurve) > 0)),
+		validation.Field(&conf.Rectifier, validation.Required, validation.In(RectifierIgbt, RectifierSixPulse, RectifierTwelvePulse)),
+		validation.Field(&conf.RectifierPowerFactor, validation.Min(float32(0)), validation.Max(float32(1))),
+		validation.Field(&conf.RectifierThd, validation.Min(float32(0)), validation.Max(float32(100))),
+	)
+}
+
+// InverterEfficiencyCurve returns the efficiency curve from the config or the default one
+func (conf *OutputConfig) InverterEfficiencyCurve() EfficiencyCurve {
+	if len(conf.EfficiencyCurve) > 0 {
+		return conf.EfficiencyCurve
+	}
+	return DefaultEfficiencyCurve
+}
+
+// BatteryConfig describes the chemistry of the battery group
+type BatteryConfig struct {
+	Chemistry     string   `toml:"chemistry"`       // linear, vrla, flooded, lifepo4, nmc
+	CellsPerBlock int      `toml:"cells_per_block"` // cells in each of the battery blocks
+	OcvCurve      OcvCurve `toml:"ocv_curve"`       // optional, V per cell, replaces the built-in curve of the chemistry
+
+	RcResist       float32       `toml:"rc_resist"`        // Ohm, resistance of the RC polarization element of the group
+	RcTimeConstant time.Duration `toml:"rc_time_constant"` // sec, 0 - RC polarization element disabled
+
+	RuntimeError float32 `toml:"runtime_error"` // from 0 to 1, simulated error of the runtime estimate, 0 - exact
+
+	SelfDischargeRate float32 `toml:"self_discharge_rate"` // from 0 to 1 per month at 25 °C, 0 - chemistry default
+	FloatCurrent      float32 `toml:"float_current"`       // mA per Ah at the float voltage and 25 °C, 0 - chemistry default
+}
+
+// MonthlySelfDischargeRate returns the self-discharge rate at 25 °C, from 0 to 1 per month
+func (conf BatteryConfig) MonthlySelfDischargeRate() float32 {
+	if conf.SelfDischargeRate > 0 {
+		return conf.SelfDischargeRate
+	}
+	return builtinChemistryParams[conf.Chemistry].selfDischargeRate
+}
+
+// FloatCurrentPerAh returns the current drawn by the charged battery at the float voltage and 25 °C, mA per Ah
+func (conf BatteryConfig) FloatCurrentPerAh() float32 {
+	if conf.FloatCurrent > 0 {
+		return conf.FloatCurrent
+	}
+	return builtinChemistryParams[conf.Chemistry].floatCurrent
+}
+
+func (conf BatteryConfig) Validate() error {
+	return validation.ValidateStruct(
+		&conf,
+		validation.Field(&conf.Chemistry, validation.Required, validation.In(
+			ChemistryLinear, ChemistryVRLA, ChemistryFlooded, ChemistryLiFePO4, ChemistryNMC,
+		)),
+		validation.Field(&conf.CellsPerBlock, validation.Required, validation.Min(1), validation.Max(32)),
+		validation.Field(&conf.OcvCurve, requiredIf(len(conf.OcvCurve) > 0)),
+		validation.Field(&conf.RcResist, validation.Min(float32(0)), validation.Max(float32(1))),
+		validation.Field(&conf.RcTimeConstant, validation.Min(time.Duration(0))),
+		validation.Field(&conf.RuntimeError, validation.Min(float32(0)), validation.Max(float32(1))),
+		validation.Field(&conf.SelfDischargeRate, validation.Min(float32(0)), validation.Max(float32(1))),
+		validation.Field(&conf.FloatCurrent, validation.Min(float32(0)), validation.Max(float32(100))),
+	)
+}
+
+// BatteryFaultsConfig describes the evolution of the faults injected into the batteries
+type BatteryFaultsConfig struct {
+	ShortedCellHeat    float32       `toml:"shorted_cell_heat"`    // °C, a block with a shorted cell heats above the others
+	HeatTimeConstant   time.Duration `toml:"heat_time_constant"`   // sec
+	ResistanceGrowth   float32       `toml:"resistance_growth"`    // mOhm per hour
+	ThermalRunawayRate float32       `toml:"thermal_runaway_rate"` // °C per minute at the start, doubles every 10 °C
+}
+
+func (conf BatteryFaultsConfig) Validate() error {
+	return validation.ValidateStruct(
+		&conf,
+		validation.Field(&conf.ShortedCellHeat, validation.Required, validation.Max(float32(100))),
+		validation.Field(&conf.HeatTimeConstant, validation.Required, validation.Min(time.Second)),
+		validation.Field(&conf.ResistanceGrowth, validation.Required),
+		validation.Field(&conf.ThermalRunawayRate, validation.Required),
+	)
+}
+
+// ChargerConfig describes set-points of the multi-stage CC/CV charger.
+// Voltages are given for the whole battery group at the reference temperature (25 °C)
+type ChargerConfig struct {
+	AbsorptionVoltage     float32       `toml:"absorption_voltage"`      // V
+	FloatVoltage          float32       `toml:"float_voltage"`           // V
+	FloatCurrentThreshold float32       `toml:"float_current_threshold"` // A, absorption -> float transition
+	EqualizeVoltage       float32       `toml:"equalize_voltage"`        // V
+	EqualizeInterval      time.Duration `toml:"equalize_interval"`       // sec, 0 - equalize disabled
+	EqualizeDuration      time.Duration `toml:"equalize_duration"`       // sec
+	TempCompensation      float32       `toml:"temp_compensation"`       // V/°C, usually negative
+	PolarizationResist    float32       `toml:"polarization_resist"`     // Ohm, charge acceptance of the battery group
+	Disabled              bool          `toml:"disabled"`                // the battery is not charged, e.g. a long-stored UPS
+}
+
+func (conf ChargerConfig) Validate() error {
+	equalizeEnabled := conf.EqualizeInterval > 0
+	return validation.ValidateStruct(
+		&conf,
+		validation.Field(&conf.AbsorptionVoltage, validation.Required, validation.Min(float32(12)), validation.Max(float32(120))),
+		validation.Field(&conf.FloatVoltage, validation.Required, validation.Min(float32(12)), validation.Max(conf.AbsorptionVoltage)),
+		validation.Field(&conf.FloatCurrentThreshold, validation.Required, validation.Min(float32(0.01))),
+		validation.Field(&conf.EqualizeVoltage, requiredIf(equalizeEnabled), validation.Min(conf.AbsorptionVoltage)),
+		validation.Field(&conf.EqualizeInterval, validation.Min(conf.EqualizeDuration)),
+		validation.Field(&conf.EqualizeDuration, requiredIf(equalizeEnabled), validation.Min(time.Second)),
+		validation.Field(&conf.TempCompensation, validation.Max(float32(0))),
+		validation.Field(&conf.PolarizationResist, validation.Required, validation.Max(float32(1))),
+	)
+}
+
+// requiredIf returns the Required rule if cond is true, otherwise skips the remaining rules
+func requiredIf(cond bool) validation.Rule {
+	if cond {
+		return validation.Required
+	}
+	return validation.Skip
 }
 
 func (conf *Config) validate() error {
@@ -30,7 +386,9 @@ func (conf *Config) validate() error {
 		validation.Field(&conf.UpsAddr, validation.Required),
 		validation.Field(&conf.RestApiBindAddr, validation.Required),
 		validation.Field(&conf.UpsSyncInterval, validation.Required, validation.Min(time.Second)),
+		validation.Field(&conf.ClockSpeed, validation.Required, validation.Min(float64(1)), validation.Max(float64(3600))),
 		validation.Field(&conf.CycleChangeTimeout, validation.Required, validation.Min(time.Second)),
+		validation.Field(&conf.Cycle),
 		validation.Field(&conf.DefaultInputAcVoltage, validation.Required, validation.Min(float32(150)), validation.Max(float32(300))),
 		validation.Field(&conf.MaxBatGroupVoltage, validation.Required, validation.Min(float32(52)), validation.Max(float32(100))),
 		validation.Field(&conf.MinBatGroupVoltage, validation.Required, validation.Min(float32(12)), validation.Max(float32(50))),
@@ -38,17 +396,112 @@ func (conf *Config) validate() error {
 		validation.Field(&conf.DefaultBatCapacity, validation.Required, validation.Min(float32(10)), validation.Max(float32(1000))),
 		validation.Field(&conf.ChargeCurrentLimit, validation.Required, validation.Min(float32(10)), validation.Max(float32(500))),
 		validation.Field(&conf.LowSocTriggerAlarm, validation.Required, validation.Max(float32(0.5))),
+		validation.Field(&conf.Battery),
+		validation.Field(&conf.BatteryFaults),
+		validation.Field(&conf.Charger, validation.By(func(any) error {
+			if conf.Charger.Disabled && conf.Cycle.HasEvent(CycleEventCharged) {
+				return errors.New("the disabled charger never reaches the charged event of the cycle")
+			}
+			return nil
+		})),
+		validation.Field(&conf.Output),
+		validation.Field(&conf.Input),
+		validation.Field(&conf.Bypass),
+		validation.Field(&conf.Load),
+		validation.Field(&conf.Overload),
+		validation.Field(&conf.Generator),
+		validation.Field(&conf.BatteryTest),
+		validation.Field(&conf.DefaultInputAcVoltage, validation.Min(conf.Input.VoltageLow), validation.Max(conf.Input.VoltageHigh)),
+		validation.Field(&conf.ThreePhase),
+		validation.Field(&conf.Sensors),
+		validation.Field(&conf.Replay),
+		validation.Field(&conf.Chaos),
+		validation.Field(&conf.Schedule),
 	)
 }
 
-func NewConfig(configPath string) (*Config, error){
+// SyncTickInterval returns the wall clock interval of the sync with the UPS,
+// UpsSyncInterval of the simulated time passes between the syncs
+func (conf *Config) SyncTickInterval() time.Duration {
+	return time.Duration(float64(conf.UpsSyncInterval) / conf.ClockSpeed)
+}
+
+// OcvCurve returns the open circuit voltage curve of a cell:
+// the curve from the config, the built-in curve of the chemistry or
+// the straight line between MinBatGroupVoltage and MaxBatGroupVoltage
+func (conf *Config) OcvCurve(numOfBlocks int) OcvCurve {
+	if len(conf.Battery.OcvCurve) > 0 {
+		return conf.Battery.OcvCurve
+	}
+	if curve, ok := BuiltinOcvCurve(conf.Battery.Chemistry); ok {
+		return curve
+	}
+	cells := float32(conf.Battery.CellsPerBlock * numOfBlocks)
+	return OcvCurve{
+		{SOC: 0, Voltage: conf.MinBatGroupVoltage / cells},
+		{SOC: 1, Voltage: conf.MaxBatGroupVoltage / cells},
+	}
+}
+
+func NewConfig(configPath string) (*Config, error) {
 	conf := &Config{}
-	_, err := toml.DecodeFile(configPath, conf)
+	md, err := toml.DecodeFile(configPath, conf)
 	if err != nil {
 		return nil, fmt.Errorf("toml decode file config error: %v", err)
 	}
 	conf.UpsSyncInterval *= time.Second
+	if conf.ClockSpeed == 0 {
+		conf.ClockSpeed = 1
+	}
 	conf.CycleChangeTimeout *= time.Second
+	for i := range conf.Cycle.States {
+		for j := range conf.Cycle.States[i].Transitions {
+			conf.Cycle.States[i].Transitions[j].After *= time.Second
+		}
+	}
+	if len(conf.Cycle.States) == 0 {
+		conf.Cycle = DefaultCycle(conf.CycleChangeTimeout)
+	}
+	conf.Battery.RcTimeConstant *= time.Second
+	conf.BatteryFaults.HeatTimeConstant *= time.Second
+	conf.Input.TransferDelay *= time.Second
+	conf.Input.RetransferDelay *= time.Second
+	conf.Charger.EqualizeInterval *= time.Second
+	conf.Charger.EqualizeDuration *= time.Second
+	conf.Overload.Cooldown *= time.Second
+	conf.Generator.StartDelay *= time.Second
+	conf.Generator.WarmUp *= time.Second
+	conf.Generator.RetransferDelay *= time.Second
+	conf.BatteryTest.Duration *= time.Second
+	conf.Chaos.DurationMedian *= time.Second
+	conf.Chaos.MaxDuration *= time.Second
+	conf.Chaos.FlickerDuration *= time.Second
+	conf.Chaos.FlickerGap *= time.Second
+	for i := range conf.Load.Steps {
+		conf.Load.Steps[i].Duration *= time.Second
+	}
+	conf.setDefaults(md)
+	if conf.Load.CsvFile != "" {
+		path := conf.Load.CsvFile
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(filepath.Dir(configPath), path)
+		}
+		if conf.Load.Series, err = readLoadSeriesFile(path); err != nil {
+			return nil, fmt.Errorf("load csv file error: %v", err)
+		}
+	}
+	if conf.Replay.Speed == 0 {
+		conf.Replay.Speed = 1
+	}
+	if conf.Replay.File != "" {
+		path := conf.Replay.File
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(filepath.Dir(configPath), path)
+		}
+		if conf.Replay.Recording, err = readRecordingFile(path); err != nil {
+			return nil, fmt.Errorf("replay file error: %v", err)
+		}
+	}
 	if err := conf.validate(); err != nil {
 		return nil, err
 	}
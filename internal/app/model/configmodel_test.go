@@ -1,10 +1,14 @@
 package model
 
 import (
+	"math"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func Test_Config_validate(t *testing.T) {
@@ -47,6 +51,15 @@ func Test_Config_validate(t *testing.T) {
 			},
 			isValid: false,
 		},
+		{
+			name: "invalid ClockSpeed",
+			config: func() *Config {
+				conf := TestConfig(t)
+				conf.ClockSpeed = 0.5
+				return conf
+			},
+			isValid: false,
+		},
 		{
 			name: "invalid CycleChangeTimeout",
 			config: func() *Config {
@@ -56,6 +69,15 @@ func Test_Config_validate(t *testing.T) {
 			},
 			isValid: false,
 		},
+		{
+			name: "invalid Cycle.Initial",
+			config: func() *Config {
+				conf := TestConfig(t)
+				conf.Cycle.Initial = "invalid"
+				return conf
+			},
+			isValid: false,
+		},
 		{
 			name: "invalid DefaultInputAcVoltage",
 			config: func() *Config {
@@ -119,6 +141,380 @@ func Test_Config_validate(t *testing.T) {
 			},
 			isValid: false,
 		},
+		{
+			name: "invalid Battery.Chemistry",
+			config: func() *Config {
+				conf := TestConfig(t)
+				conf.Battery.Chemistry = "invalid"
+				return conf
+			},
+			isValid: false,
+		},
+		{
+			name: "invalid Battery.CellsPerBlock",
+			config: func() *Config {
+				conf := TestConfig(t)
+				conf.Battery.CellsPerBlock = 0
+				return conf
+			},
+			isValid: false,
+		},
+		{
+			name: "invalid Battery.OcvCurve",
+			config: func() *Config {
+				conf := TestConfig(t)
+				conf.Battery.OcvCurve = OcvCurve{{SOC: 0, Voltage: 2}}
+				return conf
+			},
+			isValid: false,
+		},
+		{
+			name: "valid, Battery.OcvCurve",
+			config: func() *Config {
+				conf := TestConfig(t)
+				conf.Battery.OcvCurve = OcvCurve{{SOC: 0, Voltage: 2}, {SOC: 1, Voltage: 2.2}}
+				return conf
+			},
+			isValid: true,
+		},
+		{
+			name: "invalid Battery.RcResist",
+			config: func() *Config {
+				conf := TestConfig(t)
+				conf.Battery.RcResist = -1
+				return conf
+			},
+			isValid: false,
+		},
+		{
+			name: "invalid Charger.FloatVoltage",
+			config: func() *Config {
+				conf := TestConfig(t)
+				conf.Charger.FloatVoltage = conf.Charger.AbsorptionVoltage + 1
+				return conf
+			},
+			isValid: false,
+		},
+		{
+			name: "invalid Charger.EqualizeDuration",
+			config: func() *Config {
+				conf := TestConfig(t)
+				conf.Charger.EqualizeDuration = 0
+				return conf
+			},
+			isValid: false,
+		},
+		{
+			name: "valid, equalize disabled",
+			config: func() *Config {
+				conf := TestConfig(t)
+				conf.Charger.EqualizeInterval = 0
+				conf.Charger.EqualizeDuration = 0
+				conf.Charger.EqualizeVoltage = 0
+				return conf
+			},
+			isValid: true,
+		},
+		{
+			name: "invalid Charger.Disabled with the charged event",
+			config: func() *Config {
+				conf := TestConfig(t)
+				conf.Charger.Disabled = true
+				return conf
+			},
+			isValid: false,
+		},
+		{
+			name: "valid, Charger.Disabled without the charged event",
+			config: func() *Config {
+				conf := TestConfig(t)
+				conf.Charger.Disabled = true
+				conf.Cycle.States[3].Transitions[0] = CycleTransition{To: "charged", After: time.Hour}
+				return conf
+			},
+			isValid: true,
+		},
+		{
+			name: "invalid Charger.TempCompensation",
+			config: func() *Config {
+				conf := TestConfig(t)
+				conf.Charger.TempCompensation = 0.1
+				return conf
+			},
+			isValid: false,
+		},
+		{
+			name: "invalid Output.RatedActivePower",
+			config: func() *Config {
+				conf := TestConfig(t)
+				conf.Output.RatedActivePower = conf.Output.RatedApparentPower + 1
+				return conf
+			},
+			isValid: false,
+		},
+		{
+			name: "invalid Output.LoadPowerFactor",
+			config: func() *Config {
+				conf := TestConfig(t)
+				conf.Output.LoadPowerFactor = 1.1
+				return conf
+			},
+			isValid: false,
+		},
+		{
+			name: "invalid Output.EfficiencyCurve",
+			config: func() *Config {
+				conf := TestConfig(t)
+				conf.Output.EfficiencyCurve = EfficiencyCurve{{Load: 0.5, Efficiency: 1.5}}
+				return conf
+			},
+			isValid: false,
+		},
+		{
+			name: "invalid Output.Rectifier",
+			config: func() *Config {
+				conf := TestConfig(t)
+				conf.Output.Rectifier = "invalid"
+				return conf
+			},
+			isValid: false,
+		},
+		{
+			name: "invalid Output.RectifierPowerFactor",
+			config: func() *Config {
+				conf := TestConfig(t)
+				conf.Output.RectifierPowerFactor = 1.2
+				return conf
+			},
+			isValid: false,
+		},
+		{
+			name: "valid, ThreePhase",
+			config: func() *Config {
+				conf := TestConfig(t)
+				conf.ThreePhase.Enabled = true
+				return conf
+			},
+			isValid: true,
+		},
+		{
+			name: "invalid ThreePhase.LoadDistribution",
+			config: func() *Config {
+				conf := TestConfig(t)
+				conf.ThreePhase.Enabled = true
+				conf.ThreePhase.LoadDistribution = [3]float32{0.5, 0.5, 0.5}
+				return conf
+			},
+			isValid: false,
+		},
+		{
+			name: "invalid Input.Frequency",
+			config: func() *Config {
+				conf := TestConfig(t)
+				conf.Input.Frequency = 55
+				return conf
+			},
+			isValid: false,
+		},
+		{
+			name: "invalid Input.SagVoltage above VoltageLow",
+			config: func() *Config {
+				conf := TestConfig(t)
+				conf.Input.SagVoltage = 180
+				return conf
+			},
+			isValid: false,
+		},
+		{
+			name: "invalid Input.FrequencyHigh",
+			config: func() *Config {
+				conf := TestConfig(t)
+				conf.Input.FrequencyHigh = 49
+				return conf
+			},
+			isValid: false,
+		},
+		{
+			name: "valid, Load.Profile steps",
+			config: func() *Config {
+				conf := TestConfig(t)
+				conf.Load.Profile = LoadProfileSteps
+				return conf
+			},
+			isValid: true,
+		},
+		{
+			name: "invalid Load.Profile not configured",
+			config: func() *Config {
+				conf := TestConfig(t)
+				conf.Load.Profile = LoadProfileCsv
+				return conf
+			},
+			isValid: false,
+		},
+		{
+			name: "invalid Load.RandomWalkMax",
+			config: func() *Config {
+				conf := TestConfig(t)
+				conf.Load.RandomWalkMax = 100
+				return conf
+			},
+			isValid: false,
+		},
+		{
+			name: "invalid Overload.Action",
+			config: func() *Config {
+				conf := TestConfig(t)
+				conf.Overload.Action = "invalid"
+				return conf
+			},
+			isValid: false,
+		},
+		{
+			name: "valid, Overload.TripCurve empty",
+			config: func() *Config {
+				conf := TestConfig(t)
+				conf.Overload.TripCurve = nil
+				return conf
+			},
+			isValid: true,
+		},
+		{
+			name: "invalid Sensors",
+			config: func() *Config {
+				conf := TestConfig(t)
+				conf.Sensors["invalid"] = SensorConfig{}
+				return conf
+			},
+			isValid: false,
+		},
+		{
+			name: "invalid Replay.Speed",
+			config: func() *Config {
+				conf := TestConfig(t)
+				conf.Replay.Speed = 0
+				return conf
+			},
+			isValid: false,
+		},
+		{
+			name: "invalid Replay.Columns",
+			config: func() *Config {
+				conf := TestConfig(t)
+				conf.Replay.Columns["ups.temp"] = "invalid"
+				return conf
+			},
+			isValid: false,
+		},
+		{
+			name: "invalid Chaos.DurationMedian",
+			config: func() *Config {
+				conf := TestConfig(t)
+				conf.Chaos.DurationMedian = 0
+				return conf
+			},
+			isValid: false,
+		},
+		{
+			name: "invalid Chaos.FlickerCount",
+			config: func() *Config {
+				conf := TestConfig(t)
+				conf.Chaos.FlickerCount = 1
+				return conf
+			},
+			isValid: false,
+		},
+		{
+			name: "valid, Chaos not configured",
+			config: func() *Config {
+				conf := TestConfig(t)
+				conf.Chaos = ChaosConfig{}
+				return conf
+			},
+			isValid: true,
+		},
+		{
+			name: "invalid Chaos enabled, not configured",
+			config: func() *Config {
+				conf := TestConfig(t)
+				conf.Chaos = ChaosConfig{Enabled: true}
+				return conf
+			},
+			isValid: false,
+		},
+		{
+			name: "invalid Schedule.Jobs",
+			config: func() *Config {
+				conf := TestConfig(t)
+				conf.Schedule.Jobs = ScheduledJobs{{Name: "off", Cron: "invalid", Action: ActionMainsOff}}
+				return conf
+			},
+			isValid: false,
+		},
+		{
+			name: "invalid Generator.StartFailureProbability",
+			config: func() *Config {
+				conf := TestConfig(t)
+				conf.Generator.Enabled = true
+				conf.Generator.StartFailureProbability = 1.5
+				return conf
+			},
+			isValid: false,
+		},
+		{
+			name: "valid, Generator disabled",
+			config: func() *Config {
+				conf := TestConfig(t)
+				conf.Generator.StartAttempts = 0
+				return conf
+			},
+			isValid: true,
+		},
+		{
+			name: "invalid BatteryTest.FailSoh above WarningSoh",
+			config: func() *Config {
+				conf := TestConfig(t)
+				conf.BatteryTest.FailSoh = 0.9
+				return conf
+			},
+			isValid: false,
+		},
+		{
+			name: "invalid BatteryTest.EolResist",
+			config: func() *Config {
+				conf := TestConfig(t)
+				conf.BatteryTest.EolResist = 1
+				return conf
+			},
+			isValid: false,
+		},
+		{
+			name: "invalid BatteryFaults.HeatTimeConstant",
+			config: func() *Config {
+				conf := TestConfig(t)
+				conf.BatteryFaults.HeatTimeConstant = 0
+				return conf
+			},
+			isValid: false,
+		},
+		{
+			name: "invalid Bypass.VoltageHigh",
+			config: func() *Config {
+				conf := TestConfig(t)
+				conf.Bypass.VoltageHigh = 150
+				return conf
+			},
+			isValid: false,
+		},
+		{
+			name: "invalid DefaultInputAcVoltage out of the input window",
+			config: func() *Config {
+				conf := TestConfig(t)
+				conf.DefaultInputAcVoltage = 270
+				return conf
+			},
+			isValid: false,
+		},
 	}
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
@@ -130,3 +526,60 @@ func Test_Config_validate(t *testing.T) {
 		})
 	}
 }
+
+func Test_NewConfig_baseline(t *testing.T) {
+	// a config written before the model sections were added
+	path := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+ups_addr = "127.0.0.1:1502"
+rest_api_bind_addr = ":8080"
+ups_sync_interval = 30 # sec
+
+cycle_change_timeout = 3600 # sec
+
+default_input_ac_voltage    = 220   # V
+max_bat_group_voltage       = 54    # V
+min_bat_group_voltage       = 42    # V
+load_power                  = 1000  # W
+default_bat_capacity        = 50    # Ah
+charge_current_limit        = 20    # A
+low_soc_trigger_alarm       = 0.1   # from 0 to 1, 1: 100%
+`), 0o644))
+
+	conf, err := NewConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, ChemistryLinear, conf.Battery.Chemistry)
+	curve := conf.OcvCurve(4)
+	assert.InDelta(t, 42, curve.Voltage(0)*24, 0.001)
+	assert.InDelta(t, 54, curve.Voltage(1)*24, 0.001)
+	assert.Greater(t, conf.Charger.FloatVoltage, conf.MaxBatGroupVoltage)
+	assert.Equal(t, float32(176), conf.Input.VoltageLow)
+	assert.Equal(t, float32(264), conf.Input.VoltageHigh)
+	assert.Equal(t, LoadProfileConstant, conf.Load.Profile)
+	assert.Empty(t, conf.Overload.TripCurve, "never trips")
+	assert.Equal(t, time.Minute, conf.Overload.Cooldown)
+	assert.Equal(t, 10*time.Second, conf.BatteryTest.Duration)
+	assert.InDelta(t, 0.02/math.Sqrt(3), conf.Sensors[SensorBatGroupVoltage].Noise, 0.0001)
+	assert.InDelta(t, 0.04/math.Sqrt(3), conf.Sensors[SensorBatteryTemp].Noise, 0.0001)
+	assert.NotContains(t, conf.Sensors, SensorSOC, "not measured originally")
+
+	// a present section is not replaced
+	require.NoError(t, os.WriteFile(path, []byte(`
+ups_addr = "127.0.0.1:1502"
+rest_api_bind_addr = ":8080"
+ups_sync_interval = 30
+cycle_change_timeout = 3600
+default_input_ac_voltage = 220
+max_bat_group_voltage = 54
+min_bat_group_voltage = 42
+load_power = 1000
+default_bat_capacity = 50
+charge_current_limit = 20
+low_soc_trigger_alarm = 0.1
+
+[battery]
+chemistry = "vrla"
+`), 0o644))
+	_, err = NewConfig(path)
+	assert.Error(t, err, "cells_per_block is required")
+}
@@ -0,0 +1,149 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronMacros are the shortcuts of the common schedules
+var cronMacros = map[string]string{
+	"@yearly":  "0 0 1 1 *",
+	"@monthly": "0 0 1 * *",
+	"@weekly":  "0 0 * * 0",
+	"@daily":   "0 0 * * *",
+	"@hourly":  "0 * * * *",
+}
+
+var (
+	cronMonthNames = []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}
+	cronDayNames   = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+)
+
+// cronSearchLimit stops the search of a schedule that never fires, e.g. on 30 February
+const cronSearchLimit = 5 * 366 * 24 * time.Hour
+
+// CronSchedule is a parsed cron expression "minute hour day-of-month month day-of-week", a bit per value.
+// As in cron, a day matches either day field if both are restricted
+type CronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	domAny, dowAny                bool
+}
+
+// ParseCron parses the expression of 5 fields: numbers, names of months and days of week, *, ranges a-b,
+// steps /n and lists separated by commas, or a macro like @daily
+func ParseCron(expr string) (*CronSchedule, error) {
+	if macro, ok := cronMacros[strings.TrimSpace(expr)]; ok {
+		expr = macro
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression must have 5 fields: %q", expr)
+	}
+	var s CronSchedule
+	var err error
+	if s.minute, err = parseCronField(fields[0], 0, 59, nil); err != nil {
+		return nil, fmt.Errorf("minute: %v", err)
+	}
+	if s.hour, err = parseCronField(fields[1], 0, 23, nil); err != nil {
+		return nil, fmt.Errorf("hour: %v", err)
+	}
+	if s.dom, err = parseCronField(fields[2], 1, 31, nil); err != nil {
+		return nil, fmt.Errorf("day of month: %v", err)
+	}
+	if s.month, err = parseCronField(fields[3], 1, 12, cronMonthNames); err != nil {
+		return nil, fmt.Errorf("month: %v", err)
+	}
+	if s.dow, err = parseCronField(fields[4], 0, 7, cronDayNames); err != nil {
+		return nil, fmt.Errorf("day of week: %v", err)
+	}
+	if s.dow&(1<<7) != 0 { // 7 is Sunday too
+		s.dow |= 1
+	}
+	s.domAny = fields[2] == "*"
+	s.dowAny = fields[4] == "*"
+	return &s, nil
+}
+
+// parseCronField returns the set of the values from lo to hi, names are numbered from lo
+func parseCronField(field string, lo, hi int, names []string) (uint64, error) {
+	var set uint64
+	for _, item := range strings.Split(field, ",") {
+		rng, step := item, 1
+		if i := strings.IndexByte(item, '/'); i >= 0 {
+			var err error
+			if step, err = strconv.Atoi(item[i+1:]); err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step: %q", item)
+			}
+			rng = item[:i]
+		}
+		from, to := lo, hi
+		if rng != "*" {
+			bounds := strings.SplitN(rng, "-", 2)
+			var err error
+			if from, err = parseCronValue(bounds[0], lo, names); err != nil {
+				return 0, err
+			}
+			to = from
+			if len(bounds) == 2 {
+				if to, err = parseCronValue(bounds[1], lo, names); err != nil {
+					return 0, err
+				}
+			} else if step > 1 { // a/n means from a to the end
+				to = hi
+			}
+		}
+		if from < lo || to > hi || from > to {
+			return 0, fmt.Errorf("out of range %d-%d: %q", lo, hi, item)
+		}
+		for v := from; v <= to; v += step {
+			set |= 1 << v
+		}
+	}
+	return set, nil
+}
+
+func parseCronValue(s string, lo int, names []string) (int, error) {
+	for i, name := range names {
+		if strings.EqualFold(s, name) {
+			return lo + i, nil
+		}
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value: %q", s)
+	}
+	return v, nil
+}
+
+func (s *CronSchedule) dayMatches(t time.Time) bool {
+	dom := s.dom&(1<<t.Day()) != 0
+	dow := s.dow&(1<<t.Weekday()) != 0
+	if s.domAny || s.dowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// Next returns the first time matching the schedule after t, in the location of t
+func (s *CronSchedule) Next(t time.Time) (time.Time, error) {
+	limit := t.Add(cronSearchLimit)
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	for t.Before(limit) {
+		switch {
+		case s.month&(1<<t.Month()) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !s.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case s.hour&(1<<t.Hour()) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case s.minute&(1<<t.Minute()) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t, nil
+		}
+	}
+	return time.Time{}, errors.New("cron schedule never fires")
+}
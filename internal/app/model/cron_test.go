@@ -0,0 +1,70 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ParseCron(t *testing.T) {
+	testCases := []struct {
+		name    string
+		expr    string
+		isValid bool
+	}{
+		{"every minute", "* * * * *", true},
+		{"lists, ranges and steps", "0,30 8-18/2 1-15 */3 1-5", true},
+		{"names", "0 3 * jan-jun mon,fri", true},
+		{"sunday as 7", "0 0 * * 7", true},
+		{"macro", "@weekly", true},
+		{"too few fields", "0 3 * *", false},
+		{"minute out of range", "60 * * * *", false},
+		{"day of month out of range", "0 0 0 * *", false},
+		{"invalid step", "*/0 * * * *", false},
+		{"reversed range", "0 18-8 * * *", false},
+		{"unknown name", "0 0 * * xyz", false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseCron(tc.expr)
+			if tc.isValid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func Test_CronSchedule_Next(t *testing.T) {
+	from := time.Date(2024, 7, 1, 12, 30, 15, 0, time.UTC) // Monday
+	testCases := []struct {
+		name     string
+		expr     string
+		expected time.Time
+	}{
+		{"next minute", "* * * * *", time.Date(2024, 7, 1, 12, 31, 0, 0, time.UTC)},
+		{"step", "*/20 * * * *", time.Date(2024, 7, 1, 12, 40, 0, 0, time.UTC)},
+		{"next day", "0 3 * * *", time.Date(2024, 7, 2, 3, 0, 0, 0, time.UTC)},
+		{"day of week", "0 3 * * sat", time.Date(2024, 7, 6, 3, 0, 0, 0, time.UTC)},
+		{"sunday as 7", "0 0 * * 7", time.Date(2024, 7, 7, 0, 0, 0, 0, time.UTC)},
+		{"next year", "0 0 1 jan *", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"either day field", "0 0 15 * fri", time.Date(2024, 7, 5, 0, 0, 0, 0, time.UTC)},
+		{"leap day", "0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := ParseCron(tc.expr)
+			require.NoError(t, err)
+			next, err := s.Next(from)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, next)
+		})
+	}
+
+	s, _ := ParseCron("0 0 30 2 *")
+	_, err := s.Next(from)
+	assert.Error(t, err, "never fires")
+}
@@ -0,0 +1,126 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Cycle events
+const (
+	CycleEventShutdown         = "shutdown"          // the output is shut down
+	CycleEventCharged          = "charged"           // on the input, the charger is in float
+	CycleEventGeneratorRunning = "generator_running" // the generator carries the load
+)
+
+// CycleTransition leads to the state To when all its criteria hold, at least one is required
+type CycleTransition struct {
+	To    string        `toml:"to"`
+	After time.Duration `toml:"after"` // sec in the state
+	Event string        `toml:"event"` // shutdown, charged, generator_running
+	When  *Condition    `toml:"when"`  // on a param, e.g. soc < 40
+}
+
+// CycleState is a state of the auto mode cycle. On entering it switches the mains and sets the load,
+// the transitions are checked in order
+type CycleState struct {
+	Name         string            `toml:"name"`
+	Mains        bool              `toml:"mains"`         // the utility power is on
+	InputVoltage float32           `toml:"input_voltage"` // V, 0 - default_input_ac_voltage
+	LoadPower    float32           `toml:"load_power"`    // W, 0 - the load profile
+	Transitions  []CycleTransition `toml:"transitions"`
+}
+
+// CycleConfig is the state machine of the auto mode
+type CycleConfig struct {
+	Initial string       `toml:"initial"` // the first state, after a reset too
+	States  []CycleState `toml:"states"`
+}
+
+// DefaultCycle returns the cycle q0 -> q3 with the timeout of the charged and the discharged states:
+// charged -> discharging -> discharged or carried by the generator -> charging -> charged
+func DefaultCycle(timeout time.Duration) CycleConfig {
+	return CycleConfig{
+		Initial: "charged",
+		States: []CycleState{
+			{Name: "charged", Mains: true, Transitions: []CycleTransition{{To: "discharging", After: timeout}}},
+			{Name: "discharging", Transitions: []CycleTransition{
+				{To: "discharged", Event: CycleEventShutdown},
+				{To: "charging", Event: CycleEventGeneratorRunning, After: timeout},
+			}},
+			{Name: "discharged", Transitions: []CycleTransition{{To: "charging", After: timeout}}},
+			{Name: "charging", Mains: true, Transitions: []CycleTransition{{To: "charged", Event: CycleEventCharged}}},
+		},
+	}
+}
+
+// StateIndex returns the index of the state by its name, -1 if not found
+func (conf *CycleConfig) StateIndex(name string) int {
+	for i := range conf.States {
+		if conf.States[i].Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// HasEvent reports whether a transition of the cycle waits for the event
+func (conf *CycleConfig) HasEvent(event string) bool {
+	for _, state := range conf.States {
+		for _, tr := range state.Transitions {
+			if tr.Event == event {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (conf CycleConfig) Validate() error {
+	if len(conf.States) == 0 {
+		return errors.New("at least 1 state required")
+	}
+	if conf.StateIndex(conf.Initial) < 0 {
+		return fmt.Errorf("unknown initial state: %q", conf.Initial)
+	}
+	for i, state := range conf.States {
+		if state.Name == "" {
+			return fmt.Errorf("state %d: name required", i)
+		}
+		if conf.StateIndex(state.Name) != i {
+			return fmt.Errorf("state %q: duplicate name", state.Name)
+		}
+		if state.InputVoltage < 0 || state.LoadPower < 0 {
+			return fmt.Errorf("state %q: input_voltage and load_power must not be negative", state.Name)
+		}
+		for j, tr := range state.Transitions {
+			if err := conf.validateTransition(tr); err != nil {
+				return fmt.Errorf("state %q: transition %d: %v", state.Name, j, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (conf *CycleConfig) validateTransition(tr CycleTransition) error {
+	if conf.StateIndex(tr.To) < 0 {
+		return fmt.Errorf("unknown state: %q", tr.To)
+	}
+	if tr.After < 0 {
+		return errors.New("after must not be negative")
+	}
+	switch tr.Event {
+	case "", CycleEventShutdown, CycleEventCharged, CycleEventGeneratorRunning:
+	default:
+		return fmt.Errorf("unknown event: %q", tr.Event)
+	}
+	if tr.When != nil {
+		if err := tr.When.Validate(); err != nil {
+			return err
+		}
+	}
+	if tr.After == 0 && tr.Event == "" && tr.When == nil {
+		return errors.New("after, event or when required")
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_CycleConfig_Validate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		modify  func(conf *CycleConfig)
+		isValid bool
+	}{
+		{"valid, default", func(conf *CycleConfig) {}, true},
+		{"invalid, no states", func(conf *CycleConfig) { conf.States = nil }, false},
+		{"invalid, unknown initial", func(conf *CycleConfig) { conf.Initial = "invalid" }, false},
+		{"invalid, duplicate name", func(conf *CycleConfig) { conf.States[1].Name = "charged" }, false},
+		{"invalid, unknown target", func(conf *CycleConfig) { conf.States[0].Transitions[0].To = "invalid" }, false},
+		{"invalid, unknown event", func(conf *CycleConfig) { conf.States[1].Transitions[0].Event = "invalid" }, false},
+		{"invalid, no criteria", func(conf *CycleConfig) { conf.States[0].Transitions[0].After = 0 }, false},
+		{"invalid, condition", func(conf *CycleConfig) {
+			conf.States[0].Transitions[0].When = &Condition{Param: "invalid", Op: "<"}
+		}, false},
+		{"valid, condition", func(conf *CycleConfig) {
+			conf.States[0].Transitions[0].When = &Condition{Param: SensorSOC, Op: "<", Value: 0.4}
+		}, true},
+		{"invalid, negative load", func(conf *CycleConfig) { conf.States[0].LoadPower = -1 }, false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			conf := DefaultCycle(time.Hour)
+			tc.modify(&conf)
+			if tc.isValid {
+				assert.NoError(t, conf.Validate())
+			} else {
+				assert.Error(t, conf.Validate())
+			}
+		})
+	}
+}
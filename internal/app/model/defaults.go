@@ -0,0 +1,96 @@
+package model
+
+import (
+	"math"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// setDefaults fills the sections absent from the config file, so a config written before a section was added
+// keeps loading and behaves close to the original model: the linear OCV curve, the charger floating just above
+// MaxBatGroupVoltage, the input windows around DefaultInputAcVoltage, the constant load that never trips the inverter
+// and the measurement error of the original sensors
+func (conf *Config) setDefaults(md toml.MetaData) {
+	nominal := conf.DefaultInputAcVoltage
+	defaults := map[string]func(){
+		"battery": func() {
+			conf.Battery = BatteryConfig{Chemistry: ChemistryLinear, CellsPerBlock: 6}
+		},
+		"battery_faults": func() {
+			conf.BatteryFaults = BatteryFaultsConfig{
+				ShortedCellHeat:    15,
+				HeatTimeConstant:   30 * time.Minute,
+				ResistanceGrowth:   2,
+				ThermalRunawayRate: 0.5,
+			}
+		},
+		"charger": func() {
+			conf.Charger = ChargerConfig{
+				AbsorptionVoltage:     conf.MaxBatGroupVoltage * 1.067,
+				FloatVoltage:          conf.MaxBatGroupVoltage * 1.011,
+				FloatCurrentThreshold: conf.ChargeCurrentLimit * 0.05,
+				PolarizationResist:    0.05,
+			}
+		},
+		"output": func() {
+			rated := max(2700, 2*conf.LoadPower)
+			conf.Output = OutputConfig{
+				Voltage:             nominal,
+				Frequency:           50,
+				RatedApparentPower:  rated,
+				RatedActivePower:    rated,
+				LoadPowerFactor:     1,
+				RectifierEfficiency: 0.97,
+				Rectifier:           RectifierIgbt,
+			}
+		},
+		"input": func() {
+			conf.Input = InputConfig{
+				Frequency:     50,
+				VoltageLow:    nominal * 0.8,
+				VoltageHigh:   nominal * 1.2,
+				SagVoltage:    nominal * 0.68,
+				SwellVoltage:  nominal * 1.32,
+				FrequencyLow:  47,
+				FrequencyHigh: 53,
+			}
+		},
+		"bypass": func() {
+			conf.Bypass = BypassConfig{VoltageLow: nominal * 0.73, VoltageHigh: nominal * 1.25, FrequencyTolerance: 2}
+		},
+		"load": func() {
+			conf.Load = LoadConfig{Profile: LoadProfileConstant}
+		},
+		"overload": func() {
+			conf.Overload = OverloadConfig{Action: OverloadActionBypass, Cooldown: time.Minute}
+		},
+		"sensors": func() {
+			// the original error is uniform within ±2 %, ±4 % of the batteries, the noise has the same deviation
+			conf.Sensors = SensorsConfig{}
+			for _, name := range []string{
+				SensorInputAcVoltage, SensorInputAcCurrent, SensorBatGroupVoltage, SensorBatGroupCurrent, SensorLoadCurrent,
+			} {
+				conf.Sensors[name] = SensorConfig{Noise: float32(0.02 / math.Sqrt(3))}
+			}
+			for _, name := range []string{SensorBatteryVoltage, SensorBatteryTemp, SensorBatteryResist} {
+				conf.Sensors[name] = SensorConfig{Noise: float32(0.04 / math.Sqrt(3))}
+			}
+		},
+		"battery_test": func() {
+			conf.BatteryTest = BatteryTestConfig{
+				Duration:       10 * time.Second,
+				MinSoc:         0.5,
+				EolResist:      2,
+				WarningSoh:     0.8,
+				FailSoh:        0.5,
+				MaxVoltageDrop: 0.1,
+			}
+		},
+	}
+	for section, setDefault := range defaults {
+		if !md.IsDefined(section) {
+			setDefault()
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/alex11prog/ups-imitator/internal/app/utils"
+)
+
+// EfficiencyPoint is a point of the inverter efficiency curve
+type EfficiencyPoint struct {
+	Load       float32 `toml:"load"`       // fraction of the rated power, from 0
+	Efficiency float32 `toml:"efficiency"` // from 0 to 1
+}
+
+// EfficiencyCurve is a table of the inverter efficiency depending on load, sorted by load
+type EfficiencyCurve []EfficiencyPoint
+
+// DefaultEfficiencyCurve is a typical curve of a double conversion UPS
+var DefaultEfficiencyCurve = EfficiencyCurve{
+	{0.1, 0.82}, {0.25, 0.91}, {0.5, 0.94}, {0.75, 0.95}, {1, 0.945}, {1.5, 0.93},
+}
+
+// Efficiency returns the efficiency for the given load fraction using linear interpolation between the points
+func (c EfficiencyCurve) Efficiency(load float32) float32 {
+	if load <= c[0].Load {
+		return c[0].Efficiency
+	}
+	for i := 1; i < len(c); i++ {
+		if load <= c[i].Load {
+			return utils.LinearInterpolate(c[i-1].Load, c[i-1].Efficiency, c[i].Load, c[i].Efficiency, load)
+		}
+	}
+	return c[len(c)-1].Efficiency
+}
+
+func (c EfficiencyCurve) Validate() error {
+	if len(c) == 0 {
+		return errors.New("at least 1 point required")
+	}
+	for i, p := range c {
+		if p.Load < 0 || p.Efficiency <= 0 || p.Efficiency > 1 {
+			return fmt.Errorf("point %d: load must be positive, efficiency from 0 to 1", i)
+		}
+		if i > 0 && p.Load <= c[i-1].Load {
+			return fmt.Errorf("point %d: load must be increasing", i)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/alex11prog/ups-imitator/internal/app/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_EfficiencyCurve_Efficiency(t *testing.T) {
+	curve := model.EfficiencyCurve{{Load: 0.1, Efficiency: 0.8}, {Load: 0.5, Efficiency: 0.9}, {Load: 1, Efficiency: 0.95}}
+	assert.InDelta(t, 0.8, curve.Efficiency(0), 0.0001)
+	assert.InDelta(t, 0.85, curve.Efficiency(0.3), 0.0001)
+	assert.InDelta(t, 0.95, curve.Efficiency(1), 0.0001)
+	assert.InDelta(t, 0.95, curve.Efficiency(1.2), 0.0001)
+}
+
+func Test_EfficiencyCurve_Validate(t *testing.T) {
+	assert.NoError(t, model.DefaultEfficiencyCurve.Validate())
+	assert.Error(t, model.EfficiencyCurve{}.Validate())
+	assert.Error(t, model.EfficiencyCurve{{Load: 0.5, Efficiency: 1.2}}.Validate())
+	assert.Error(t, model.EfficiencyCurve{{Load: 0.5, Efficiency: 0.9}, {Load: 0.4, Efficiency: 0.9}}.Validate())
+}
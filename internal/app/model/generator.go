@@ -0,0 +1,82 @@
+package model
+
+import "fmt"
+
+// GeneratorState is the state of the standby generator
+type GeneratorState uint16
+
+const (
+	GeneratorStandby  GeneratorState = iota // the mains is on, the generator is stopped
+	GeneratorStarting                       // start delay and cranking
+	GeneratorWarmUp                         // running without the load
+	GeneratorRunning                        // the ATS has transferred the load to the generator
+	GeneratorFailed                         // failed to start or out of fuel, until the mains returns
+)
+
+var generatorStateNames = [...]string{"standby", "starting", "warm_up", "running", "failed"}
+
+func (s GeneratorState) String() string {
+	if int(s) < len(generatorStateNames) {
+		return generatorStateNames[s]
+	}
+	return fmt.Sprintf("unknown(%d)", uint16(s))
+}
+
+func (s GeneratorState) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s *GeneratorState) UnmarshalText(text []byte) error {
+	for i, name := range generatorStateNames {
+		if name == string(text) {
+			*s = GeneratorState(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown generator state: %q", text)
+}
+
+// AtsSource is the source the automatic transfer switch feeds the UPS input from
+type AtsSource uint16
+
+const (
+	SourceMains AtsSource = iota
+	SourceGenerator
+)
+
+var atsSourceNames = [...]string{"mains", "generator"}
+
+func (s AtsSource) String() string {
+	if int(s) < len(atsSourceNames) {
+		return atsSourceNames[s]
+	}
+	return fmt.Sprintf("unknown(%d)", uint16(s))
+}
+
+func (s AtsSource) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s *AtsSource) UnmarshalText(text []byte) error {
+	for i, name := range atsSourceNames {
+		if name == string(text) {
+			*s = AtsSource(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown ats source: %q", text)
+}
+
+// GeneratorParams are params of the standby generator and the automatic transfer switch
+type GeneratorParams struct {
+	State         GeneratorState `json:"state" swaggertype:"string" enums:"standby,starting,warm_up,running,failed" example:"standby"`
+	Source        AtsSource      `json:"source" swaggertype:"string" enums:"mains,generator" example:"mains"`
+	StartAttempts uint16         `json:"start_attempts" example:"0"` // of the current start
+	FuelLevel     float32        `json:"fuel_level" example:"1"`     // from 0 to 1
+	Voltage       float32        `json:"voltage" example:"0"`        // V
+	Frequency     float32        `json:"frequency" example:"0"`      // Hz
+}
+
+type GeneratorUpdateForm struct {
+	FuelLevel *float32 `json:"fuel_level" example:"1"` // from 0 to 1
+}
@@ -0,0 +1,187 @@
+package model
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alex11prog/ups-imitator/internal/app/utils"
+)
+
+// Load profiles
+const (
+	LoadProfileConstant   = "constant"    // LoadPower
+	LoadProfileDaily      = "daily"       // daily curve, local time
+	LoadProfileSteps      = "steps"       // step schedule, repeated
+	LoadProfileRandomWalk = "random_walk" // random walk between the bounds
+	LoadProfileCsv        = "csv"         // time series from a CSV file, repeated
+)
+
+// LoadPoint is a point of the daily load curve
+type LoadPoint struct {
+	Hour  float32 `toml:"hour"`  // from 0 to 24, local time
+	Power float32 `toml:"power"` // W
+}
+
+// DailyLoadCurve is a table of the load power depending on the time of day, sorted by hour.
+// The curve wraps around midnight
+type DailyLoadCurve []LoadPoint
+
+// Power returns the load power at the time of day using linear interpolation between the points
+func (c DailyLoadCurve) Power(t time.Time) float32 {
+	hour := float32(t.Hour()) + float32(t.Minute())/60 + float32(t.Second())/3600
+	last := c[len(c)-1]
+	if hour < c[0].Hour {
+		return utils.LinearInterpolate(last.Hour-24, last.Power, c[0].Hour, c[0].Power, hour)
+	}
+	for i := 1; i < len(c); i++ {
+		if hour <= c[i].Hour {
+			return utils.LinearInterpolate(c[i-1].Hour, c[i-1].Power, c[i].Hour, c[i].Power, hour)
+		}
+	}
+	return utils.LinearInterpolate(last.Hour, last.Power, c[0].Hour+24, c[0].Power, hour)
+}
+
+func (c DailyLoadCurve) Validate() error {
+	if len(c) == 0 {
+		return errors.New("at least 1 point required")
+	}
+	for i, point := range c {
+		if point.Hour < 0 || point.Hour >= 24 {
+			return fmt.Errorf("point %d: hour must be from 0 to 24", i)
+		}
+		if point.Power < 0 {
+			return fmt.Errorf("point %d: power must not be negative", i)
+		}
+		if i > 0 && point.Hour <= c[i-1].Hour {
+			return fmt.Errorf("point %d: hour must be increasing", i)
+		}
+	}
+	return nil
+}
+
+// LoadStep is a step of the load schedule
+type LoadStep struct {
+	Duration time.Duration `toml:"duration"` // sec
+	Power    float32       `toml:"power"`    // W
+}
+
+// LoadSchedule is a sequence of load steps repeated in cycle
+type LoadSchedule []LoadStep
+
+// Power returns the load power of the step active after elapsed since the start of the schedule
+func (s LoadSchedule) Power(elapsed time.Duration) float32 {
+	var period time.Duration
+	for _, step := range s {
+		period += step.Duration
+	}
+	elapsed %= period
+	for _, step := range s {
+		if elapsed < step.Duration {
+			return step.Power
+		}
+		elapsed -= step.Duration
+	}
+	return s[len(s)-1].Power
+}
+
+func (s LoadSchedule) Validate() error {
+	if len(s) == 0 {
+		return errors.New("at least 1 step required")
+	}
+	for i, step := range s {
+		if step.Duration < time.Second {
+			return fmt.Errorf("step %d: duration must be at least 1 sec", i)
+		}
+		if step.Power < 0 {
+			return fmt.Errorf("step %d: power must not be negative", i)
+		}
+	}
+	return nil
+}
+
+// LoadSample is a sample of the load time series
+type LoadSample struct {
+	Time  time.Duration // since the start of the series
+	Power float32       // W
+}
+
+// LoadSeries is a load time series sorted by time, repeated in cycle
+type LoadSeries []LoadSample
+
+// ReadLoadSeries reads CSV rows "time, power": time in sec since the start of the series, power in W.
+// The header row is optional
+func ReadLoadSeries(r io.Reader) (LoadSeries, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 2
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	var series LoadSeries
+	for i, record := range records {
+		sec, err := strconv.ParseFloat(strings.TrimSpace(record[0]), 64)
+		if err != nil {
+			if i == 0 { // header
+				continue
+			}
+			return nil, fmt.Errorf("row %d: invalid time: %v", i+1, err)
+		}
+		power, err := strconv.ParseFloat(strings.TrimSpace(record[1]), 32)
+		if err != nil {
+			return nil, fmt.Errorf("row %d: invalid power: %v", i+1, err)
+		}
+		series = append(series, LoadSample{Time: time.Duration(sec * float64(time.Second)), Power: float32(power)})
+	}
+	return series, series.Validate()
+}
+
+func readLoadSeriesFile(path string) (LoadSeries, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadLoadSeries(f)
+}
+
+// Power returns the load power after elapsed since the start of the series using linear interpolation between the samples.
+// The series is repeated with the period of its last sample
+func (s LoadSeries) Power(elapsed time.Duration) float32 {
+	elapsed %= s[len(s)-1].Time
+	if elapsed <= s[0].Time {
+		return s[0].Power
+	}
+	for i := 1; i < len(s); i++ {
+		if elapsed <= s[i].Time {
+			x0, x1, x := float32(s[i-1].Time.Seconds()), float32(s[i].Time.Seconds()), float32(elapsed.Seconds())
+			return utils.LinearInterpolate(x0, s[i-1].Power, x1, s[i].Power, x)
+		}
+	}
+	return s[len(s)-1].Power
+}
+
+func (s LoadSeries) Validate() error {
+	if len(s) < 2 {
+		return errors.New("at least 2 samples required")
+	}
+	for i, sample := range s {
+		if sample.Time < 0 {
+			return fmt.Errorf("sample %d: time must not be negative", i)
+		}
+		if sample.Power < 0 {
+			return fmt.Errorf("sample %d: power must not be negative", i)
+		}
+		if i > 0 && sample.Time <= s[i-1].Time {
+			return fmt.Errorf("sample %d: time must be increasing", i)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package model_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alex11prog/ups-imitator/internal/app/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_DailyLoadCurve_Power(t *testing.T) {
+	curve := model.DailyLoadCurve{{Hour: 6, Power: 600}, {Hour: 12, Power: 1200}, {Hour: 18, Power: 600}}
+	at := func(hour, min int) time.Time {
+		return time.Date(2024, 1, 1, hour, min, 0, 0, time.Local)
+	}
+	assert.InDelta(t, 600, curve.Power(at(6, 0)), 0.001)
+	assert.InDelta(t, 900, curve.Power(at(9, 0)), 0.001)
+	assert.InDelta(t, 1100, curve.Power(at(11, 0)), 0.01)
+	assert.InDelta(t, 600, curve.Power(at(0, 0)), 0.001, "wraps around midnight")
+	assert.InDelta(t, 600, curve.Power(at(23, 30)), 0.001)
+
+	assert.NoError(t, curve.Validate())
+	assert.Error(t, model.DailyLoadCurve{}.Validate())
+	assert.Error(t, model.DailyLoadCurve{{Hour: 12, Power: 1}, {Hour: 6, Power: 1}}.Validate())
+	assert.Error(t, model.DailyLoadCurve{{Hour: 24, Power: 1}}.Validate())
+}
+
+func Test_LoadSchedule_Power(t *testing.T) {
+	schedule := model.LoadSchedule{{Duration: time.Minute, Power: 1000}, {Duration: 2 * time.Minute, Power: 1500}}
+	assert.Equal(t, float32(1000), schedule.Power(0))
+	assert.Equal(t, float32(1500), schedule.Power(time.Minute))
+	assert.Equal(t, float32(1500), schedule.Power(150*time.Second))
+	assert.Equal(t, float32(1000), schedule.Power(3*time.Minute+time.Second), "repeated")
+
+	assert.NoError(t, schedule.Validate())
+	assert.Error(t, model.LoadSchedule{}.Validate())
+	assert.Error(t, model.LoadSchedule{{Duration: 0, Power: 1000}}.Validate())
+}
+
+func Test_ReadLoadSeries(t *testing.T) {
+	series, err := model.ReadLoadSeries(strings.NewReader("time,power\n0,1000\n60, 2000\n# comment\n120,1000\n"))
+	require.NoError(t, err)
+	require.Len(t, series, 3)
+	assert.Equal(t, model.LoadSample{Time: time.Minute, Power: 2000}, series[1])
+
+	assert.InDelta(t, 1500, series.Power(30*time.Second), 0.001)
+	assert.InDelta(t, 2000, series.Power(time.Minute), 0.001)
+	assert.InDelta(t, 1500, series.Power(150*time.Second), 0.001, "repeated")
+
+	testCases := []struct {
+		name string
+		csv  string
+	}{
+		{"one sample", "0,1000\n"},
+		{"invalid time", "0,1000\nx,1000\n"},
+		{"invalid power", "0,1000\n60,x\n"},
+		{"time not increasing", "0,1000\n0,1000\n"},
+		{"invalid number of fields", "0,1000,1\n60,1000,1\n"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := model.ReadLoadSeries(strings.NewReader(tc.csv))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func Test_LoadConfig_CheckProfile(t *testing.T) {
+	conf := model.TestConfig(t).Load
+	assert.NoError(t, conf.CheckProfile(model.LoadProfileConstant))
+	assert.NoError(t, conf.CheckProfile(model.LoadProfileDaily))
+	assert.NoError(t, conf.CheckProfile(model.LoadProfileSteps))
+	assert.NoError(t, conf.CheckProfile(model.LoadProfileRandomWalk))
+	assert.Error(t, conf.CheckProfile(model.LoadProfileCsv), "not configured")
+	assert.Error(t, conf.CheckProfile("invalid"))
+}
@@ -0,0 +1,97 @@
+package model
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Params besides the measured ones, see SensorNames. Alarms are named "alarms.<name>", e.g. alarms.low_battery
+const (
+	ParamOperatingMode = "operating_mode"
+	ParamChargerStage  = "charger_stage"
+	paramAlarmsPrefix  = "alarms."
+)
+
+// ParamRef refers to a UpsParams field by name, e.g. the one a column is replayed to or a pinned one
+type ParamRef struct {
+	Name  string // a measured param, operating_mode, charger_stage or alarms.<name>
+	Index int    // of the battery or the phase
+}
+
+// ParseParamRef parses the param name with the optional index, e.g. battery_temp[1]
+func ParseParamRef(s string) (ParamRef, error) {
+	param := ParamRef{Name: s}
+	if i := strings.IndexByte(s, '['); i > 0 && strings.HasSuffix(s, "]") {
+		index, err := strconv.Atoi(s[i+1 : len(s)-1])
+		if err != nil {
+			return param, fmt.Errorf("invalid index: %q", s)
+		}
+		param = ParamRef{Name: s[:i], Index: index}
+	}
+	var params UpsParams
+	switch {
+	case param.Name == ParamOperatingMode || param.Name == ParamChargerStage:
+	case strings.HasPrefix(param.Name, paramAlarmsPrefix):
+		if _, ok := params.Alarms.ByName()[strings.TrimPrefix(param.Name, paramAlarmsPrefix)]; !ok {
+			return param, fmt.Errorf("unknown alarm: %q", param.Name)
+		}
+	default:
+		fields, ok := params.MeasuredParams()[param.Name]
+		if !ok {
+			return param, fmt.Errorf("unknown param: %q", param.Name)
+		}
+		if param.Index < 0 || param.Index >= len(fields) {
+			return param, fmt.Errorf("index out of range: %q", s)
+		}
+		return param, nil
+	}
+	if param.Index != 0 {
+		return param, fmt.Errorf("index not supported: %q", s)
+	}
+	return param, nil
+}
+
+// String returns the canonical name: the index is omitted for the params having a single field
+func (r ParamRef) String() string {
+	var params UpsParams
+	if fields, ok := params.MeasuredParams()[r.Name]; ok && len(fields) > 1 {
+		return fmt.Sprintf("%s[%d]", r.Name, r.Index)
+	}
+	return r.Name
+}
+
+// IsDiscrete reports whether the param takes the last sample instead of the interpolation
+func (r ParamRef) IsDiscrete() bool {
+	return r.Name == ParamOperatingMode || r.Name == ParamChargerStage || strings.HasPrefix(r.Name, paramAlarmsPrefix)
+}
+
+// IsState reports whether the param is a state driven by the state machines of the model,
+// such a param can't be pinned, the params derived from it can
+func (r ParamRef) IsState() bool {
+	return r.Name == ParamOperatingMode || r.Name == ParamChargerStage
+}
+
+// Set sets the param to the value, the alarms are raised by a non-zero value.
+// The states are left unchanged by a value out of their enums
+func (r ParamRef) Set(params *UpsParams, value float32) {
+	switch {
+	case r.Name == ParamOperatingMode:
+		if isEnumValue(value, len(operatingModeNames)) {
+			params.OperatingMode = OperatingMode(value)
+		}
+	case r.Name == ParamChargerStage:
+		if isEnumValue(value, len(chargerStageNames)) {
+			params.ChargerStage = ChargerStage(value)
+		}
+	case strings.HasPrefix(r.Name, paramAlarmsPrefix):
+		*params.Alarms.ByName()[strings.TrimPrefix(r.Name, paramAlarmsPrefix)] = value != 0
+	default:
+		*params.MeasuredParams()[r.Name][r.Index] = value
+	}
+}
+
+// isEnumValue reports whether the value is an integer from 0 to n-1
+func isEnumValue(value float32, n int) bool {
+	return value >= 0 && value < float32(n) && value == float32(int(value))
+}
@@ -0,0 +1,64 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParseParamRef(t *testing.T) {
+	testCases := []struct {
+		name    string
+		param   string
+		isValid bool
+	}{
+		{"measured", "input_ac_voltage", true},
+		{"indexed", "battery_temp[3]", true},
+		{"alarm", "alarms.low_battery", true},
+		{"operating mode", "operating_mode", true},
+		{"unknown", "invalid", false},
+		{"unknown alarm", "alarms.invalid", false},
+		{"index out of range", "battery_temp[4]", false},
+		{"invalid index", "battery_temp[a]", false},
+		{"index not supported", "operating_mode[1]", false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseParamRef(tc.param)
+			if tc.isValid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func Test_ParamRef_Set(t *testing.T) {
+	var params UpsParams
+	param, _ := ParseParamRef("battery_temp[2]")
+	param.Set(&params, 40)
+	assert.Equal(t, float32(40), params.Batteries[2].Temp)
+	assert.False(t, param.IsDiscrete())
+
+	param, _ = ParseParamRef("alarms.on_bypass")
+	param.Set(&params, 1)
+	assert.True(t, params.Alarms.OnBypass)
+	assert.True(t, param.IsDiscrete())
+
+	param, _ = ParseParamRef("operating_mode")
+	param.Set(&params, float32(ModeOnBattery))
+	assert.Equal(t, ModeOnBattery, params.OperatingMode)
+	assert.True(t, param.IsState())
+	param.Set(&params, 1.5)
+	param.Set(&params, float32(len(operatingModeNames)))
+	param.Set(&params, -1)
+	assert.Equal(t, ModeOnBattery, params.OperatingMode, "not an operating mode")
+}
+
+func Test_ParamRef_String(t *testing.T) {
+	param, _ := ParseParamRef("battery_temp")
+	assert.Equal(t, "battery_temp[0]", param.String())
+	param, _ = ParseParamRef("input_ac_voltage")
+	assert.Equal(t, "input_ac_voltage", param.String())
+}
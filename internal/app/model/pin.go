@@ -0,0 +1,31 @@
+package model
+
+import (
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+)
+
+// maxPinDuration limits the expiry of a pin, sec
+const maxPinDuration = 30 * 24 * 3600
+
+// Pin holds the param at the value while the rest of the model keeps running and reacts to it
+type Pin struct {
+	Param   string     `json:"param" example:"battery_temp[2]"`
+	Value   float32    `json:"value" example:"45"`
+	Expires *time.Time `json:"expires,omitempty" example:"2024-07-01T12:10:00Z"` // simulated time, never if absent
+}
+
+// PinForm pins the param given by the path
+type PinForm struct {
+	Value    *float32 `json:"value" example:"45"`
+	Duration float64  `json:"duration" example:"600"` // sec, until unpinned if 0
+}
+
+func (form PinForm) Validate() error {
+	return validation.ValidateStruct(
+		&form,
+		validation.Field(&form.Value, validation.NotNil),
+		validation.Field(&form.Duration, validation.Min(float64(0)), validation.Max(float64(maxPinDuration))),
+	)
+}
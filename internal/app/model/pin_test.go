@@ -0,0 +1,30 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/alex11prog/ups-imitator/internal/app/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_PinForm_Validate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		form    PinForm
+		isValid bool
+	}{
+		{"valid", PinForm{Value: utils.NewP(float32(45)), Duration: 600}, true},
+		{"valid, zero value, no expiry", PinForm{Value: utils.NewP(float32(0))}, true},
+		{"no value", PinForm{Duration: 600}, false},
+		{"negative duration", PinForm{Value: utils.NewP(float32(45)), Duration: -1}, false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.isValid {
+				assert.NoError(t, tc.form.Validate())
+			} else {
+				assert.Error(t, tc.form.Validate())
+			}
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package model
+
+// Rectifier topologies of the UPS input
+const (
+	RectifierIgbt        = "igbt"         // active front end with power factor correction
+	RectifierSixPulse    = "six_pulse"    // thyristor bridge
+	RectifierTwelvePulse = "twelve_pulse" // two phase-shifted thyristor bridges
+)
+
+// rectifierParams are the defaults of a rectifier topology at the rated load
+type rectifierParams struct {
+	displacementFactor float32 // cos φ of the fundamental current
+	thd                float32 // percent, total harmonic distortion of the input current
+}
+
+var builtinRectifierParams = map[string]rectifierParams{
+	RectifierIgbt:        {displacementFactor: 0.99, thd: 3},
+	RectifierSixPulse:    {displacementFactor: 0.85, thd: 30},
+	RectifierTwelvePulse: {displacementFactor: 0.9, thd: 10},
+}
@@ -0,0 +1,23 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/alex11prog/ups-imitator/internal/app/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_OutputConfig_Rectifier(t *testing.T) {
+	conf := model.TestConfig(t).Output
+	conf.Rectifier = model.RectifierIgbt
+	igbtThd := conf.RectifierRatedThd()
+	igbtFactor := conf.RectifierDisplacementFactor()
+	conf.Rectifier = model.RectifierSixPulse
+	assert.Greater(t, conf.RectifierRatedThd(), igbtThd)
+	assert.Less(t, conf.RectifierDisplacementFactor(), igbtFactor)
+
+	conf.RectifierThd = 5
+	conf.RectifierPowerFactor = 0.95
+	assert.Equal(t, float32(5), conf.RectifierRatedThd())
+	assert.Equal(t, float32(0.95), conf.RectifierDisplacementFactor())
+}
@@ -6,6 +6,7 @@ const (
 	RegInputAcCurrent      uint16 = 0x0002
 	RegBatteryGroupVoltage uint16 = 0x0004
 	RegBatteryGroupCurrent uint16 = 0x0006
+	RegInputFrequency      uint16 = 0x0008
 	RegBattery1Voltage     uint16 = 0x0010
 	RegBattery1Temp        uint16 = 0x0012
 	RegBattery1Res         uint16 = 0x0014
@@ -19,10 +20,70 @@ const (
 	RegBattery4Temp        uint16 = 0x0042
 	RegBattery4Res         uint16 = 0x0044
 
+	// Extended holding registers, sent as a separate block
+	RegExtParamsStart uint16 = 0x0050
+	RegChargerStage   uint16 = 0x0050 // uint16, see ChargerStage
+	RegOperatingMode  uint16 = 0x0051 // uint16, see OperatingMode
+	RegRuntime        uint16 = 0x0052 // min, estimated time to empty
+	RegBattery1Faults uint16 = 0x0054 // uint16, see BatteryFaults, battery i: + i
+
+	RegOutputAcVoltage     uint16 = 0x0060
+	RegOutputAcCurrent     uint16 = 0x0062
+	RegOutputFrequency     uint16 = 0x0064
+	RegOutputActivePower   uint16 = 0x0066
+	RegOutputApparentPower uint16 = 0x0068
+	RegLoadPercent         uint16 = 0x006A
+	RegInverterEfficiency  uint16 = 0x006C
+
+	// per phase: L1, L2 = L1 + 2, L3 = L1 + 4
+	RegPhase1InputAcVoltage  uint16 = 0x0070
+	RegPhase1InputAcCurrent  uint16 = 0x0076
+	RegPhase1OutputAcVoltage uint16 = 0x007C
+	RegPhase1OutputAcCurrent uint16 = 0x0082
+
+	RegGeneratorState     uint16 = 0x0090 // uint16, see GeneratorState
+	RegAtsSource          uint16 = 0x0091 // uint16, see AtsSource
+	RegGeneratorFuelLevel uint16 = 0x0092 // from 0 to 1
+	RegGeneratorVoltage   uint16 = 0x0094
+	RegGeneratorFrequency uint16 = 0x0096
+
+	RegInputActivePower   uint16 = 0x00A0
+	RegInputApparentPower uint16 = 0x00A2
+	RegInputPowerFactor   uint16 = 0x00A4
+	RegInputCurrentThd    uint16 = 0x00A6 // percent
+	RegOutputPowerFactor  uint16 = 0x00A8
+
+	RegBatteryTestResult uint16 = 0x00AA // uint16, see BatteryTestResult
+	RegBatteryTestTime   uint16 = 0x00AC // uint32, unix time of the start, 0 if never run
+	RegBatteryTestResist uint16 = 0x00AE // mOhm
+	RegBatteryTestSoh    uint16 = 0x00B0 // from 0 to 1
+
+	RegExtParamsEnd uint16 = 0x00B2 // first register after the block
+
+	// Command register, written by the monitoring, read and cleared by the imitator
+	RegCommand uint16 = 0x00C0
+	// Status of the last command, written by the imitator before clearing RegCommand
+	RegCommandStatus uint16 = 0x00C1
+
+	// Commands
+	CommandNone        uint16 = 0
+	CommandBatteryTest uint16 = 1
+
+	// Command statuses
+	CommandStatusNone     uint16 = 0
+	CommandStatusDone     uint16 = 1
+	CommandStatusRejected uint16 = 2 // not possible now, e.g. in manual mode
+	CommandStatusUnknown  uint16 = 3
+
 	// Coils
 	// Alarms
-	RegAlarmUpcInBatteryMode = 0x0000
-	RegAlarmLowBattery       = 0x0001
-	RegAlarmOverload         = 0x0002
-	NumOfAlarm               = 3
+	RegAlarmUpcInBatteryMode  = 0x0000
+	RegAlarmLowBattery        = 0x0001
+	RegAlarmOverload          = 0x0002
+	RegAlarmPhaseLoss         = 0x0003
+	RegAlarmPhaseImbalance    = 0x0004
+	RegAlarmInputFault        = 0x0005
+	RegAlarmOnBypass          = 0x0006
+	RegAlarmBypassUnavailable = 0x0007
+	NumOfAlarm                = 8
 )
@@ -0,0 +1,288 @@
+package model
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alex11prog/ups-imitator/internal/app/utils"
+	validation "github.com/go-ozzo/ozzo-validation"
+)
+
+// unixMillisThreshold separates the timestamps in ms from the ones in sec
+const unixMillisThreshold = 1e11
+
+// TelemetrySample is a recorded value of a column
+type TelemetrySample struct {
+	Time  time.Duration // since the start of the recording
+	Value float32
+}
+
+// TelemetrySeries is a column of the recording sorted by time
+type TelemetrySeries []TelemetrySample
+
+// Value returns the value after elapsed since the start of the recording: the linear interpolation between
+// the samples or, if step is true, the last sample. Before the first sample it is the first value
+func (s TelemetrySeries) Value(elapsed time.Duration, step bool) float32 {
+	i := sort.Search(len(s), func(i int) bool { return s[i].Time > elapsed })
+	switch {
+	case i == 0:
+		return s[0].Value
+	case i == len(s) || step:
+		return s[i-1].Value
+	}
+	x0, x1, x := float32(s[i-1].Time.Seconds()), float32(s[i].Time.Seconds()), float32(elapsed.Seconds())
+	return utils.LinearInterpolate(x0, s[i-1].Value, x1, s[i].Value, x)
+}
+
+// Recording is the recorded UPS telemetry by column
+type Recording struct {
+	Columns  map[string]TelemetrySeries
+	Duration time.Duration // up to the last sample
+}
+
+// ParseRecording parses a CSV or an OpenTSDB JSON export: a CSV has a header row, the first column is the time
+// (unix sec or ms, RFC 3339 or sec since the start) and the others are the columns, empty values are skipped.
+// The JSON is an array of series with the metric name as the column and the "dps" timestamps
+func ParseRecording(data []byte) (*Recording, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && (trimmed[0] == '[' || trimmed[0] == '{') {
+		return parseRecordingJson(trimmed)
+	}
+	return parseRecordingCsv(data)
+}
+
+func readRecordingFile(path string) (*Recording, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseRecording(data)
+}
+
+func parseRecordingCsv(data []byte) (*Recording, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) < 2 || len(records[0]) < 2 {
+		return nil, errors.New("header and at least 1 row with time and a column required")
+	}
+	header := records[0]
+	raw := map[string][]rawSample{}
+	for i, record := range records[1:] {
+		ts, err := parseTimestamp(strings.TrimSpace(record[0]))
+		if err != nil {
+			return nil, fmt.Errorf("row %d: invalid time: %v", i+2, err)
+		}
+		for j := 1; j < len(record); j++ {
+			field := strings.TrimSpace(record[j])
+			if field == "" {
+				continue
+			}
+			value, err := strconv.ParseFloat(field, 32)
+			if err != nil {
+				return nil, fmt.Errorf("row %d: column %q: %v", i+2, header[j], err)
+			}
+			name := strings.TrimSpace(header[j])
+			raw[name] = append(raw[name], rawSample{ts, float32(value)})
+		}
+	}
+	return newRecording(raw)
+}
+
+// openTsdbSeries is a series of the OpenTSDB query response, dps is either {"ts": value} or [[ts, value]]
+type openTsdbSeries struct {
+	Metric string          `json:"metric"`
+	Dps    json.RawMessage `json:"dps"`
+}
+
+func parseRecordingJson(data []byte) (*Recording, error) {
+	if data[0] == '{' { // a single series
+		data = append(append([]byte{'['}, data...), ']')
+	}
+	var series []openTsdbSeries
+	if err := json.Unmarshal(data, &series); err != nil {
+		return nil, err
+	}
+	raw := map[string][]rawSample{}
+	for _, s := range series {
+		if _, ok := raw[s.Metric]; ok {
+			return nil, fmt.Errorf("duplicate metric: %q", s.Metric)
+		}
+		samples, err := parseDps(s.Dps)
+		if err != nil {
+			return nil, fmt.Errorf("metric %q: %v", s.Metric, err)
+		}
+		raw[s.Metric] = samples
+	}
+	return newRecording(raw)
+}
+
+func parseDps(data json.RawMessage) ([]rawSample, error) {
+	var samples []rawSample
+	var byTime map[string]float32
+	if err := json.Unmarshal(data, &byTime); err == nil {
+		for ts, value := range byTime {
+			t, err := parseTimestamp(ts)
+			if err != nil {
+				return nil, err
+			}
+			samples = append(samples, rawSample{t, value})
+		}
+		return samples, nil
+	}
+	var pairs [][2]float64
+	if err := json.Unmarshal(data, &pairs); err != nil {
+		return nil, errors.New("dps must be an object or an array of pairs")
+	}
+	for _, pair := range pairs {
+		samples = append(samples, rawSample{unixTime(pair[0]), float32(pair[1])})
+	}
+	return samples, nil
+}
+
+// rawSample is a sample with the time as recorded
+type rawSample struct {
+	time  float64 // sec
+	value float32
+}
+
+// parseTimestamp returns the time in sec: unix sec or ms, RFC 3339 or sec since the start
+func parseTimestamp(s string) (float64, error) {
+	if v, err := strconv.ParseFloat(s, 64); err == nil {
+		return unixTime(v), nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return 0, err
+	}
+	return float64(t.UnixNano()) / 1e9, nil
+}
+
+func unixTime(v float64) float64 {
+	if v > unixMillisThreshold {
+		return v / 1000
+	}
+	return v
+}
+
+// newRecording sorts the samples and makes their time relative to the earliest one
+func newRecording(raw map[string][]rawSample) (*Recording, error) {
+	start := math.Inf(1)
+	for _, samples := range raw {
+		for _, sample := range samples {
+			start = min(start, sample.time)
+		}
+	}
+	if math.IsInf(start, 1) {
+		return nil, errors.New("no samples")
+	}
+	rec := &Recording{Columns: map[string]TelemetrySeries{}}
+	for name, samples := range raw {
+		sort.Slice(samples, func(i, j int) bool { return samples[i].time < samples[j].time })
+		series := make(TelemetrySeries, len(samples))
+		for i, sample := range samples {
+			series[i] = TelemetrySample{time.Duration((sample.time - start) * float64(time.Second)), sample.value}
+			if i > 0 && series[i].Time == series[i-1].Time {
+				return nil, fmt.Errorf("column %q: duplicate time %v", name, series[i].Time)
+			}
+		}
+		rec.Columns[name] = series
+		rec.Duration = max(rec.Duration, series[len(series)-1].Time)
+	}
+	return rec, nil
+}
+
+// Mapping maps the columns of the recording onto the params: by the columns config or, if absent there,
+// by the column name itself. The other columns are ignored
+func (rec *Recording) Mapping(columns map[string]string) (map[string]ParamRef, error) {
+	res := map[string]ParamRef{}
+	for column, name := range columns {
+		if _, ok := rec.Columns[column]; !ok {
+			return nil, fmt.Errorf("column not recorded: %q", column)
+		}
+		param, err := ParseParamRef(name)
+		if err != nil {
+			return nil, err
+		}
+		res[column] = param
+	}
+	for column := range rec.Columns {
+		if _, ok := columns[column]; ok {
+			continue
+		}
+		if param, err := ParseParamRef(column); err == nil {
+			res[column] = param
+		}
+	}
+	if len(res) == 0 {
+		return nil, errors.New("no column mapped onto the params")
+	}
+	return res, nil
+}
+
+// ReplayConfig describes the replay of recorded telemetry
+type ReplayConfig struct {
+	File    string            `toml:"file"`    // CSV or OpenTSDB JSON, relative to the config file, optional
+	Speed   float64           `toml:"speed"`   // of the replay relative to the simulated time, 1 if not set
+	Loop    bool              `toml:"loop"`    // start over at the end
+	Columns map[string]string `toml:"columns"` // column -> param, e.g. "ups.temp" = "battery_temp[0]"
+
+	Recording *Recording `toml:"-"` // read from File
+}
+
+func (conf ReplayConfig) Validate() error {
+	if err := validation.ValidateStruct(
+		&conf,
+		validation.Field(&conf.Speed, validation.Required, validation.Min(0.01), validation.Max(float64(1000))),
+	); err != nil {
+		return err
+	}
+	for column, name := range conf.Columns {
+		if _, err := ParseParamRef(name); err != nil {
+			return fmt.Errorf("column %q: %v", column, err)
+		}
+	}
+	if conf.Recording != nil {
+		_, err := conf.Recording.Mapping(conf.Columns)
+		return err
+	}
+	return nil
+}
+
+// Replay statuses
+const (
+	ReplayIdle     = "idle" // loaded, not started
+	ReplayRunning  = "running"
+	ReplayFinished = "finished"
+	ReplayStopped  = "stopped"
+)
+
+// ReplayStartForm overrides the speed and the looping of the config
+type ReplayStartForm struct {
+	Speed *float64 `json:"speed" example:"10"`
+	Loop  *bool    `json:"loop" example:"true"`
+}
+
+// ReplayProgress is the progress of the loaded recording
+type ReplayProgress struct {
+	Status    string            `json:"status" enums:"idle,running,finished,stopped" example:"running"`
+	Position  float32           `json:"position" example:"125"`  // sec of the recording
+	Duration  float32           `json:"duration" example:"3600"` // sec
+	Speed     float64           `json:"speed" example:"10"`
+	Loop      bool              `json:"loop" example:"true"`
+	Iteration int               `json:"iteration" example:"1"` // from 1
+	Columns   map[string]string `json:"columns"`               // column -> param
+}
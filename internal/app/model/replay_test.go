@@ -0,0 +1,87 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ParseRecording_csv(t *testing.T) {
+	testCases := []struct {
+		name string
+		data string
+	}{
+		{"unix sec", "time,input_ac_voltage,ups.temp\n1700000000,220,25\n1700000010,200,\n1700000020,0,27\n"},
+		{"unix ms", "time,input_ac_voltage,ups.temp\n1700000000000,220,25\n1700000010000,200,\n1700000020000,0,27\n"},
+		{"rfc3339", "time,input_ac_voltage,ups.temp\n2024-01-01T00:00:00Z,220,25\n2024-01-01T00:00:10Z,200,\n2024-01-01T00:00:20Z,0,27\n"},
+		{"relative", "# comment\ntime,input_ac_voltage,ups.temp\n0,220,25\n10,200,\n20,0,27\n"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec, err := ParseRecording([]byte(tc.data))
+			require.NoError(t, err)
+			assert.Equal(t, 20*time.Second, rec.Duration)
+			assert.Len(t, rec.Columns["input_ac_voltage"], 3)
+			assert.Len(t, rec.Columns["ups.temp"], 2, "empty values are skipped")
+			assert.Equal(t, float32(210), rec.Columns["input_ac_voltage"].Value(5*time.Second, false))
+		})
+	}
+}
+
+func Test_ParseRecording_json(t *testing.T) {
+	data := `[
+		{"metric": "input_ac_voltage", "tags": {"host": "ups1"}, "dps": {"1700000000": 220, "1700000010": 200}},
+		{"metric": "alarms.upc_in_battery_mode", "dps": [[1700000005, 0], [1700000010, 1]]}
+	]`
+	rec, err := ParseRecording([]byte(data))
+	require.NoError(t, err)
+	assert.Equal(t, 10*time.Second, rec.Duration)
+	assert.Equal(t, TelemetrySeries{{0, 220}, {10 * time.Second, 200}}, rec.Columns["input_ac_voltage"])
+	assert.Equal(t, 5*time.Second, rec.Columns["alarms.upc_in_battery_mode"][0].Time)
+
+	_, err = ParseRecording([]byte(`{"metric": "soc", "dps": {"1700000000": 1}}`))
+	assert.NoError(t, err, "single series")
+
+	for _, invalid := range []string{
+		`[{"metric": "soc", "dps": {}}, {"metric": "soc", "dps": {}}]`,
+		`[{"metric": "soc", "dps": "invalid"}]`,
+		`[]`,
+		"time,soc\n",
+		"time,soc\ninvalid,1\n",
+		"time,soc\n0,invalid\n",
+		"time,soc\n0,1\n0,1\n",
+	} {
+		_, err := ParseRecording([]byte(invalid))
+		assert.Error(t, err, invalid)
+	}
+}
+
+func Test_TelemetrySeries_Value(t *testing.T) {
+	series := TelemetrySeries{{time.Second, 10}, {3 * time.Second, 30}}
+	assert.Equal(t, float32(10), series.Value(0, false), "before the first sample")
+	assert.Equal(t, float32(20), series.Value(2*time.Second, false))
+	assert.Equal(t, float32(10), series.Value(2*time.Second, true))
+	assert.Equal(t, float32(30), series.Value(time.Hour, false), "after the last sample")
+}
+
+func Test_Recording_Mapping(t *testing.T) {
+	rec, err := ParseRecording([]byte("time,input_ac_voltage,ups.temp,ups.other\n0,220,25,1\n"))
+	require.NoError(t, err)
+	mapping, err := rec.Mapping(map[string]string{"ups.temp": "battery_temp[1]"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]ParamRef{
+		"input_ac_voltage": {Name: "input_ac_voltage"},
+		"ups.temp":         {Name: "battery_temp", Index: 1},
+	}, mapping)
+
+	_, err = rec.Mapping(map[string]string{"missing": "soc"})
+	assert.Error(t, err)
+	_, err = rec.Mapping(map[string]string{"ups.temp": "invalid"})
+	assert.Error(t, err)
+
+	rec, _ = ParseRecording([]byte("time,ups.other\n0,1\n"))
+	_, err = rec.Mapping(nil)
+	assert.Error(t, err, "no column mapped")
+}
@@ -0,0 +1,228 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Scenario actions
+const (
+	ActionMainsOff          = "mains_off"
+	ActionMainsOn           = "mains_on"
+	ActionSetInput          = "set_input"           // voltage, frequency
+	ActionSetLoad           = "set_load"            // power, optional ramp duration
+	ActionLoadProfile       = "load_profile"        // profile
+	ActionSetBattery        = "set_battery"         // battery, temp, resist
+	ActionAddBatteryFault   = "add_battery_fault"   // battery, fault
+	ActionClearBatteryFault = "clear_battery_fault" // battery, fault
+	ActionRequestBypass     = "request_bypass"
+	ActionReturnFromBypass  = "return_from_bypass"
+	ActionFailInverter      = "fail_inverter"
+	ActionRepairInverter    = "repair_inverter"
+	ActionLog               = "log" // message
+)
+
+// Duration is a duration written as a string, e.g. "1m30s"
+type Duration time.Duration
+
+func (d *Duration) UnmarshalYAML(node *yaml.Node) error {
+	var s string
+	if err := node.Decode(&s); err != nil {
+		return err
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// Condition compares a param with the value, the param is named as its sensor, see SensorNames
+type Condition struct {
+	Param string  `toml:"param" yaml:"param" json:"param"`
+	Index int     `toml:"index" yaml:"index" json:"index"` // of the battery or the phase
+	Op    string  `toml:"op" yaml:"op" json:"op"`          // <, <=, >, >=, ==, !=
+	Value float32 `toml:"value" yaml:"value" json:"value"`
+}
+
+// Eval reports whether the condition holds for the params
+func (c *Condition) Eval(params *UpsParams) bool {
+	value := *params.MeasuredParams()[c.Param][c.Index]
+	switch c.Op {
+	case "<":
+		return value < c.Value
+	case "<=":
+		return value <= c.Value
+	case ">":
+		return value > c.Value
+	case ">=":
+		return value >= c.Value
+	case "==":
+		return value == c.Value
+	}
+	return value != c.Value
+}
+
+func (c *Condition) Validate() error {
+	if err := CheckSensorName(c.Param); err != nil {
+		return err
+	}
+	var params UpsParams
+	if n := len(params.MeasuredParams()[c.Param]); c.Index < 0 || c.Index >= n {
+		return fmt.Errorf("index out of range: %d, expected less %d", c.Index, n)
+	}
+	switch c.Op {
+	case "<", "<=", ">", ">=", "==", "!=":
+		return nil
+	}
+	return fmt.Errorf("unknown op: %q", c.Op)
+}
+
+// ScenarioStep is a step of the scenario. The step is skipped unless the If condition holds, then it waits
+// until At since the start of the scenario or for Wait, then until the WaitUntil condition holds,
+// then performs the action and finally runs the nested steps in a loop
+type ScenarioStep struct {
+	If        *Condition `yaml:"if" json:"if,omitempty"`
+	At        Duration   `yaml:"at" json:"at,omitempty" swaggertype:"string"` // since the start of the scenario
+	Wait      Duration   `yaml:"wait" json:"wait,omitempty" swaggertype:"string"`
+	WaitUntil *Condition `yaml:"wait_until" json:"wait_until,omitempty"`
+	Timeout   Duration   `yaml:"timeout" json:"timeout,omitempty" swaggertype:"string"` // of WaitUntil, the scenario fails after, 0 - none
+
+	Action    string   `yaml:"action" json:"action,omitempty"`
+	Voltage   *float32 `yaml:"voltage" json:"voltage,omitempty"`                        // V
+	Frequency *float32 `yaml:"frequency" json:"frequency,omitempty"`                    // Hz
+	Power     float32  `yaml:"power" json:"power,omitempty"`                            // W
+	Duration  Duration `yaml:"duration" json:"duration,omitempty" swaggertype:"string"` // of the load ramp
+	Profile   string   `yaml:"profile" json:"profile,omitempty"`
+	Battery   int      `yaml:"battery" json:"battery,omitempty"`
+	Temp      *float32 `yaml:"temp" json:"temp,omitempty"`     // °C
+	Resist    *float32 `yaml:"resist" json:"resist,omitempty"` // mOhm
+	Fault     string   `yaml:"fault" json:"fault,omitempty"`
+	Message   string   `yaml:"message" json:"message,omitempty"`
+
+	Repeat int            `yaml:"repeat" json:"repeat,omitempty"` // times, 0 - until the Until condition holds
+	Until  *Condition     `yaml:"until" json:"until,omitempty"`   // checked before each iteration
+	Steps  []ScenarioStep `yaml:"steps" json:"steps,omitempty"`
+}
+
+func (s *ScenarioStep) Validate() error {
+	for _, c := range []*Condition{s.If, s.WaitUntil, s.Until} {
+		if c == nil {
+			continue
+		}
+		if err := c.Validate(); err != nil {
+			return err
+		}
+	}
+	if s.At < 0 || s.Wait < 0 || s.Timeout < 0 || s.Duration < 0 {
+		return errors.New("durations must not be negative")
+	}
+	if s.At > 0 && s.Wait > 0 {
+		return errors.New("at and wait are exclusive")
+	}
+	if err := s.validateAction(); err != nil {
+		return err
+	}
+	if len(s.Steps) > 0 {
+		if s.Repeat < 0 || s.Repeat == 0 && s.Until == nil {
+			return errors.New("loop requires repeat or until")
+		}
+		return ScenarioSteps(s.Steps).Validate()
+	}
+	if s.Repeat != 0 || s.Until != nil {
+		return errors.New("repeat and until require steps")
+	}
+	return nil
+}
+
+func (s *ScenarioStep) validateAction() error {
+	switch s.Action {
+	case "", ActionMainsOff, ActionMainsOn, ActionRequestBypass, ActionReturnFromBypass, ActionFailInverter, ActionRepairInverter, ActionLog:
+	case ActionSetInput:
+		if s.Voltage == nil && s.Frequency == nil {
+			return errors.New("set_input requires voltage or frequency")
+		}
+	case ActionSetLoad:
+		if s.Power < 0 {
+			return errors.New("power must not be negative")
+		}
+	case ActionLoadProfile:
+		if s.Profile == "" {
+			return errors.New("load_profile requires profile")
+		}
+	case ActionSetBattery:
+		if s.Temp == nil && s.Resist == nil {
+			return errors.New("set_battery requires temp or resist")
+		}
+	case ActionAddBatteryFault, ActionClearBatteryFault:
+		if _, err := ParseBatteryFault(s.Fault); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown action: %q", s.Action)
+	}
+	if s.Battery < 0 || s.Battery >= len(UpsParams{}.Batteries) {
+		return fmt.Errorf("battery out of range: %d", s.Battery)
+	}
+	return nil
+}
+
+type ScenarioSteps []ScenarioStep
+
+func (steps ScenarioSteps) Validate() error {
+	if len(steps) == 0 {
+		return errors.New("at least 1 step required")
+	}
+	for i := range steps {
+		if err := steps[i].Validate(); err != nil {
+			return fmt.Errorf("step %d: %v", i+1, err)
+		}
+	}
+	return nil
+}
+
+// Scenario is a timeline of actions, see ScenarioStep
+type Scenario struct {
+	Name  string         `yaml:"name" json:"name"`
+	Steps []ScenarioStep `yaml:"steps" json:"steps"`
+}
+
+// ParseScenario parses the scenario from YAML or JSON
+func ParseScenario(data []byte) (*Scenario, error) {
+	var sc Scenario
+	if err := yaml.Unmarshal(data, &sc); err != nil {
+		return nil, err
+	}
+	if err := ScenarioSteps(sc.Steps).Validate(); err != nil {
+		return nil, err
+	}
+	return &sc, nil
+}
+
+// Scenario statuses
+const (
+	ScenarioIdle     = "idle" // uploaded, not started
+	ScenarioRunning  = "running"
+	ScenarioFinished = "finished"
+	ScenarioStopped  = "stopped"
+	ScenarioFailed   = "failed"
+)
+
+// ScenarioProgress is the progress of the uploaded scenario
+type ScenarioProgress struct {
+	Name      string  `json:"name" example:"night outage"`
+	Status    string  `json:"status" enums:"idle,running,finished,stopped,failed" example:"running"`
+	Step      string  `json:"step" example:"3.1"`    // the current step, nested steps are separated by dots
+	Iteration int     `json:"iteration" example:"2"` // of the innermost loop, from 1, 0 - not in a loop
+	Elapsed   float32 `json:"elapsed" example:"125"` // sec since the start
+	Error     string  `json:"error,omitempty"`
+}
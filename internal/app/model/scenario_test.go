@@ -0,0 +1,88 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParseScenario(t *testing.T) {
+	data := `
+name: night outage
+steps:
+  - at: 5m
+    action: mains_off
+  - wait: 90s
+    action: mains_on
+  - at: 10m
+    action: set_load
+    power: 3000
+    duration: 30s
+  - at: 12m
+    action: set_battery
+    battery: 1
+    temp: 45
+  - wait_until: {param: soc, op: "<", value: 0.3}
+    timeout: 2h
+    action: log
+    message: low soc
+  - repeat: 3
+    steps:
+      - action: mains_off
+      - wait: 10s
+        action: mains_on
+`
+	sc, err := ParseScenario([]byte(data))
+	assert.NoError(t, err)
+	assert.Equal(t, "night outage", sc.Name)
+	assert.Len(t, sc.Steps, 6)
+	assert.Equal(t, Duration(5*time.Minute), sc.Steps[0].At)
+	assert.Equal(t, Duration(90*time.Second), sc.Steps[1].Wait)
+	assert.Equal(t, float32(3000), sc.Steps[2].Power)
+	assert.Equal(t, float32(45), *sc.Steps[3].Temp)
+	assert.Equal(t, &Condition{Param: SensorSOC, Op: "<", Value: 0.3}, sc.Steps[4].WaitUntil)
+	assert.Len(t, sc.Steps[5].Steps, 2)
+}
+
+func Test_ScenarioStep_Validate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		data    string
+		isValid bool
+	}{
+		{"valid, json", `{"steps": [{"action": "mains_off"}, {"wait": "1m", "action": "mains_on"}]}`, true},
+		{"invalid, no steps", `name: empty`, false},
+		{"invalid, unknown action", `steps: [{action: invalid}]`, false},
+		{"invalid, duration", `steps: [{wait: 10, action: mains_on}]`, false},
+		{"invalid, at and wait", `steps: [{at: 1m, wait: 1m}]`, false},
+		{"invalid, set_input without params", `steps: [{action: set_input}]`, false},
+		{"invalid, unknown fault", `steps: [{action: add_battery_fault, fault: invalid}]`, false},
+		{"invalid, battery", `steps: [{action: set_battery, battery: 4, temp: 40}]`, false},
+		{"invalid, unknown param", `steps: [{wait_until: {param: invalid, op: "<", value: 1}}]`, false},
+		{"invalid, index", `steps: [{if: {param: phase_input_ac_voltage, index: 3, op: "<", value: 1}}]`, false},
+		{"invalid, op", `steps: [{until: {param: soc, op: "=", value: 1}, steps: [{action: log}]}]`, false},
+		{"invalid, loop without repeat", `steps: [{steps: [{action: log}]}]`, false},
+		{"invalid, repeat without steps", `steps: [{repeat: 2, action: log}]`, false},
+		{"invalid, nested", `steps: [{repeat: 2, steps: [{action: invalid}]}]`, false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseScenario([]byte(tc.data))
+			if tc.isValid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func Test_Condition_Eval(t *testing.T) {
+	params := UpsParams{SOC: 0.25}
+	params.Phases[1].InputAcVoltage = 210
+	assert.True(t, (&Condition{Param: SensorSOC, Op: "<", Value: 0.3}).Eval(&params))
+	assert.False(t, (&Condition{Param: SensorSOC, Op: ">=", Value: 0.3}).Eval(&params))
+	assert.True(t, (&Condition{Param: SensorSOC, Op: "!=", Value: 0.3}).Eval(&params))
+	assert.True(t, (&Condition{Param: SensorPhaseInputAcVoltage, Index: 1, Op: "==", Value: 210}).Eval(&params))
+}
@@ -0,0 +1,79 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+)
+
+// Scheduled actions besides mains_off, mains_on, set_load and load_profile of the scenario
+const (
+	ActionSetMode     = "set_mode"     // mode
+	ActionBatteryTest = "battery_test" // starts the battery self-test
+)
+
+// Modes of set_mode
+const (
+	ModeAuto   = "auto"
+	ModeManual = "manual"
+)
+
+// ScheduledJob is an action run on a cron schedule
+type ScheduledJob struct {
+	Name    string  `toml:"name" json:"name" example:"maintenance"`
+	Cron    string  `toml:"cron" json:"cron" example:"0 2 * * sat"` // minute hour day-of-month month day-of-week
+	Action  string  `toml:"action" json:"action" enums:"mains_off,mains_on,set_load,load_profile,set_mode,battery_test" example:"mains_off"`
+	Power   float32 `toml:"power" json:"power,omitempty" example:"1500"`                   // W, of set_load
+	Profile string  `toml:"profile" json:"profile,omitempty" example:"daily"`              // of load_profile
+	Mode    string  `toml:"mode" json:"mode,omitempty" enums:"auto,manual" example:"auto"` // of set_mode
+}
+
+func (job ScheduledJob) Validate() error {
+	if _, err := ParseCron(job.Cron); err != nil {
+		return fmt.Errorf("cron: %v", err)
+	}
+	return validation.ValidateStruct(
+		&job,
+		validation.Field(&job.Name, validation.Required),
+		validation.Field(&job.Action, validation.Required, validation.In(ActionMainsOff, ActionMainsOn, ActionSetLoad, ActionLoadProfile, ActionSetMode, ActionBatteryTest)),
+		validation.Field(&job.Power, validation.Min(float32(0))),
+		validation.Field(&job.Profile, requiredIf(job.Action == ActionLoadProfile)),
+		validation.Field(&job.Mode, requiredIf(job.Action == ActionSetMode), validation.In(ModeAuto, ModeManual)),
+	)
+}
+
+// ScheduledJobs are validated together, the names must be unique
+type ScheduledJobs []ScheduledJob
+
+func (jobs ScheduledJobs) Validate() error {
+	names := map[string]bool{}
+	for i, job := range jobs {
+		if err := job.Validate(); err != nil {
+			return fmt.Errorf("job %d: %v", i, err)
+		}
+		if names[job.Name] {
+			return fmt.Errorf("job %d: duplicate name: %q", i, job.Name)
+		}
+		names[job.Name] = true
+	}
+	return nil
+}
+
+// ScheduleConfig describes the scheduled actions, e.g. a weekly maintenance window
+type ScheduleConfig struct {
+	WallClock bool          `toml:"wall_clock"` // the jobs run on the wall clock instead of the simulated time
+	Jobs      ScheduledJobs `toml:"jobs"`
+}
+
+func (conf ScheduleConfig) Validate() error {
+	return conf.Jobs.Validate()
+}
+
+// ScheduledJobStatus is a job with its runs
+type ScheduledJobStatus struct {
+	ScheduledJob
+	NextRun   *time.Time `json:"next_run,omitempty" example:"2024-07-06T02:00:00Z"` // absent if the schedule never fires
+	LastRun   *time.Time `json:"last_run,omitempty" example:"2024-06-29T02:00:00Z"`
+	LastError string     `json:"last_error,omitempty"`
+}
@@ -0,0 +1,48 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ScheduledJobs_Validate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		jobs    ScheduledJobs
+		isValid bool
+	}{
+		{
+			"valid",
+			ScheduledJobs{
+				{Name: "off", Cron: "0 2 * * sat", Action: ActionMainsOff},
+				{Name: "on", Cron: "30 2 * * sat", Action: ActionMainsOn},
+				{Name: "manual", Cron: "@daily", Action: ActionSetMode, Mode: ModeManual},
+				{Name: "test", Cron: "0 3 1 * *", Action: ActionBatteryTest},
+			},
+			true,
+		},
+		{"invalid cron", ScheduledJobs{{Name: "off", Cron: "0 2 * *", Action: ActionMainsOff}}, false},
+		{"no name", ScheduledJobs{{Cron: "@daily", Action: ActionMainsOff}}, false},
+		{"unknown action", ScheduledJobs{{Name: "off", Cron: "@daily", Action: "invalid"}}, false},
+		{"no profile", ScheduledJobs{{Name: "load", Cron: "@daily", Action: ActionLoadProfile}}, false},
+		{"invalid mode", ScheduledJobs{{Name: "mode", Cron: "@daily", Action: ActionSetMode, Mode: "invalid"}}, false},
+		{
+			"duplicate name",
+			ScheduledJobs{
+				{Name: "off", Cron: "0 2 * * sat", Action: ActionMainsOff},
+				{Name: "off", Cron: "0 3 * * sat", Action: ActionMainsOff},
+			},
+			false,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.isValid {
+				assert.NoError(t, tc.jobs.Validate())
+			} else {
+				assert.Error(t, tc.jobs.Validate())
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package model
+
+import (
+	"fmt"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+)
+
+// Sensors of the measured params, by the json name of the param.
+// A sensor of the batteries or the phases measures each of them
+const (
+	SensorInputAcVoltage       = "input_ac_voltage"
+	SensorInputAcCurrent       = "input_ac_current"
+	SensorInputFrequency       = "input_frequency"
+	SensorInputActivePower     = "input_active_power"
+	SensorInputApparentPower   = "input_apparent_power"
+	SensorInputPowerFactor     = "input_power_factor"
+	SensorInputCurrentThd      = "input_current_thd"
+	SensorBatGroupVoltage      = "bat_group_voltage"
+	SensorBatGroupCurrent      = "bat_group_current"
+	SensorLoadCurrent          = "load_current"
+	SensorRemainingBatCapacity = "remaining_battery_capacity"
+	SensorSOC                  = "soc"
+	SensorRuntime              = "runtime"
+	SensorOutputAcVoltage      = "output_ac_voltage"
+	SensorOutputAcCurrent      = "output_ac_current"
+	SensorOutputFrequency      = "output_frequency"
+	SensorOutputActivePower    = "output_active_power"
+	SensorOutputApparentPower  = "output_apparent_power"
+	SensorOutputPowerFactor    = "output_power_factor"
+	SensorLoadPercent          = "load_percent"
+	SensorInverterEfficiency   = "inverter_efficiency"
+	SensorBatteryVoltage       = "battery_voltage"
+	SensorBatteryTemp          = "battery_temp"
+	SensorBatteryResist        = "battery_resist"
+	SensorPhaseInputAcVoltage  = "phase_input_ac_voltage"
+	SensorPhaseInputAcCurrent  = "phase_input_ac_current"
+	SensorPhaseOutputAcVoltage = "phase_output_ac_voltage"
+	SensorPhaseOutputAcCurrent = "phase_output_ac_current"
+	SensorGeneratorVoltage     = "generator_voltage"
+	SensorGeneratorFrequency   = "generator_frequency"
+	SensorGeneratorFuelLevel   = "generator_fuel_level"
+)
+
+// SensorNames are the names of all sensors
+var SensorNames = []string{
+	SensorInputAcVoltage, SensorInputAcCurrent, SensorInputFrequency, SensorInputActivePower, SensorInputApparentPower,
+	SensorInputPowerFactor, SensorInputCurrentThd, SensorBatGroupVoltage, SensorBatGroupCurrent, SensorLoadCurrent,
+	SensorRemainingBatCapacity, SensorSOC, SensorRuntime, SensorOutputAcVoltage, SensorOutputAcCurrent,
+	SensorOutputFrequency, SensorOutputActivePower, SensorOutputApparentPower, SensorOutputPowerFactor,
+	SensorLoadPercent, SensorInverterEfficiency, SensorBatteryVoltage, SensorBatteryTemp, SensorBatteryResist,
+	SensorPhaseInputAcVoltage, SensorPhaseInputAcCurrent, SensorPhaseOutputAcVoltage, SensorPhaseOutputAcCurrent,
+	SensorGeneratorVoltage, SensorGeneratorFrequency, SensorGeneratorFuelLevel,
+}
+
+// SensorConfig describes the measurement error of a param. The reading is
+// (value·(1 + gain + drift) + offset) + gaussian noise + spikes, quantized to the resolution
+type SensorConfig struct {
+	Noise              float32 `toml:"noise" json:"noise" example:"0.01"`                          // relative standard deviation of the gaussian noise
+	Drift              float32 `toml:"drift" json:"drift" example:"0"`                             // relative standard deviation of the random walk drift after an hour
+	Resolution         float32 `toml:"resolution" json:"resolution" example:"0.1"`                 // quantization step of the ADC in the param units, 0 - none
+	Offset             float32 `toml:"offset" json:"offset" example:"0"`                           // calibration offset in the param units
+	Gain               float32 `toml:"gain" json:"gain" example:"0"`                               // relative calibration gain error
+	SpikeProbability   float32 `toml:"spike_probability" json:"spike_probability" example:"0"`     // from 0 to 1, of each reading
+	SpikeAmplitude     float32 `toml:"spike_amplitude" json:"spike_amplitude" example:"0"`         // relative
+	Stuck              bool    `toml:"stuck" json:"stuck" example:"false"`                         // the reading stays at the last value
+	DropoutProbability float32 `toml:"dropout_probability" json:"dropout_probability" example:"0"` // from 0 to 1, of each reading, the reading is 0
+}
+
+func (conf SensorConfig) Validate() error {
+	return validation.ValidateStruct(
+		&conf,
+		validation.Field(&conf.Noise, validation.Min(float32(0)), validation.Max(float32(1))),
+		validation.Field(&conf.Drift, validation.Min(float32(0)), validation.Max(float32(1))),
+		validation.Field(&conf.Resolution, validation.Min(float32(0))),
+		validation.Field(&conf.Gain, validation.Min(float32(-1)), validation.Max(float32(1))),
+		validation.Field(&conf.SpikeProbability, validation.Min(float32(0)), validation.Max(float32(1))),
+		validation.Field(&conf.SpikeAmplitude, validation.Min(float32(0))),
+		validation.Field(&conf.DropoutProbability, validation.Min(float32(0)), validation.Max(float32(1))),
+	)
+}
+
+// SensorsConfig are the sensors by name, the params without a sensor are measured exactly
+type SensorsConfig map[string]SensorConfig
+
+func (conf SensorsConfig) Validate() error {
+	for name, sensor := range conf {
+		if err := CheckSensorName(name); err != nil {
+			return err
+		}
+		if err := sensor.Validate(); err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// CheckSensorName returns an error if there is no sensor with the name
+func CheckSensorName(name string) error {
+	for _, sensor := range SensorNames {
+		if sensor == name {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown sensor: %q", name)
+}
+
+// MeasuredParams returns pointers to the measured params by the sensor name, see SensorNames
+func (p *UpsParams) MeasuredParams() map[string][]*float32 {
+	res := map[string][]*float32{
+		SensorInputAcVoltage:       {&p.InputAcVoltage},
+		SensorInputAcCurrent:       {&p.InputAcCurrent},
+		SensorInputFrequency:       {&p.InputFrequency},
+		SensorInputActivePower:     {&p.InputActivePower},
+		SensorInputApparentPower:   {&p.InputApparentPower},
+		SensorInputPowerFactor:     {&p.InputPowerFactor},
+		SensorInputCurrentThd:      {&p.InputCurrentThd},
+		SensorBatGroupVoltage:      {&p.BatGroupVoltage},
+		SensorBatGroupCurrent:      {&p.BatGroupCurrent},
+		SensorLoadCurrent:          {&p.LoadCurrent},
+		SensorRemainingBatCapacity: {&p.RemainingBatCapacity},
+		SensorSOC:                  {&p.SOC},
+		SensorRuntime:              {&p.Runtime},
+		SensorOutputAcVoltage:      {&p.OutputAcVoltage},
+		SensorOutputAcCurrent:      {&p.OutputAcCurrent},
+		SensorOutputFrequency:      {&p.OutputFrequency},
+		SensorOutputActivePower:    {&p.OutputActivePower},
+		SensorOutputApparentPower:  {&p.OutputApparentPower},
+		SensorOutputPowerFactor:    {&p.OutputPowerFactor},
+		SensorLoadPercent:          {&p.LoadPercent},
+		SensorInverterEfficiency:   {&p.InverterEfficiency},
+		SensorGeneratorVoltage:     {&p.Generator.Voltage},
+		SensorGeneratorFrequency:   {&p.Generator.Frequency},
+		SensorGeneratorFuelLevel:   {&p.Generator.FuelLevel},
+	}
+	for i := range p.Batteries {
+		bat := &p.Batteries[i]
+		res[SensorBatteryVoltage] = append(res[SensorBatteryVoltage], &bat.Voltage)
+		res[SensorBatteryTemp] = append(res[SensorBatteryTemp], &bat.Temp)
+		res[SensorBatteryResist] = append(res[SensorBatteryResist], &bat.Resist)
+	}
+	for i := range p.Phases {
+		phase := &p.Phases[i]
+		res[SensorPhaseInputAcVoltage] = append(res[SensorPhaseInputAcVoltage], &phase.InputAcVoltage)
+		res[SensorPhaseInputAcCurrent] = append(res[SensorPhaseInputAcCurrent], &phase.InputAcCurrent)
+		res[SensorPhaseOutputAcVoltage] = append(res[SensorPhaseOutputAcVoltage], &phase.OutputAcVoltage)
+		res[SensorPhaseOutputAcCurrent] = append(res[SensorPhaseOutputAcCurrent], &phase.OutputAcCurrent)
+	}
+	return res
+}
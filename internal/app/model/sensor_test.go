@@ -0,0 +1,32 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/alex11prog/ups-imitator/internal/app/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SensorsConfig_Validate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		conf    model.SensorsConfig
+		isValid bool
+	}{
+		{"valid", model.SensorsConfig{model.SensorBatteryTemp: {Noise: 0.01, Resolution: 0.5, Stuck: true}}, true},
+		{"valid, empty", nil, true},
+		{"invalid name", model.SensorsConfig{"invalid": {}}, false},
+		{"invalid noise", model.SensorsConfig{model.SensorSOC: {Noise: 2}}, false},
+		{"invalid spike probability", model.SensorsConfig{model.SensorSOC: {SpikeProbability: -0.1}}, false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.conf.Validate()
+			if tc.isValid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
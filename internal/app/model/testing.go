@@ -10,7 +10,9 @@ func TestConfig(t *testing.T) *Config {
 		UpsAddr:               "127.0.0.1:1502",
 		RestApiBindAddr:       ":8080",
 		UpsSyncInterval:       time.Second * 30,
+		ClockSpeed:            1,
 		CycleChangeTimeout:    time.Hour,
+		Cycle:                 DefaultCycle(time.Hour),
 		DefaultInputAcVoltage: 220,
 		MaxBatGroupVoltage:    54,
 		MinBatGroupVoltage:    42,
@@ -18,6 +20,112 @@ func TestConfig(t *testing.T) *Config {
 		DefaultBatCapacity:    50,
 		ChargeCurrentLimit:    20,
 		LowSocTriggerAlarm:    0.1,
+		Battery: BatteryConfig{
+			Chemistry:      ChemistryVRLA,
+			CellsPerBlock:  6,
+			RcResist:       0.02,
+			RcTimeConstant: time.Minute,
+		},
+		BatteryFaults: BatteryFaultsConfig{
+			ShortedCellHeat:    15,
+			HeatTimeConstant:   30 * time.Minute,
+			ResistanceGrowth:   2,
+			ThermalRunawayRate: 0.5,
+		},
+		Charger: ChargerConfig{
+			AbsorptionVoltage:     57.6,
+			FloatVoltage:          54.6,
+			FloatCurrentThreshold: 1,
+			EqualizeVoltage:       58.8,
+			EqualizeInterval:      time.Hour * 24 * 30,
+			EqualizeDuration:      time.Hour * 2,
+			TempCompensation:      -0.072,
+			PolarizationResist:    0.05,
+		},
+		Output: OutputConfig{
+			Voltage:             220,
+			Frequency:           50,
+			RatedApparentPower:  3000,
+			RatedActivePower:    2700,
+			LoadPowerFactor:     0.9,
+			RectifierEfficiency: 0.97,
+			Rectifier:           RectifierIgbt,
+		},
+		Input: InputConfig{
+			Frequency:       50,
+			VoltageLow:      176,
+			VoltageHigh:     264,
+			SagVoltage:      150,
+			SwellVoltage:    290,
+			FrequencyLow:    47,
+			FrequencyHigh:   53,
+			TransferDelay:   time.Second * 2,
+			RetransferDelay: time.Second * 10,
+		},
+		Bypass: BypassConfig{
+			VoltageLow:         160,
+			VoltageHigh:        276,
+			FrequencyTolerance: 2,
+		},
+		Load: LoadConfig{
+			Profile:        LoadProfileConstant,
+			DailyCurve:     DailyLoadCurve{{Hour: 0, Power: 600}, {Hour: 9, Power: 1000}, {Hour: 18, Power: 1000}},
+			Steps:          LoadSchedule{{Duration: time.Minute, Power: 1000}, {Duration: time.Minute, Power: 1500}},
+			RandomWalkStep: 50,
+			RandomWalkMin:  500,
+			RandomWalkMax:  1500,
+		},
+		Overload: OverloadConfig{
+			TripCurve: TripCurve{{Load: 1.1, Time: 60}, {Load: 1.5, Time: 10}},
+			Action:    OverloadActionBypass,
+			Cooldown:  time.Minute,
+		},
+		Generator: GeneratorConfig{
+			Enabled:                 false,
+			StartDelay:              10 * time.Second,
+			StartAttempts:           3,
+			StartFailureProbability: 0,
+			WarmUp:                  30 * time.Second,
+			RetransferDelay:         time.Minute,
+			FuelTank:                50,
+			FuelConsumption:         0.3,
+			IdleFuelConsumption:     0.5,
+			FrequencyDeviation:      0.5,
+		},
+		BatteryTest: BatteryTestConfig{
+			Duration:       10 * time.Second,
+			MinSoc:         0.5,
+			EolResist:      2,
+			WarningSoh:     0.8,
+			FailSoh:        0.5,
+			MaxVoltageDrop: 0.1,
+		},
+		ThreePhase: ThreePhaseConfig{
+			Enabled:               false,
+			LoadDistribution:      [3]float32{0.4, 0.3, 0.3},
+			PhaseLossVoltage:      110,
+			VoltageImbalanceAlarm: 3,
+			CurrentImbalanceAlarm: 20,
+		},
+		Sensors: SensorsConfig{
+			SensorInputAcVoltage: {Noise: 0.01, Resolution: 0.1},
+			SensorBatteryTemp:    {Noise: 0.02},
+		},
+		Replay: ReplayConfig{
+			Speed:   1,
+			Columns: map[string]string{"ups.temp": "battery_temp[1]"},
+		},
+		Chaos: ChaosConfig{
+			Seed:               42,
+			EventRate:          1,
+			DurationMedian:     2 * time.Minute,
+			DurationSigma:      1,
+			MaxDuration:        time.Hour,
+			FlickerProbability: 0.3,
+			FlickerCount:       5,
+			FlickerDuration:    2 * time.Second,
+			FlickerGap:         10 * time.Second,
+		},
 	}
 }
 
@@ -25,12 +133,26 @@ func TestUpsParams(t *testing.T) *UpsParams {
 	return &UpsParams{
 		InputAcVoltage:       220,
 		InputAcCurrent:       5,
+		InputFrequency:       50,
+		InputActivePower:     1100,
+		InputApparentPower:   1120,
+		InputPowerFactor:     0.98,
+		InputCurrentThd:      4,
 		BatGroupVoltage:      54,
 		BatGroupCurrent:      0,
 		LoadCurrent:          20,
 		BatCapacity:          50,
 		RemainingBatCapacity: 50,
 		SOC:                  1,
+		Runtime:              120,
+		OutputAcVoltage:      220,
+		OutputAcCurrent:      5.05,
+		OutputFrequency:      50,
+		OutputActivePower:    1000,
+		OutputApparentPower:  1111,
+		OutputPowerFactor:    0.9,
+		LoadPercent:          37,
+		InverterEfficiency:   0.92,
 		Batteries: [4]BatteryParams{
 			{
 				Voltage: 13.5,
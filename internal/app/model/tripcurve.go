@@ -0,0 +1,61 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/alex11prog/ups-imitator/internal/app/utils"
+)
+
+// Overload actions
+const (
+	OverloadActionBypass   = "bypass"   // transfer the load to the bypass, shutdown if it is unavailable
+	OverloadActionShutdown = "shutdown" // turn the output off
+)
+
+// TripPoint is a point of the inverse-time overload trip curve
+type TripPoint struct {
+	Load float32 `toml:"load"` // fraction of the rated power, above 1
+	Time float32 `toml:"time"` // sec, the overload is tolerated for
+}
+
+// TripCurve is a table of the tolerated overload time depending on load, sorted by load
+type TripCurve []TripPoint
+
+// TripTime returns the time the load is tolerated for using linear interpolation between the points.
+// Below the first point the load never trips, above the last one it trips after the time of the last point
+func (c TripCurve) TripTime(load float32) (time.Duration, bool) {
+	if len(c) == 0 || load < c[0].Load {
+		return 0, false
+	}
+	sec := c[len(c)-1].Time
+	for i := 1; i < len(c); i++ {
+		if load <= c[i].Load {
+			sec = utils.LinearInterpolate(c[i-1].Load, c[i-1].Time, c[i].Load, c[i].Time, load)
+			break
+		}
+	}
+	return time.Duration(float64(sec) * float64(time.Second)), true
+}
+
+func (c TripCurve) Validate() error {
+	if len(c) == 0 {
+		return errors.New("at least 1 point required")
+	}
+	for i, point := range c {
+		if point.Load <= 1 {
+			return fmt.Errorf("point %d: load must be above 1", i)
+		}
+		if point.Time <= 0 {
+			return fmt.Errorf("point %d: time must be positive", i)
+		}
+		if i > 0 && point.Load <= c[i-1].Load {
+			return fmt.Errorf("point %d: load must be increasing", i)
+		}
+		if i > 0 && point.Time > c[i-1].Time {
+			return fmt.Errorf("point %d: time must not increase", i)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package model_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alex11prog/ups-imitator/internal/app/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_TripCurve_TripTime(t *testing.T) {
+	curve := model.TripCurve{{Load: 1.1, Time: 60}, {Load: 1.5, Time: 10}, {Load: 2, Time: 0.5}}
+	_, trips := curve.TripTime(1.05)
+	assert.False(t, trips)
+
+	tripTime, trips := curve.TripTime(1.1)
+	assert.True(t, trips)
+	assert.Equal(t, time.Minute, tripTime)
+
+	tripTime, _ = curve.TripTime(1.3)
+	assert.InDelta(t, float64(35*time.Second), float64(tripTime), float64(time.Millisecond))
+
+	tripTime, _ = curve.TripTime(3)
+	assert.Equal(t, 500*time.Millisecond, tripTime)
+
+	_, trips = model.TripCurve{}.TripTime(3)
+	assert.False(t, trips)
+}
+
+func Test_TripCurve_Validate(t *testing.T) {
+	assert.NoError(t, model.TripCurve{{Load: 1.1, Time: 60}, {Load: 1.5, Time: 10}}.Validate())
+	assert.Error(t, model.TripCurve{}.Validate())
+	assert.Error(t, model.TripCurve{{Load: 0.9, Time: 60}}.Validate())
+	assert.Error(t, model.TripCurve{{Load: 1.1, Time: 0}}.Validate())
+	assert.Error(t, model.TripCurve{{Load: 1.5, Time: 10}, {Load: 1.1, Time: 60}}.Validate())
+	assert.Error(t, model.TripCurve{{Load: 1.1, Time: 10}, {Load: 1.5, Time: 60}}.Validate())
+}
@@ -2,15 +2,86 @@ package model
 
 import (
 	"encoding/binary"
+	"fmt"
 	"math"
 
 	"github.com/alex11prog/ups-imitator/internal/app/utils"
 )
 
+// ChargerStage is the stage of the multi-stage CC/CV battery charger
+type ChargerStage uint16
+
+const (
+	ChargerOff        ChargerStage = iota // no input power, the battery is discharging or idle
+	ChargerBulk                           // constant current up to the absorption voltage
+	ChargerAbsorption                     // constant absorption voltage, current decays
+	ChargerFloat                          // constant float voltage, the battery is charged
+	ChargerEqualize                       // periodic overcharge at the equalize voltage
+)
+
+var chargerStageNames = [...]string{"off", "bulk", "absorption", "float", "equalize"}
+
+func (s ChargerStage) String() string {
+	if int(s) < len(chargerStageNames) {
+		return chargerStageNames[s]
+	}
+	return fmt.Sprintf("unknown(%d)", uint16(s))
+}
+
+func (s ChargerStage) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s *ChargerStage) UnmarshalText(text []byte) error {
+	for i, name := range chargerStageNames {
+		if name == string(text) {
+			*s = ChargerStage(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown charger stage: %q", text)
+}
+
+// OperatingMode is the state of the power path of the UPS
+type OperatingMode uint16
+
+const (
+	ModeOnline    OperatingMode = iota // double conversion: the load is fed by the inverter, the battery is charged
+	ModeOnBattery                      // the load is fed by the inverter from the battery
+	ModeBypass                         // the load is fed from the input through the static switch, the inverter is off
+	ModeEco                            // high-efficiency mode: the load is fed through the bypass, the inverter is in standby
+	ModeShutdown                       // the output is off
+)
+
+var operatingModeNames = [...]string{"online", "on_battery", "bypass", "eco", "shutdown"}
+
+func (m OperatingMode) String() string {
+	if int(m) < len(operatingModeNames) {
+		return operatingModeNames[m]
+	}
+	return fmt.Sprintf("unknown(%d)", uint16(m))
+}
+
+func (m OperatingMode) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+func (m *OperatingMode) UnmarshalText(text []byte) error {
+	for i, name := range operatingModeNames {
+		if name == string(text) {
+			*m = OperatingMode(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown operating mode: %q", text)
+}
+
 type BatteryParams struct {
-	Voltage float32 `json:"voltage" example:"12"`
-	Temp    float32 `json:"temp" example:"24"`
-	Resist  float32 `json:"resist" example:"5"`
+	Voltage float32 `json:"voltage" example:"12"` // V
+	Temp    float32 `json:"temp" example:"24"`    // °C
+	Resist  float32 `json:"resist" example:"5"`   // mOhm, internal resistance
+
+	Faults BatteryFaults `json:"faults" swaggertype:"array,string" enums:"shorted_cell,open_cell,high_resistance,thermal_runaway"`
 }
 
 func (bat *BatteryParams) Update(form BatteryParamsUpdateForm) {
@@ -31,10 +102,46 @@ type BatteryParamsUpdateForm struct {
 	Resist  *float32 `json:"resist" example:"5"`
 }
 
+// PhaseParams are params of one phase (L1, L2, L3), phase-to-neutral.
+// In single-phase mode only L1 is used
+type PhaseParams struct {
+	InputAcVoltage  float32 `json:"input_ac_voltage" example:"220"`  // V
+	InputAcCurrent  float32 `json:"input_ac_current" example:"1.7"`  // Amp
+	OutputAcVoltage float32 `json:"output_ac_voltage" example:"220"` // V
+	OutputAcCurrent float32 `json:"output_ac_current" example:"1.7"` // Amp
+}
+
+func (p *PhaseParams) Update(form PhaseParamsUpdateForm) {
+	if form.InputAcVoltage != nil {
+		p.InputAcVoltage = *form.InputAcVoltage
+	}
+	if form.InputAcCurrent != nil {
+		p.InputAcCurrent = *form.InputAcCurrent
+	}
+	if form.OutputAcVoltage != nil {
+		p.OutputAcVoltage = *form.OutputAcVoltage
+	}
+	if form.OutputAcCurrent != nil {
+		p.OutputAcCurrent = *form.OutputAcCurrent
+	}
+}
+
+type PhaseParamsUpdateForm struct {
+	InputAcVoltage  *float32 `json:"input_ac_voltage" example:"220"`  // V
+	InputAcCurrent  *float32 `json:"input_ac_current" example:"1.7"`  // Amp
+	OutputAcVoltage *float32 `json:"output_ac_voltage" example:"220"` // V
+	OutputAcCurrent *float32 `json:"output_ac_current" example:"1.7"` // Amp
+}
+
 type Alarms struct {
-	UpcInBatteryMode bool `json:"upc_in_battery_mode" example:"false"`
-	LowBattery       bool `json:"low_battery" example:"false"`
-	Overload         bool `json:"overload" example:"false"`
+	UpcInBatteryMode  bool `json:"upc_in_battery_mode" example:"false"`
+	LowBattery        bool `json:"low_battery" example:"false"`
+	Overload          bool `json:"overload" example:"false"`
+	PhaseLoss         bool `json:"phase_loss" example:"false"`
+	PhaseImbalance    bool `json:"phase_imbalance" example:"false"`
+	InputFault        bool `json:"input_fault" example:"false"` // input voltage or frequency out of the windows
+	OnBypass          bool `json:"on_bypass" example:"false"`
+	BypassUnavailable bool `json:"bypass_unavailable" example:"false"` // bypass input out of the window or not synchronized
 }
 
 func (a *Alarms) Update(form AlarmsUpdateForm) {
@@ -47,26 +154,86 @@ func (a *Alarms) Update(form AlarmsUpdateForm) {
 	if form.Overload != nil {
 		a.Overload = *form.Overload
 	}
+	if form.PhaseLoss != nil {
+		a.PhaseLoss = *form.PhaseLoss
+	}
+	if form.PhaseImbalance != nil {
+		a.PhaseImbalance = *form.PhaseImbalance
+	}
+	if form.InputFault != nil {
+		a.InputFault = *form.InputFault
+	}
+	if form.OnBypass != nil {
+		a.OnBypass = *form.OnBypass
+	}
+	if form.BypassUnavailable != nil {
+		a.BypassUnavailable = *form.BypassUnavailable
+	}
+}
+
+// ByName returns pointers to the alarms by the json name
+func (a *Alarms) ByName() map[string]*bool {
+	return map[string]*bool{
+		"upc_in_battery_mode": &a.UpcInBatteryMode,
+		"low_battery":         &a.LowBattery,
+		"overload":            &a.Overload,
+		"phase_loss":          &a.PhaseLoss,
+		"phase_imbalance":     &a.PhaseImbalance,
+		"input_fault":         &a.InputFault,
+		"on_bypass":           &a.OnBypass,
+		"bypass_unavailable":  &a.BypassUnavailable,
+	}
+}
+
+// bits returns alarms in the order of the coils
+func (a *Alarms) bits() []bool {
+	return []bool{a.UpcInBatteryMode, a.LowBattery, a.Overload, a.PhaseLoss, a.PhaseImbalance, a.InputFault,
+		a.OnBypass, a.BypassUnavailable}
 }
 
 type AlarmsUpdateForm struct {
-	UpcInBatteryMode *bool `json:"upc_in_battery_mode" example:"false"`
-	LowBattery       *bool `json:"low_battery" example:"false"`
-	Overload         *bool `json:"overload" example:"false"`
+	UpcInBatteryMode  *bool `json:"upc_in_battery_mode" example:"false"`
+	LowBattery        *bool `json:"low_battery" example:"false"`
+	Overload          *bool `json:"overload" example:"false"`
+	PhaseLoss         *bool `json:"phase_loss" example:"false"`
+	PhaseImbalance    *bool `json:"phase_imbalance" example:"false"`
+	InputFault        *bool `json:"input_fault" example:"false"`
+	OnBypass          *bool `json:"on_bypass" example:"false"`
+	BypassUnavailable *bool `json:"bypass_unavailable" example:"false"`
 }
 
 type UpsParams struct {
-	InputAcVoltage       float32          `json:"input_ac_voltage" example:"220"`          // V
-	InputAcCurrent       float32          `json:"input_ac_current" example:"5"`            // Amp
-	BatGroupVoltage      float32          `json:"bat_group_voltage" example:"48"`          // V
-	BatGroupCurrent      float32          `json:"bat_group_current" example:"0"`           // Amp
-	LoadCurrent          float32          `json:"load_current" example:"20"`               // Amp
-	BatCapacity          float32          `json:"battery_capacity" example:"50"`           // Ah
-	RemainingBatCapacity float32          `json:"remaining_battery_capacity" example:"50"` // Ah
-	SOC                  float32          `json:"soc" example:"100"`                       // state of charge (percent)
-	Batteries            [4]BatteryParams `json:"batteries"`
+	InputAcVoltage       float32           `json:"input_ac_voltage" example:"220"`          // V
+	InputAcCurrent       float32           `json:"input_ac_current" example:"5"`            // Amp
+	InputFrequency       float32           `json:"input_frequency" example:"50"`            // Hz
+	InputActivePower     float32           `json:"input_active_power" example:"1100"`       // W
+	InputApparentPower   float32           `json:"input_apparent_power" example:"1120"`     // VA
+	InputPowerFactor     float32           `json:"input_power_factor" example:"0.98"`       // from 0 to 1
+	InputCurrentThd      float32           `json:"input_current_thd" example:"4"`           // percent
+	BatGroupVoltage      float32           `json:"bat_group_voltage" example:"48"`          // V
+	BatGroupCurrent      float32           `json:"bat_group_current" example:"0"`           // Amp
+	LoadCurrent          float32           `json:"load_current" example:"20"`               // Amp
+	BatCapacity          float32           `json:"battery_capacity" example:"50"`           // Ah
+	RemainingBatCapacity float32           `json:"remaining_battery_capacity" example:"50"` // Ah
+	SOC                  float32           `json:"soc" example:"100"`                       // state of charge (percent)
+	Runtime              float32           `json:"runtime" example:"25"`                    // min, estimated time to empty at the present load
+	ChargerStage         ChargerStage      `json:"charger_stage" swaggertype:"string" enums:"off,bulk,absorption,float,equalize" example:"float"`
+	OperatingMode        OperatingMode     `json:"operating_mode" swaggertype:"string" enums:"online,on_battery,bypass,eco,shutdown" example:"online"`
+	OutputAcVoltage      float32           `json:"output_ac_voltage" example:"220"`      // V
+	OutputAcCurrent      float32           `json:"output_ac_current" example:"5"`        // Amp
+	OutputFrequency      float32           `json:"output_frequency" example:"50"`        // Hz
+	OutputActivePower    float32           `json:"output_active_power" example:"1000"`   // W
+	OutputApparentPower  float32           `json:"output_apparent_power" example:"1111"` // VA
+	OutputPowerFactor    float32           `json:"output_power_factor" example:"0.9"`    // from 0 to 1, of the load
+	LoadPercent          float32           `json:"load_percent" example:"37"`            // percent of the rated power
+	InverterEfficiency   float32           `json:"inverter_efficiency" example:"0.94"`   // from 0 to 1
+	Batteries            [4]BatteryParams  `json:"batteries"`
+	Phases               [3]PhaseParams    `json:"phases"`
+	Generator            GeneratorParams   `json:"generator"`
+	BatteryTest          BatteryTestParams `json:"battery_test"`
 
 	Alarms Alarms `json:"alarms"`
+	Pins   []Pin  `json:"pins"` // held by the user, see Pin
 }
 
 func (ups *UpsParams) Update(form UpsParamsUpdateForm) {
@@ -76,6 +243,9 @@ func (ups *UpsParams) Update(form UpsParamsUpdateForm) {
 	if form.InputAcCurrent != nil {
 		ups.InputAcCurrent = *form.InputAcCurrent
 	}
+	if form.InputFrequency != nil {
+		ups.InputFrequency = *form.InputFrequency
+	}
 	if form.BatGroupVoltage != nil {
 		ups.BatGroupVoltage = *form.BatGroupVoltage
 	}
@@ -87,14 +257,16 @@ func (ups *UpsParams) Update(form UpsParamsUpdateForm) {
 type UpsParamsUpdateForm struct {
 	InputAcVoltage  *float32 `json:"input_ac_voltage" example:"220"` // V
 	InputAcCurrent  *float32 `json:"input_ac_current" example:"5"`   // Amp
+	InputFrequency  *float32 `json:"input_frequency" example:"50"`   // Hz
 	BatGroupVoltage *float32 `json:"bat_group_voltage" example:"48"` // V
 	BatGroupCurrent *float32 `json:"bat_group_current" example:"0"`  // Amp
 }
 
 func (ups *UpsParams) GetParamBytes() []byte {
-	res := make([]byte, RegBattery4Res*2 +4 )
+	res := make([]byte, RegBattery4Res*2+4)
 	binary.BigEndian.PutUint32(res[RegInputAcVoltage*2:], math.Float32bits(ups.InputAcVoltage))
 	binary.BigEndian.PutUint32(res[RegInputAcCurrent*2:], math.Float32bits(ups.InputAcCurrent))
+	binary.BigEndian.PutUint32(res[RegInputFrequency*2:], math.Float32bits(ups.InputFrequency))
 	binary.BigEndian.PutUint32(res[RegBatteryGroupVoltage*2:], math.Float32bits(ups.BatGroupVoltage))
 	binary.BigEndian.PutUint32(res[RegBatteryGroupCurrent*2:], math.Float32bits(ups.BatGroupCurrent))
 	for i, battery := range ups.Batteries {
@@ -106,10 +278,56 @@ func (ups *UpsParams) GetParamBytes() []byte {
 	return res
 }
 
+// GetExtParamBytes returns the extended register block starting at RegExtParamsStart
+func (ups *UpsParams) GetExtParamBytes() []byte {
+	res := make([]byte, (RegExtParamsEnd-RegExtParamsStart)*2)
+	binary.BigEndian.PutUint16(res[(RegChargerStage-RegExtParamsStart)*2:], uint16(ups.ChargerStage))
+	binary.BigEndian.PutUint16(res[(RegOperatingMode-RegExtParamsStart)*2:], uint16(ups.OperatingMode))
+	for i, battery := range ups.Batteries {
+		binary.BigEndian.PutUint16(res[(RegBattery1Faults+uint16(i)-RegExtParamsStart)*2:], uint16(battery.Faults))
+	}
+	putFloat32 := func(reg uint16, val float32) {
+		binary.BigEndian.PutUint32(res[(reg-RegExtParamsStart)*2:], math.Float32bits(val))
+	}
+	putFloat32(RegRuntime, ups.Runtime)
+	putFloat32(RegOutputAcVoltage, ups.OutputAcVoltage)
+	putFloat32(RegOutputAcCurrent, ups.OutputAcCurrent)
+	putFloat32(RegOutputFrequency, ups.OutputFrequency)
+	putFloat32(RegOutputActivePower, ups.OutputActivePower)
+	putFloat32(RegOutputApparentPower, ups.OutputApparentPower)
+	putFloat32(RegLoadPercent, ups.LoadPercent)
+	putFloat32(RegInverterEfficiency, ups.InverterEfficiency)
+	for i, phase := range ups.Phases {
+		offset := uint16(i) * 2
+		putFloat32(RegPhase1InputAcVoltage+offset, phase.InputAcVoltage)
+		putFloat32(RegPhase1InputAcCurrent+offset, phase.InputAcCurrent)
+		putFloat32(RegPhase1OutputAcVoltage+offset, phase.OutputAcVoltage)
+		putFloat32(RegPhase1OutputAcCurrent+offset, phase.OutputAcCurrent)
+	}
+	putFloat32(RegInputActivePower, ups.InputActivePower)
+	putFloat32(RegInputApparentPower, ups.InputApparentPower)
+	putFloat32(RegInputPowerFactor, ups.InputPowerFactor)
+	putFloat32(RegInputCurrentThd, ups.InputCurrentThd)
+	putFloat32(RegOutputPowerFactor, ups.OutputPowerFactor)
+	binary.BigEndian.PutUint16(res[(RegGeneratorState-RegExtParamsStart)*2:], uint16(ups.Generator.State))
+	binary.BigEndian.PutUint16(res[(RegAtsSource-RegExtParamsStart)*2:], uint16(ups.Generator.Source))
+	putFloat32(RegGeneratorFuelLevel, ups.Generator.FuelLevel)
+	putFloat32(RegGeneratorVoltage, ups.Generator.Voltage)
+	putFloat32(RegGeneratorFrequency, ups.Generator.Frequency)
+	binary.BigEndian.PutUint16(res[(RegBatteryTestResult-RegExtParamsStart)*2:], uint16(ups.BatteryTest.Result))
+	if ups.BatteryTest.Time != nil {
+		binary.BigEndian.PutUint32(res[(RegBatteryTestTime-RegExtParamsStart)*2:], uint32(ups.BatteryTest.Time.Unix()))
+	}
+	putFloat32(RegBatteryTestResist, ups.BatteryTest.Resist)
+	putFloat32(RegBatteryTestSoh, ups.BatteryTest.SOH)
+	return res
+}
+
 func (ups *UpsParams) GetAlarmBytes() []byte {
-	var alarmsBitwise byte
-	alarmsBitwise |= utils.Bool2byte(ups.Alarms.UpcInBatteryMode)
-	alarmsBitwise |= utils.Bool2byte(ups.Alarms.LowBattery) << 1
-	alarmsBitwise |= utils.Bool2byte(ups.Alarms.Overload) << 2
-	return []byte{alarmsBitwise}
+	bits := ups.Alarms.bits()
+	res := make([]byte, (len(bits)+7)/8)
+	for i, bit := range bits {
+		res[i/8] |= utils.Bool2byte(bit) << (i % 8)
+	}
+	return res
 }
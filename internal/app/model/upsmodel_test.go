@@ -4,6 +4,7 @@ import (
 	"encoding/binary"
 	"math"
 	"testing"
+	"time"
 
 	"github.com/alex11prog/ups-imitator/internal/app/model"
 	"github.com/alex11prog/ups-imitator/internal/app/utils"
@@ -42,6 +43,18 @@ func Test_BatteryParams_Update(t *testing.T) {
 	}
 }
 
+func Test_PhaseParams_Update(t *testing.T) {
+	phaseParams := model.PhaseParams{InputAcVoltage: 220, InputAcCurrent: 2, OutputAcVoltage: 220, OutputAcCurrent: 1.5}
+	phaseParams.Update(model.PhaseParamsUpdateForm{InputAcVoltage: utils.NewP(float32(0))})
+	assert.Equal(t, model.PhaseParams{InputAcVoltage: 0, InputAcCurrent: 2, OutputAcVoltage: 220, OutputAcCurrent: 1.5}, phaseParams)
+	phaseParams.Update(model.PhaseParamsUpdateForm{
+		InputAcCurrent:  utils.NewP(float32(0)),
+		OutputAcVoltage: utils.NewP(float32(230)),
+		OutputAcCurrent: utils.NewP(float32(3)),
+	})
+	assert.Equal(t, model.PhaseParams{InputAcVoltage: 0, InputAcCurrent: 0, OutputAcVoltage: 230, OutputAcCurrent: 3}, phaseParams)
+}
+
 func Test_Alarms_Update(t *testing.T) {
 	alarms := model.Alarms{}
 	testCases := []struct {
@@ -64,6 +77,33 @@ func Test_Alarms_Update(t *testing.T) {
 			updateForm: model.AlarmsUpdateForm{Overload: utils.NewP(true)},
 			expected:   model.Alarms{UpcInBatteryMode: true, LowBattery: true, Overload: true},
 		},
+		{
+			name:       "PhaseLoss",
+			updateForm: model.AlarmsUpdateForm{PhaseLoss: utils.NewP(true)},
+			expected:   model.Alarms{UpcInBatteryMode: true, LowBattery: true, Overload: true, PhaseLoss: true},
+		},
+		{
+			name:       "PhaseImbalance",
+			updateForm: model.AlarmsUpdateForm{PhaseImbalance: utils.NewP(true)},
+			expected:   model.Alarms{UpcInBatteryMode: true, LowBattery: true, Overload: true, PhaseLoss: true, PhaseImbalance: true},
+		},
+		{
+			name:       "InputFault",
+			updateForm: model.AlarmsUpdateForm{InputFault: utils.NewP(true)},
+			expected:   model.Alarms{UpcInBatteryMode: true, LowBattery: true, Overload: true, PhaseLoss: true, PhaseImbalance: true, InputFault: true},
+		},
+		{
+			name:       "OnBypass",
+			updateForm: model.AlarmsUpdateForm{OnBypass: utils.NewP(true)},
+			expected: model.Alarms{UpcInBatteryMode: true, LowBattery: true, Overload: true, PhaseLoss: true, PhaseImbalance: true, InputFault: true,
+				OnBypass: true},
+		},
+		{
+			name:       "BypassUnavailable",
+			updateForm: model.AlarmsUpdateForm{BypassUnavailable: utils.NewP(true)},
+			expected: model.Alarms{UpcInBatteryMode: true, LowBattery: true, Overload: true, PhaseLoss: true, PhaseImbalance: true, InputFault: true,
+				OnBypass: true, BypassUnavailable: true},
+		},
 	}
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
@@ -108,6 +148,20 @@ func Test_UpsParams_Update(t *testing.T) {
 				return params
 			},
 		},
+		{
+			name: "InputFrequency",
+			src: func() *model.UpsParams {
+				return model.TestUpsParams(t)
+			},
+			updateForm: model.UpsParamsUpdateForm{
+				InputFrequency: utils.NewP(float32(49.5)),
+			},
+			expected: func() *model.UpsParams {
+				params := model.TestUpsParams(t)
+				params.InputFrequency = 49.5
+				return params
+			},
+		},
 		{
 			name: "BatGroupVoltage",
 			src: func() *model.UpsParams {
@@ -160,6 +214,7 @@ func Test_UpsParams_GetParamBytes(t *testing.T) {
 	assert.Equal(t, upsParams.InputAcCurrent, receivedUpsParams.InputAcCurrent)
 	assert.Equal(t, upsParams.BatGroupVoltage, receivedUpsParams.BatGroupVoltage)
 	assert.Equal(t, upsParams.BatGroupCurrent, receivedUpsParams.BatGroupCurrent)
+	assert.Equal(t, upsParams.InputFrequency, math.Float32frombits(binary.BigEndian.Uint32(paramBytes[model.RegInputFrequency*2:])))
 	for i := range 4 {
 		start := 32 * (i + 1)
 		receivedUpsParams.Batteries[i].Voltage = math.Float32frombits(binary.BigEndian.Uint32(paramBytes[start:]))
@@ -169,6 +224,83 @@ func Test_UpsParams_GetParamBytes(t *testing.T) {
 	}
 }
 
+func Test_UpsParams_GetExtParamBytes(t *testing.T) {
+	upsParams := model.TestUpsParams(t)
+	upsParams.ChargerStage = model.ChargerAbsorption
+	upsParams.OperatingMode = model.ModeEco
+	upsParams.Batteries[3].Faults = model.FaultOpenCell | model.FaultThermalRunaway
+	upsParams.Generator = model.GeneratorParams{State: model.GeneratorRunning, Source: model.SourceGenerator, FuelLevel: 0.75, Voltage: 230, Frequency: 50.3}
+	testTime := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	upsParams.BatteryTest = model.BatteryTestParams{Result: model.BatteryTestWarning, Time: &testTime, Resist: 31, SOH: 0.7}
+	extParamBytes := upsParams.GetExtParamBytes()
+	require.Equal(t, int(model.RegExtParamsEnd-model.RegExtParamsStart)*2, len(extParamBytes))
+	offset := (model.RegChargerStage - model.RegExtParamsStart) * 2
+	assert.Equal(t, uint16(model.ChargerAbsorption), binary.BigEndian.Uint16(extParamBytes[offset:]))
+	offset = (model.RegOperatingMode - model.RegExtParamsStart) * 2
+	assert.Equal(t, uint16(model.ModeEco), binary.BigEndian.Uint16(extParamBytes[offset:]))
+	offset = (model.RegBattery1Faults + 3 - model.RegExtParamsStart) * 2
+	assert.Equal(t, uint16(0b1010), binary.BigEndian.Uint16(extParamBytes[offset:]))
+	offset = (model.RegGeneratorState - model.RegExtParamsStart) * 2
+	assert.Equal(t, uint16(model.GeneratorRunning), binary.BigEndian.Uint16(extParamBytes[offset:]))
+	offset = (model.RegAtsSource - model.RegExtParamsStart) * 2
+	assert.Equal(t, uint16(model.SourceGenerator), binary.BigEndian.Uint16(extParamBytes[offset:]))
+	offset = (model.RegBatteryTestResult - model.RegExtParamsStart) * 2
+	assert.Equal(t, uint16(model.BatteryTestWarning), binary.BigEndian.Uint16(extParamBytes[offset:]))
+	offset = (model.RegBatteryTestTime - model.RegExtParamsStart) * 2
+	assert.Equal(t, uint32(testTime.Unix()), binary.BigEndian.Uint32(extParamBytes[offset:]))
+	float32At := func(reg uint16) float32 {
+		return math.Float32frombits(binary.BigEndian.Uint32(extParamBytes[(reg-model.RegExtParamsStart)*2:]))
+	}
+	assert.Equal(t, upsParams.Runtime, float32At(model.RegRuntime))
+	assert.Equal(t, upsParams.OutputAcVoltage, float32At(model.RegOutputAcVoltage))
+	assert.Equal(t, upsParams.OutputAcCurrent, float32At(model.RegOutputAcCurrent))
+	assert.Equal(t, upsParams.OutputFrequency, float32At(model.RegOutputFrequency))
+	assert.Equal(t, upsParams.OutputActivePower, float32At(model.RegOutputActivePower))
+	assert.Equal(t, upsParams.OutputApparentPower, float32At(model.RegOutputApparentPower))
+	assert.Equal(t, upsParams.LoadPercent, float32At(model.RegLoadPercent))
+	assert.Equal(t, upsParams.InverterEfficiency, float32At(model.RegInverterEfficiency))
+	assert.Equal(t, upsParams.Generator.FuelLevel, float32At(model.RegGeneratorFuelLevel))
+	assert.Equal(t, upsParams.InputActivePower, float32At(model.RegInputActivePower))
+	assert.Equal(t, upsParams.InputApparentPower, float32At(model.RegInputApparentPower))
+	assert.Equal(t, upsParams.InputPowerFactor, float32At(model.RegInputPowerFactor))
+	assert.Equal(t, upsParams.InputCurrentThd, float32At(model.RegInputCurrentThd))
+	assert.Equal(t, upsParams.OutputPowerFactor, float32At(model.RegOutputPowerFactor))
+	assert.Equal(t, upsParams.Generator.Voltage, float32At(model.RegGeneratorVoltage))
+	assert.Equal(t, upsParams.Generator.Frequency, float32At(model.RegGeneratorFrequency))
+	assert.Equal(t, upsParams.BatteryTest.Resist, float32At(model.RegBatteryTestResist))
+	assert.Equal(t, upsParams.BatteryTest.SOH, float32At(model.RegBatteryTestSoh))
+	upsParams.Phases[2] = model.PhaseParams{InputAcVoltage: 221, InputAcCurrent: 2, OutputAcVoltage: 219, OutputAcCurrent: 1.5}
+	extParamBytes = upsParams.GetExtParamBytes()
+	assert.Equal(t, upsParams.Phases[2].InputAcVoltage, float32At(model.RegPhase1InputAcVoltage+4))
+	assert.Equal(t, upsParams.Phases[2].InputAcCurrent, float32At(model.RegPhase1InputAcCurrent+4))
+	assert.Equal(t, upsParams.Phases[2].OutputAcVoltage, float32At(model.RegPhase1OutputAcVoltage+4))
+	assert.Equal(t, upsParams.Phases[2].OutputAcCurrent, float32At(model.RegPhase1OutputAcCurrent+4))
+}
+
+func Test_ChargerStage_Text(t *testing.T) {
+	for _, stage := range []model.ChargerStage{model.ChargerOff, model.ChargerBulk, model.ChargerAbsorption, model.ChargerFloat, model.ChargerEqualize} {
+		text, err := stage.MarshalText()
+		require.NoError(t, err)
+		var received model.ChargerStage
+		require.NoError(t, received.UnmarshalText(text))
+		assert.Equal(t, stage, received)
+	}
+	var stage model.ChargerStage
+	assert.Error(t, stage.UnmarshalText([]byte("invalid")))
+}
+
+func Test_OperatingMode_Text(t *testing.T) {
+	for _, mode := range []model.OperatingMode{model.ModeOnline, model.ModeOnBattery, model.ModeBypass, model.ModeEco, model.ModeShutdown} {
+		text, err := mode.MarshalText()
+		require.NoError(t, err)
+		var received model.OperatingMode
+		require.NoError(t, received.UnmarshalText(text))
+		assert.Equal(t, mode, received)
+	}
+	var mode model.OperatingMode
+	assert.Error(t, mode.UnmarshalText([]byte("invalid")))
+}
+
 func Test_UpsParams_GetAlarmBytes(t *testing.T) {
 	upsParams := model.UpsParams{
 		Alarms: model.Alarms{
@@ -178,4 +310,37 @@ func Test_UpsParams_GetAlarmBytes(t *testing.T) {
 		},
 	}
 	assert.Equal(t, []byte{0b00000101}, upsParams.GetAlarmBytes())
+
+	upsParams.Alarms.PhaseImbalance = true
+	assert.Equal(t, []byte{0b00010101}, upsParams.GetAlarmBytes())
+	upsParams.Alarms.InputFault = true
+	assert.Equal(t, []byte{0b00110101}, upsParams.GetAlarmBytes())
+	upsParams.Alarms.BypassUnavailable = true
+	assert.Equal(t, []byte{0b10110101}, upsParams.GetAlarmBytes())
+}
+
+func Test_GeneratorState_Text(t *testing.T) {
+	for _, state := range []model.GeneratorState{model.GeneratorStandby, model.GeneratorStarting, model.GeneratorWarmUp, model.GeneratorRunning, model.GeneratorFailed} {
+		text, err := state.MarshalText()
+		require.NoError(t, err)
+		var received model.GeneratorState
+		require.NoError(t, received.UnmarshalText(text))
+		assert.Equal(t, state, received)
+	}
+	var source model.AtsSource
+	require.NoError(t, source.UnmarshalText([]byte("generator")))
+	assert.Equal(t, model.SourceGenerator, source)
+	assert.Error(t, source.UnmarshalText([]byte("invalid")))
+}
+
+func Test_BatteryTestResult_Text(t *testing.T) {
+	for _, result := range []model.BatteryTestResult{model.BatteryTestNone, model.BatteryTestRunning, model.BatteryTestPassed, model.BatteryTestWarning, model.BatteryTestFailed, model.BatteryTestAborted} {
+		text, err := result.MarshalText()
+		require.NoError(t, err)
+		var received model.BatteryTestResult
+		require.NoError(t, received.UnmarshalText(text))
+		assert.Equal(t, result, received)
+	}
+	var result model.BatteryTestResult
+	assert.Error(t, result.UnmarshalText([]byte("invalid")))
 }
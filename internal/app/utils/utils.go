@@ -1,7 +1,5 @@
 package utils
 
-import "math/rand"
-
 func Bool2byte(val bool) byte {
 	if val {
 		return 1
@@ -9,14 +7,12 @@ func Bool2byte(val bool) byte {
 	return 0
 }
 
-// SimulateMeasErr simulates measure error
-// errDev - err deviation. for example 0.1 (percent)
-func SimulateMeasErr(errDev, srcVal float32) float32 {
-	measErrCoef := rand.Float32() * 2 - 1 // from - 1 to 1
-	return srcVal + measErrCoef * (srcVal * errDev)
+// LinearInterpolate returns the value at x on the line through (x0, y0) and (x1, y1)
+func LinearInterpolate(x0, y0, x1, y1, x float32) float32 {
+	return y0 + (x-x0)*(y1-y0)/(x1-x0)
 }
 
 // NewP returns pointer of value
 func NewP[V any](v V) *V {
 	return &v
-}
\ No newline at end of file
+}
@@ -1,8 +1,6 @@
 package utils_test
 
 import (
-	"math"
-	"math/rand"
 	"testing"
 
 	"github.com/alex11prog/ups-imitator/internal/app/utils"
@@ -14,11 +12,8 @@ func Test_Bool2byte(t *testing.T) {
 	assert.Equal(t, utils.Bool2byte(false), byte(0))
 }
 
-func Test_SimulateMeasErr(t *testing.T) {
-	for i := 0; i < 100; i++ {
-		src := rand.Float32() * 100
-		res := utils.SimulateMeasErr(0.1, src)
-
-		assert.GreaterOrEqual(t, 0.1, math.Abs(float64((src-res)/src)))
-	}
+func Test_LinearInterpolate(t *testing.T) {
+	assert.Equal(t, float32(15), utils.LinearInterpolate(1, 10, 3, 20, 2))
+	assert.Equal(t, float32(10), utils.LinearInterpolate(1, 10, 3, 20, 1))
+	assert.Equal(t, float32(25), utils.LinearInterpolate(1, 10, 3, 20, 4))
 }